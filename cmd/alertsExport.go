@@ -0,0 +1,223 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/alertexport"
+	"github.com/EpykLab/wazctl/pkg/alertquery"
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+	"github.com/spf13/cobra"
+)
+
+// alertsExportCmd represents the alerts export command
+var alertsExportCmd = &cobra.Command{
+	Use:   "export [query]...",
+	Short: "export every alert matching a search to NDJSON or CSV",
+	Long: `Writes every alert matching the filter flags and query (see alerts search
+for the syntax), oldest first, to a file or stdout. Alerts are read from an
+OpenSearch point in time with search_after, so exports are not limited to
+10000 results and alerts indexed meanwhile do not shift the pages. Without
+[--until] the window ends when the export starts.
+
+NDJSON writes one search hit per line with its _index, _id and _source; CSV
+writes a header and the [--fields] of each alert, dotted paths such as
+data.srcip, plus _id and _index.
+
+Exports to a file record their progress in a checkpoint next to it after
+every page. If an export is interrupted, running it again with the same
+[--out] resumes where it stopped, with the query, format and fields of the
+checkpoint, and the checkpoint is removed once the export completes.`,
+	Example: `  wazctl alerts export --since 2025-06-01 --until 2025-06-03 --out incident.ndjson.gz --gzip
+  wazctl alerts export --since 7d --agent web-1 --format csv --fields timestamp,rule.id,data.srcip --out web-1.csv
+  wazctl alerts export --since 1h --query 'group:authentication_failed' | jq -r ._source.data.srcip`,
+	Run: func(cmd *cobra.Command, args []string) {
+		out := cmd.Flag("out").Value.String()
+		checkpointPath := cmd.Flag("checkpoint").Value.String()
+		pageSize, _ := cmd.Flags().GetInt("page-size")
+		force := cmd.Flag("force").Changed
+
+		if pageSize < 1 || pageSize > maxSearchSize {
+			fmt.Printf("[--page-size] must be between 1 and %d\n", maxSearchSize)
+			os.Exit(1)
+		}
+		if out == "" && checkpointPath != "" {
+			fmt.Println("[--checkpoint] needs [--out]")
+			os.Exit(1)
+		}
+		if out != "" && checkpointPath == "" {
+			checkpointPath = out + ".checkpoint"
+		}
+
+		var checkpoint *alertexport.Checkpoint
+		if checkpointPath != "" && !force {
+			var err error
+			if checkpoint, err = alertexport.LoadCheckpoint(checkpointPath); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		if checkpoint != nil {
+			if checkpoint.Output != out {
+				fmt.Printf("checkpoint %s is for %s, not %s\n", checkpointPath, checkpoint.Output, out)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "resuming export to %s after %d of %d alerts, using the query and format of %s\n",
+				out, checkpoint.Exported, checkpoint.Total, checkpointPath)
+		} else {
+			var err error
+			if checkpoint, err = newExportCheckpoint(cmd, args, out); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if out != "" && !force {
+				if _, err := os.Stat(out); err == nil {
+					fmt.Printf("%s exists, use --force to overwrite\n", out)
+					os.Exit(1)
+				}
+			}
+		}
+
+		var writer *alertexport.Writer
+		if out == "" {
+			var err error
+			if writer, err = alertexport.NewWriter(os.Stdout, checkpoint.Format, checkpoint.Gzip, checkpoint.Fields, 0); err != nil {
+				log.Fatalln(err)
+			}
+		} else {
+			w, f, err := alertexport.Open(out, checkpoint.Format, checkpoint.Gzip, checkpoint.Fields, checkpoint.Offset)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer f.Close()
+			writer = w
+			checkpoint.Offset = writer.Offset()
+			if err := checkpoint.Save(checkpointPath); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		export := alertquery.NewExport(actions.IndexerClientFactory(), string(opensearch.AlertsIndexPattern), checkpoint.Query)
+		export.PageSize = pageSize
+		export.After = checkpoint.After
+
+		err := export.Run(ctx, func(hits []opensearch.SearchHit) error {
+			if err := writer.WritePage(hits); err != nil {
+				return err
+			}
+			checkpoint.After = hits[len(hits)-1].Sort
+			checkpoint.Offset = writer.Offset()
+			checkpoint.Exported += len(hits)
+			checkpoint.Total = export.Total
+			if checkpointPath != "" {
+				if err := checkpoint.Save(checkpointPath); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(os.Stderr, "\rexported %d of %d alerts", checkpoint.Exported, checkpoint.Total)
+			return nil
+		})
+		if checkpoint.Exported > 0 {
+			fmt.Fprintln(os.Stderr)
+		}
+
+		switch {
+		case errors.Is(err, context.Canceled):
+			if checkpointPath != "" {
+				fmt.Fprintf(os.Stderr, "interrupted, run the same command again to resume from %s\n", checkpointPath)
+			}
+			os.Exit(1)
+		case err != nil:
+			if checkpointPath != "" {
+				log.Printf("export stopped, run the same command again to resume from %s", checkpointPath)
+			}
+			log.Fatalln(err)
+		}
+
+		if checkpointPath != "" {
+			if err := os.Remove(checkpointPath); err != nil {
+				log.Println(err)
+			}
+			fmt.Fprintf(os.Stderr, "exported %d alerts to %s\n", checkpoint.Exported, out)
+		}
+	},
+}
+
+// newExportCheckpoint returns the starting point of an export to out from
+// the command flags. The window is closed at the current time when no
+// [--until] is given, so a resumed export covers the same alerts.
+func newExportCheckpoint(cmd *cobra.Command, args []string, out string) (*alertexport.Checkpoint, error) {
+	format, err := alertexport.ParseFormat(cmd.Flag("format").Value.String())
+	if err != nil {
+		return nil, err
+	}
+	fields, _ := cmd.Flags().GetStringSlice("fields")
+	if len(fields) > 0 && format != alertexport.FormatCsv {
+		return nil, fmt.Errorf("[--fields] is only used with --format csv")
+	}
+	if format == alertexport.FormatCsv && len(fields) == 0 {
+		fields = alertexport.DefaultFields
+	}
+
+	if !cmd.Flag("until").Changed {
+		_ = cmd.Flags().Set("until", time.Now().UTC().Format(time.RFC3339Nano))
+	}
+	if q := cmd.Flag("query").Value.String(); q != "" {
+		args = append(args, q)
+	}
+	query, err := alertQueryFromFlags(cmd, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &alertexport.Checkpoint{
+		Output: out,
+		Format: format,
+		Gzip:   cmd.Flag("gzip").Changed,
+		Fields: fields,
+		Query:  query,
+	}, nil
+}
+
+func init() {
+	addAlertFilterFlags(alertsExportCmd, "24h")
+	alertsExportCmd.Flags().StringP("query", "q", "", "query in the alerts search syntax, in addition to any given as arguments")
+	alertsExportCmd.Flags().String("format", string(alertexport.FormatNdjson), "file format [ndjson, csv]")
+	alertsExportCmd.Flags().StringSlice("fields", nil, "alert fields written to CSV (default "+strings.Join(alertexport.DefaultFields, ",")+")")
+	alertsExportCmd.Flags().Bool("gzip", false, "compress the output with gzip")
+	alertsExportCmd.Flags().String("out", "", "file to write the alerts to instead of stdout")
+	alertsExportCmd.Flags().String("checkpoint", "", "checkpoint file recording progress (defaults to <out>.checkpoint)")
+	alertsExportCmd.Flags().Int("page-size", 1000, "alerts fetched per request")
+	alertsExportCmd.Flags().Bool("force", false, "overwrite the output and ignore any checkpoint")
+}
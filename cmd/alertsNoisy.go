@@ -0,0 +1,297 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EpykLab/wazctl/internal/files"
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/internal/rulexml"
+	"github.com/EpykLab/wazctl/internal/templates/rules"
+	"github.com/EpykLab/wazctl/internal/timeframe"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/noise"
+	"github.com/EpykLab/wazctl/pkg/ruletest"
+	"github.com/spf13/cobra"
+)
+
+// alertsNoisyCmd represents the alerts noisy command
+var alertsNoisyCmd = &cobra.Command{
+	Use:   "noisy",
+	Short: "rank the noisiest rules and generate suppression child rules",
+	Long: `Aggregates wazuh-alerts-* by rule id and ranks the rules raising the most
+alerts, with the agents and field values most of their alerts share.
+
+With --suppress <rule id> a level 0 child rule silencing the rule for one
+field value is written for review, together with a rule test replaying the
+latest matching alert: the suppression must match it and the noisy rule must
+no longer alert. The field and value default to the rule's most common one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		top, _ := cmd.Flags().GetInt("top")
+		fields, _ := cmd.Flags().GetStringSlice("fields")
+		suppress := cmd.Flag("suppress").Value.String()
+
+		since, err := timeframe.ParseTime(cmd.Flag("since").Value.String(), time.Now())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if top < 1 {
+			fmt.Println("[--top] must be at least 1")
+			os.Exit(1)
+		}
+		if suppress == "" && (cmd.Flag("field").Changed || cmd.Flag("value").Changed) {
+			fmt.Println("[--field] and [--value] select what [--suppress] silences")
+			os.Exit(1)
+		}
+
+		filters := []any{actions.TimestampSince(since)}
+		if field := cmd.Flag("field").Value.String(); field != "" && !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+		if suppress != "" {
+			filters = append(filters, actions.TermFilter("rule.id", suppress))
+			top = 1
+		}
+
+		indexer := actions.IndexerClientFactory()
+		resp, err := indexer.AlertAggregations(filters, noise.Aggregation(fields, top))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		noisy, err := noise.Parse(resp.Aggregations, fields, resp.Hits.Total.Value)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if suppress != "" {
+			if len(noisy) == 0 {
+				fmt.Printf("rule %s raised no alerts since %s\n", suppress, since.Format(time.RFC3339))
+				os.Exit(1)
+			}
+			writeSuppression(cmd, indexer, noisy[0], filters, since)
+			return
+		}
+
+		if format == printers.FormatJson {
+			printers.PrintJson(map[string]any{
+				"since": since,
+				"total": resp.Hits.Total.Value,
+				"rules": noisy,
+			})
+			return
+		}
+
+		var rows [][]string
+		for i, r := range noisy {
+			topAgent, topValue := "-", "-"
+			if len(r.Agents) > 0 {
+				topAgent = fmt.Sprintf("%s (%s)", r.Agents[0].Value, percent(r.Agents[0].Share))
+			}
+			if v, ok := r.TopValue(); ok {
+				topValue = fmt.Sprintf("%s=%s (%s)", v.Field, v.Value, percent(v.Share))
+			}
+			rows = append(rows, []string{
+				strconv.Itoa(i + 1),
+				r.Id,
+				strconv.Itoa(r.Level),
+				strconv.Itoa(r.Count),
+				percent(r.Share),
+				topAgent,
+				topValue,
+				r.Description,
+			})
+		}
+		printers.PrintTable([]string{"#", "rule", "level", "alerts", "share", "top agent", "top value", "description"}, rows)
+
+		fmt.Printf("\n%d alerts since %s\n", resp.Hits.Total.Value, since.Format(time.RFC3339))
+		if len(noisy) > 0 {
+			fmt.Println(printers.Dim("silence a rule for one value with --suppress <rule> [--field <field> --value <value>]"))
+		}
+	},
+}
+
+// writeSuppression writes a suppression rule for r and its rule test.
+func writeSuppression(cmd *cobra.Command, indexer *actions.IndexerClient, r noise.Rule, filters []any, since time.Time) {
+	format := outputFormat(cmd)
+	field := cmd.Flag("field").Value.String()
+	value := cmd.Flag("value").Value.String()
+	outputDir := cmd.Flag("output-dir").Value.String()
+	author := cmd.Flag("author").Value.String()
+	force := cmd.Flag("force").Changed
+
+	if field == "" {
+		top, ok := r.TopDecodedValue()
+		if !ok {
+			fmt.Printf("rule %s has no common decoded (data.*) field value, choose one with [--field] and [--value]\n", r.Id)
+			os.Exit(1)
+		}
+		field = top.Field
+	}
+
+	s := noise.Suppression{
+		ParentId:          r.Id,
+		ParentDescription: r.Description,
+		Field:             field,
+		Value:             value,
+		Since:             since.Format(time.RFC3339),
+	}
+	if _, err := s.RuleField(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if v, ok := r.Lookup(field, value); ok {
+		s.Value, s.Count = v.Value, v.Count
+	} else if value == "" {
+		fmt.Printf("rule %s alerts have no %s, choose a field with [--field]\n", r.Id, field)
+		os.Exit(1)
+	}
+
+	filters = append(filters, actions.TermFilter(field, s.Value))
+	alert, docId, err := indexer.FindLatestAlert(filters)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if alert == nil {
+		fmt.Printf("no rule %s alert with %s %s since %s\n", r.Id, field, s.Value, s.Since)
+		os.Exit(1)
+	}
+	if s.Count == 0 {
+		// The value is not among the most common ones, count it on its own
+		resp, err := indexer.AlertAggregations(filters, map[string]any{})
+		if err != nil {
+			log.Fatalln(err)
+		}
+		s.Count = resp.Hits.Total.Value
+	}
+
+	base := filepath.Join(outputDir, suppressionName(s))
+	xmlPath, testPath := base+".xml", base+".yaml"
+	if !force {
+		if existing := existingFile(xmlPath, testPath); existing != "" {
+			fmt.Printf("%s exists, use --force to overwrite\n", existing)
+			os.Exit(1)
+		}
+	}
+
+	rulesDirs, _ := cmd.Flags().GetStringSlice("rules-dir")
+	idRange, err := rulexml.ParseIdRange(cmd.Flag("range").Value.String())
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	ids, err := nextFreeRuleIds(rulesDirs, cmd.Flag("offline").Changed, idRange, 1)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	s.Id = strconv.Itoa(ids[0])
+
+	xml, err := s.XML()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		log.Fatalln(err)
+	}
+	if err := files.FileCreateWithSpecifiedNameAndContent(xmlPath, *bytes.NewBufferString(xml)); err != nil {
+		log.Fatalln(err)
+	}
+
+	// Alerts without a full_log, such as eventchannel, cannot be replayed
+	sample, sampleErr := ruletest.EdgeFromAlert(docId, alert)
+	if sampleErr == nil {
+		test := s.Test(xml, sample, author)
+		if err := files.FileCreateWithSpecifiedNameAndContent(testPath, rules.ScaffoldFromTempl(test)); err != nil {
+			log.Fatalln(err)
+		}
+	} else {
+		testPath = ""
+	}
+
+	if format == printers.FormatJson {
+		report := struct {
+			Rule        string `json:"rule"`
+			Parent      string `json:"parent"`
+			Field       string `json:"field"`
+			Value       string `json:"value"`
+			Alerts      int    `json:"alerts"`
+			Xml         string `json:"xml"`
+			Test        string `json:"test,omitempty"`
+			Sample      string `json:"sample"`
+			SampleError string `json:"sample_error,omitempty"`
+		}{s.Id, s.ParentId, s.Field, s.Value, s.Count, xmlPath, testPath, docId, ""}
+		if sampleErr != nil {
+			report.SampleError = sampleErr.Error()
+		}
+		printers.PrintJson(report)
+		return
+	}
+
+	fmt.Printf("rule %s suppresses rule %s for %s %s (%d alerts since %s)\n", s.Id, s.ParentId, s.Field, s.Value, s.Count, s.Since)
+	fmt.Printf("wrote %s\n", xmlPath)
+	if sampleErr != nil {
+		fmt.Println(printers.Red(fmt.Sprintf("no test written: %v", sampleErr)))
+		return
+	}
+	fmt.Printf("wrote %s with the negative case from alert %s\n", testPath, docId)
+}
+
+var nonWord = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// suppressionName is the base file name of a suppression rule.
+func suppressionName(s noise.Suppression) string {
+	name, _ := s.RuleField()
+	slug := strings.Trim(nonWord.ReplaceAllString(name+"_"+s.Value, "_"), "_")
+	if len(slug) > 48 {
+		slug = strings.TrimRight(slug[:48], "_")
+	}
+	return fmt.Sprintf("suppress_%s_%s", s.ParentId, slug)
+}
+
+// percent formats a fraction as a percentage.
+func percent(share float64) string {
+	return fmt.Sprintf("%.1f%%", share*100)
+}
+
+func init() {
+	alertsNoisyCmd.Flags().String("since", "7d", "rank alerts newer than this, as a duration or RFC3339 timestamp")
+	alertsNoisyCmd.Flags().Int("top", 20, "number of rules to rank")
+	alertsNoisyCmd.Flags().StringSlice("fields", noise.DefaultFields, "alert fields whose common values are reported")
+	alertsNoisyCmd.Flags().String("suppress", "", "write a level 0 suppression child rule and test for this rule id")
+	alertsNoisyCmd.Flags().String("field", "", "decoded field the suppression matches, such as data.srcip (defaults to the most common value's field)")
+	alertsNoisyCmd.Flags().String("value", "", "value the suppression matches (defaults to the field's most common value)")
+	alertsNoisyCmd.Flags().String("output-dir", ".", "directory to write the suppression rule and test to")
+	alertsNoisyCmd.Flags().String("author", "", "rule author written to the suppression test")
+	alertsNoisyCmd.Flags().Bool("force", false, "overwrite existing files")
+	addRuleIdAllocationFlags(alertsNoisyCmd)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/internal/timeframe"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/alertquery"
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+	"github.com/spf13/cobra"
+)
+
+// maxSearchSize is the largest page a single search may return.
+const maxSearchSize = 10000
+
+// alertsSearchCmd represents the alerts search command
+var alertsSearchCmd = &cobra.Command{
+	Use:   "search [query]...",
+	Short: "search alerts in the indexer",
+	Long: `Searches wazuh-alerts-* with the filter flags and an optional query, newest
+alerts first. The query is free text, matched as a phrase against full_log
+and rule.description, and field:value terms combined with AND, OR, NOT (or a
+leading -) and parentheses; terms next to each other must all match.
+
+  field:value        exact value, or a phrase for text fields
+  field:"a value"    values with spaces
+  field:web-*        wildcard
+  field:*            the field exists
+  field:>=10         comparison with >, >=, < or <=
+
+rule, level, group, mitre, agent, agent_id, decoder and manager are short
+for rule.id, rule.level, rule.groups, rule.mitre.id, agent.name, agent.id,
+decoder.name and manager.name; other fields are used as written, such as
+data.srcip or data.win.eventdata.image.`,
+	Example: `  wazctl alerts search --level '>=10' --since 1h
+  wazctl alerts search --agent web-1 'group:web NOT data.url:/health*'
+  wazctl alerts search 'data.srcip:10.0.0.5 AND (rule:5710 OR rule:5712)'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		limit, _ := cmd.Flags().GetInt("limit")
+		oldest := cmd.Flag("asc").Changed
+
+		if limit < 1 || limit > maxSearchSize {
+			fmt.Printf("[--limit] must be between 1 and %d\n", maxSearchSize)
+			os.Exit(1)
+		}
+
+		query, err := alertQueryFromFlags(cmd, args)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		order := "desc"
+		if oldest {
+			order = "asc"
+		}
+		body := map[string]any{
+			"size":             limit,
+			"track_total_hits": true,
+			"sort":             []any{map[string]any{"timestamp": order}},
+			"query":            query,
+		}
+
+		if cmd.Flag("dsl").Changed {
+			printers.PrintJson(body)
+			return
+		}
+
+		resp, err := actions.IndexerClientFactory().Search(string(opensearch.AlertsIndexPattern), body)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if format == printers.FormatJson {
+			printers.PrintJson(resp.Hits.Hits)
+			return
+		}
+
+		var rows [][]string
+		for _, hit := range resp.Hits.Hits {
+			alert, err := hit.Alert()
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			rows = append(rows, []string{
+				alertTime(alert),
+				alert.Rule.Id,
+				strconv.Itoa(alert.Rule.Level),
+				alert.Agent.Name,
+				alert.Rule.Description,
+				hit.Id,
+			})
+		}
+		if len(rows) == 0 {
+			fmt.Println("no alerts found")
+			return
+		}
+		printers.PrintTable([]string{"time", "rule", "level", "agent", "description", "id"}, rows)
+
+		total := resp.Hits.Total.Value
+		if resp.Hits.Total.Relation == "gte" {
+			fmt.Printf("\nshowing %d of at least %d alerts\n", len(rows), total)
+		} else if total > len(rows) {
+			fmt.Printf("\nshowing %d of %d alerts, raise [--limit] or narrow the search\n", len(rows), total)
+		}
+	},
+}
+
+// addAlertFilterFlags registers the alert filter flags read by
+// alertQueryFromFlags, searching alerts newer than since by default.
+func addAlertFilterFlags(cmd *cobra.Command, since string) {
+	cmd.Flags().StringSlice("rule", nil, "rule id (repeatable or comma separated)")
+	cmd.Flags().String("level", "", "rule level: 10, >=10, <3 or 5-10")
+	cmd.Flags().StringSlice("agent", nil, "agent name, wildcard or id (repeatable or comma separated)")
+	cmd.Flags().StringSlice("group", nil, "rule group (repeatable or comma separated)")
+	cmd.Flags().StringSlice("mitre", nil, "MITRE ATT&CK technique id (repeatable or comma separated)")
+	cmd.Flags().String("since", since, "alerts newer than this, as a duration or RFC3339 timestamp")
+	cmd.Flags().String("until", "", "alerts older than this, as a duration or RFC3339 timestamp")
+}
+
+// alertQueryFromFlags compiles the alert filter flags and the query given
+// as args into an OpenSearch query.
+func alertQueryFromFlags(cmd *cobra.Command, args []string) (map[string]any, error) {
+	opts, err := alertOptionsFromFlags(cmd, args)
+	if err != nil {
+		return nil, err
+	}
+	return alertquery.Build(opts)
+}
+
+// alertOptionsFromFlags reads the alert filter flags and the query given as
+// args, resolving the time window against the current time.
+func alertOptionsFromFlags(cmd *cobra.Command, args []string) (alertquery.Options, error) {
+	now := time.Now()
+	opts := alertquery.Options{
+		Level: cmd.Flag("level").Value.String(),
+		Query: strings.Join(args, " "),
+	}
+	opts.Rules, _ = cmd.Flags().GetStringSlice("rule")
+	opts.Agents, _ = cmd.Flags().GetStringSlice("agent")
+	opts.Groups, _ = cmd.Flags().GetStringSlice("group")
+	opts.Mitre, _ = cmd.Flags().GetStringSlice("mitre")
+
+	var err error
+	if since := cmd.Flag("since").Value.String(); since != "" {
+		if opts.Since, err = timeframe.ParseTime(since, now); err != nil {
+			return opts, err
+		}
+	}
+	if until := cmd.Flag("until").Value.String(); until != "" {
+		if opts.Until, err = timeframe.ParseTime(until, now); err != nil {
+			return opts, err
+		}
+	}
+	if !opts.Since.IsZero() && !opts.Until.IsZero() && !opts.Until.After(opts.Since) {
+		return opts, fmt.Errorf("[--until] must be after [--since]")
+	}
+
+	return opts, nil
+}
+
+// alertTime formats the alert timestamp in local time, or returns it as is
+// when it cannot be parsed.
+func alertTime(alert *opensearch.Alert) string {
+	t, err := alert.Time()
+	if err != nil {
+		return alert.Timestamp
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func init() {
+	addAlertFilterFlags(alertsSearchCmd, "24h")
+	alertsSearchCmd.Flags().IntP("limit", "n", 50, "number of alerts to return")
+	alertsSearchCmd.Flags().Bool("asc", false, "oldest alerts first")
+	alertsSearchCmd.Flags().Bool("dsl", false, "print the compiled OpenSearch request instead of running it")
+}
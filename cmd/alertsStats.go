@@ -0,0 +1,198 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/internal/timeframe"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/alertquery"
+	"github.com/EpykLab/wazctl/pkg/alertstats"
+	"github.com/spf13/cobra"
+)
+
+// alertsStatsCmd represents the alerts stats command
+var alertsStatsCmd = &cobra.Command{
+	Use:   "stats [query]...",
+	Short: "summarise alerts with top rules, agents, levels and techniques",
+	Long: `Aggregates the alerts matching the filter flags and query (see alerts search
+for the syntax) and prints the alerts over time as a sparkline, the top rules
+and agents with a sparkline of their alerts over the same intervals, the
+distribution of rule levels and the top MITRE ATT&CK techniques.
+
+The interval is picked from the window unless given with [--interval].
+With -o json the statistics, including the counts behind every sparkline,
+are printed as a single document for other tools.`,
+	Example: `  wazctl alerts stats --since 7d
+  wazctl alerts stats --since 24h --interval 15m --agent web-1
+  wazctl alerts stats --since 30d --level '>=10' -o json | jq '.rules[:5]'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		top, _ := cmd.Flags().GetInt("top")
+
+		if top < 1 || top > 100 {
+			fmt.Println("[--top] must be between 1 and 100")
+			os.Exit(1)
+		}
+
+		opts, err := alertOptionsFromFlags(cmd, args)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		window, err := statsWindow(cmd.Flag("interval").Value.String(), opts)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		query, err := alertquery.Build(opts)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		resp, err := actions.IndexerClientFactory().AlertAggregations([]any{query}, alertstats.Aggregation(top, window))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		stats, err := alertstats.Parse(resp.Aggregations, resp.Hits.Total.Value, window.Interval)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if format == printers.FormatJson {
+			printers.PrintJson(stats)
+			return
+		}
+		printAlertStats(stats, window)
+	},
+}
+
+// statsWindow returns the window of the statistics, picking an interval
+// from its span unless one is given. The window ends now when open.
+func statsWindow(interval string, opts alertquery.Options) (alertstats.Window, error) {
+	window := alertstats.Window{Since: opts.Since, Until: opts.Until}
+	if window.Until.IsZero() {
+		window.Until = time.Now()
+	}
+
+	if interval != "" {
+		d, err := timeframe.ParseDuration(interval)
+		if err != nil {
+			return window, err
+		}
+		if d < time.Second {
+			return window, fmt.Errorf("[--interval] must be at least 1s")
+		}
+		window.Interval = d
+	} else if window.Since.IsZero() {
+		window.Interval = 24 * time.Hour
+	} else {
+		window.Interval = alertstats.AutoInterval(window.Until.Sub(window.Since))
+	}
+
+	if !window.Since.IsZero() {
+		if n := window.Until.Sub(window.Since) / window.Interval; n > alertstats.MaxBuckets {
+			return window, fmt.Errorf("an interval of %s splits the window into %d intervals, use an [--interval] giving at most %d", alertstats.FixedInterval(window.Interval), n, alertstats.MaxBuckets)
+		}
+	}
+	return window, nil
+}
+
+// printAlertStats prints the alerts over time and a table for each
+// aggregation.
+func printAlertStats(stats *alertstats.Stats, window alertstats.Window) {
+	if stats.Total == 0 {
+		fmt.Println("no alerts found")
+		return
+	}
+
+	layout := "2006-01-02 15:04"
+	if window.Interval%(24*time.Hour) == 0 {
+		layout = "2006-01-02"
+	}
+
+	fmt.Printf("%d alerts, %s intervals\n", stats.Total, stats.Interval)
+
+	fmt.Println("\nAlerts over time")
+	if len(stats.Timeline) > 0 {
+		var counts []int
+		peak := stats.Timeline[0]
+		for _, b := range stats.Timeline {
+			counts = append(counts, b.Count)
+			if b.Count > peak.Count {
+				peak = b
+			}
+		}
+		first, last := stats.Timeline[0].Time.Local(), stats.Timeline[len(stats.Timeline)-1].Time.Local()
+		fmt.Printf("  %s\n", alertstats.Sparkline(counts))
+		fmt.Printf("  %s to %s, peak of %d at %s\n", first.Format(layout), last.Format(layout), peak.Count, peak.Time.Local().Format(layout))
+	}
+
+	fmt.Println("\nTop rules")
+	var rows [][]string
+	for _, r := range stats.Rules {
+		rows = append(rows, []string{r.Value, strconv.Itoa(r.Level), strconv.Itoa(r.Count), percent(r.Share), alertstats.Sparkline(r.Trend), r.Name})
+	}
+	printers.PrintTable([]string{"rule", "level", "alerts", "share", "trend", "description"}, rows)
+
+	fmt.Println("\nTop agents")
+	rows = nil
+	for _, a := range stats.Agents {
+		rows = append(rows, []string{a.Value, strconv.Itoa(a.Count), percent(a.Share), alertstats.Sparkline(a.Trend)})
+	}
+	printers.PrintTable([]string{"agent", "alerts", "share", "trend"}, rows)
+
+	fmt.Println("\nLevels")
+	rows = nil
+	most := 0
+	for _, l := range stats.Levels {
+		most = max(most, l.Count)
+	}
+	for _, l := range stats.Levels {
+		rows = append(rows, []string{l.Value, strconv.Itoa(l.Count), percent(l.Share), coverageBar(l.Count, most, 20)})
+	}
+	printers.PrintTable([]string{"level", "alerts", "share", ""}, rows)
+
+	fmt.Println("\nTop MITRE ATT&CK techniques")
+	if len(stats.Mitre) == 0 {
+		fmt.Println(printers.Dim("  no alerts carry MITRE ATT&CK techniques"))
+		return
+	}
+	rows = nil
+	for _, m := range stats.Mitre {
+		rows = append(rows, []string{m.Value, strconv.Itoa(m.Count), percent(m.Share), m.Name})
+	}
+	printers.PrintTable([]string{"technique", "alerts", "share", "name"}, rows)
+}
+
+func init() {
+	addAlertFilterFlags(alertsStatsCmd, "24h")
+	alertsStatsCmd.Flags().Int("top", 10, "number of rules, agents and techniques to list")
+	alertsStatsCmd.Flags().String("interval", "", "interval of the alerts over time, such as 15m or 1d (picked from the window by default)")
+}
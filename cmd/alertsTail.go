@@ -0,0 +1,163 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/alertquery"
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+	"github.com/spf13/cobra"
+)
+
+// alertsTailCmd represents the alerts tail command
+var alertsTailCmd = &cobra.Command{
+	Use:   "tail [query]...",
+	Short: "print the latest alerts and follow new ones",
+	Long: `Prints the latest alerts matching the filter flags and query (see alerts
+search for the syntax) as one line each, coloured by severity. With -f new
+alerts are printed as they are indexed until interrupted with Ctrl-C.
+
+Alerts are read in @timestamp order with search_after and the alert id as
+a tie-breaker, so alerts sharing a timestamp are neither skipped nor printed
+twice between polls. While following, only alerts at least --lag old are
+read, so alerts indexed a little after their timestamp are still printed.`,
+	Example: `  wazctl alerts tail -f --level '>=8'
+  wazctl alerts tail -f --agent web-1 'NOT group:web_scan'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		follow := cmd.Flag("follow").Changed
+		lines, _ := cmd.Flags().GetInt("lines")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		lag, _ := cmd.Flags().GetDuration("lag")
+
+		if lines < 0 || lines > maxSearchSize {
+			fmt.Printf("[--lines] must be between 0 and %d\n", maxSearchSize)
+			os.Exit(1)
+		}
+		if interval <= 0 {
+			fmt.Println("[--interval] must be positive")
+			os.Exit(1)
+		}
+		if lag < 0 {
+			fmt.Println("[--lag] must not be negative")
+			os.Exit(1)
+		}
+
+		query, err := alertQueryFromFlags(cmd, args)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		tail := alertquery.NewTail(actions.IndexerClientFactory(), string(opensearch.AlertsIndexPattern), query, time.Now())
+		tail.Lag = 0
+		if follow {
+			tail.Lag = lag
+		}
+
+		hits, err := tail.Last(lines)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		printTailHits(hits, format)
+		if !follow {
+			return
+		}
+
+		for {
+			hits, more, err := tail.Next()
+			if err != nil {
+				// keep following through indexer restarts and timeouts
+				log.Println(err)
+			}
+			printTailHits(hits, format)
+			if more && ctx.Err() == nil {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	},
+}
+
+// printTailHits prints alerts one per line, or as one JSON document per line.
+func printTailHits(hits []opensearch.SearchHit, format printers.Format) {
+	for _, hit := range hits {
+		if format == printers.FormatJson {
+			out, err := json.Marshal(hit)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			fmt.Println(string(out))
+			continue
+		}
+
+		alert, err := hit.Alert()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		line := fmt.Sprintf("%s %2d %-6s %-16s %s",
+			alertTime(alert), alert.Rule.Level, alert.Rule.Id, alert.Agent.Name, alert.Rule.Description)
+		fmt.Println(severityColor(alert.Rule.Level, line))
+	}
+}
+
+// severityColor colours s by Wazuh rule level: red for critical (12 and
+// above), yellow for high (8 to 11) and faint for low (below 4).
+func severityColor(level int, s string) string {
+	switch {
+	case level >= 12:
+		return printers.Red(s)
+	case level >= 8:
+		return printers.Yellow(s)
+	case level < 4:
+		return printers.Dim(s)
+	default:
+		return s
+	}
+}
+
+func init() {
+	addAlertFilterFlags(alertsTailCmd, "")
+	alertsTailCmd.Flags().BoolP("follow", "f", false, "keep printing new alerts until interrupted")
+	alertsTailCmd.Flags().IntP("lines", "n", 10, "number of recent alerts to print first")
+	alertsTailCmd.Flags().Duration("interval", 2*time.Second, "delay between polls with --follow")
+	alertsTailCmd.Flags().Duration("lag", alertquery.DefaultLag, "how old alerts must be before --follow reads them, to catch alerts indexed late")
+}
@@ -38,4 +38,5 @@ func init() {
 
 	initCmd.AddCommand(ruleCmd)
 	initCmd.AddCommand(configCmd)
+	initCmd.AddCommand(decoderCmd)
 }
@@ -0,0 +1,96 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/EpykLab/wazctl/internal/files"
+	"github.com/EpykLab/wazctl/internal/templates/rules"
+	"github.com/spf13/cobra"
+)
+
+// decoderCmd represents the decoder command
+var decoderCmd = &cobra.Command{
+	Use:   "decoder",
+	Short: "create a decoder skeleton and its decoder test file",
+	Long: `Writes <name>.xml with a decoder skeleton and <name>.yaml with a test
+asserting the decoder name and the fields it extracts from a sample log.
+With --parent the skeleton is a child of an existing decoder; Wazuh reports
+the parent's name for events it decodes, so the test expects the parent.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name := cmd.Flag("name").Value.String()
+		parent := cmd.Flag("parent").Value.String()
+		author := cmd.Flag("author").Value.String()
+		outputDir := cmd.Flag("output-dir").Value.String()
+		force := cmd.Flag("force").Changed
+
+		if name == "" {
+			fmt.Println("no decoder name provided. use the [-n --name] flag to name the decoder")
+			os.Exit(1)
+		}
+		if name == parent {
+			fmt.Println("a decoder cannot be its own parent")
+			os.Exit(1)
+		}
+
+		xmlPath := filepath.Join(outputDir, fmt.Sprintf("%s.xml", name))
+		testPath := filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name))
+		if !force {
+			if existing := existingFile(xmlPath, testPath); existing != "" {
+				fmt.Printf("%s exists, use --force to overwrite\n", existing)
+				os.Exit(1)
+			}
+		}
+
+		if outputDir != "" {
+			if err := os.MkdirAll(outputDir, 0755); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		var decoder bytes.Buffer
+		decoder.WriteString(rules.ExampleDecoder(name, parent) + "\n")
+		if err := files.FileCreateWithSpecifiedNameAndContent(xmlPath, decoder); err != nil {
+			log.Fatalln(err)
+		}
+
+		err := files.FileCreateWithSpecifiedNameAndContent(
+			testPath,
+			rules.ScaffoldFromTempl(rules.DecoderTest(name, parent, author)))
+		if err != nil {
+			log.Fatalln(err)
+		}
+	},
+}
+
+func init() {
+	decoderCmd.Flags().StringP("name", "n", "", "name of the new decoder")
+	decoderCmd.Flags().String("parent", "", "make the decoder a child of this existing decoder")
+	decoderCmd.Flags().String("author", "John Doe", "author written to the decoder test")
+	decoderCmd.Flags().String("output-dir", "", "directory to write the decoder and test files to")
+	decoderCmd.Flags().Bool("force", false, "overwrite existing files")
+}
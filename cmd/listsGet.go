@@ -0,0 +1,59 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/EpykLab/wazctl/internal/cdb"
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/spf13/cobra"
+)
+
+// listsGetCmd represents the lists get command
+var listsGetCmd = &cobra.Command{
+	Use:   "get <filename>",
+	Short: "print the content of a CDB list",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+
+		client := actions.WazctlClientFactory()
+
+		content, err := client.GetCdbListFileFromWazuhManager(args[0])
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if format == printers.FormatJson {
+			list, _ := cdb.Parse(content)
+			printers.PrintJson(list)
+			return
+		}
+
+		fmt.Print(string(content))
+	},
+}
+
+func init() {}
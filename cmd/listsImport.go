@@ -0,0 +1,130 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/EpykLab/wazctl/internal/cdb"
+	"github.com/EpykLab/wazctl/internal/files"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/spf13/cobra"
+)
+
+// listsImportCmd represents the lists import command
+var listsImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "convert a CSV or plain IP/hash feed into a CDB list",
+	Run: func(cmd *cobra.Command, args []string) {
+		csvPath := cmd.Flag("csv").Value.String()
+		plainPath := cmd.Flag("plain").Value.String()
+		outFile := cmd.Flag("out").Value.String()
+		name := cmd.Flag("name").Value.String()
+		upload := cmd.Flag("upload").Changed
+		dryRun := cmd.Flag("dry-run").Changed
+
+		if (csvPath == "") == (plainPath == "") {
+			fmt.Println("exactly one of [--csv, --plain] must be provided")
+			os.Exit(1)
+		}
+		if upload && name == "" {
+			fmt.Println("no list name provided. use the [-n --name] flag to name the list in the manager")
+			os.Exit(1)
+		}
+
+		kind, err := cdb.ParseKind(cmd.Flag("type").Value.String())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		opts := cdb.ImportOptions{
+			KeyColumn:    cmd.Flag("key-column").Value.String(),
+			ValueColumn:  cmd.Flag("value-column").Value.String(),
+			DefaultValue: cmd.Flag("value").Value.String(),
+			Header:       cmd.Flag("header").Changed,
+			Kind:         kind,
+		}
+
+		var list *cdb.List
+		var problems []cdb.Problem
+		if csvPath != "" {
+			content, err := files.ReadFileFromSpecifiedPath(csvPath)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			list, problems, err = cdb.ImportCSV(bytes.NewReader(content), opts)
+			if err != nil {
+				log.Fatalln(err)
+			}
+		} else {
+			content, err := files.ReadFileFromSpecifiedPath(plainPath)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			list, problems, err = cdb.ImportPlain(bytes.NewReader(content), opts)
+			if err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		if len(problems) > 0 {
+			// Problems are reported on stderr so stdout stays a valid list
+			for _, p := range problems {
+				fmt.Fprintf(os.Stderr, "skipped %s\n", p)
+			}
+		}
+
+		if outFile != "" {
+			if err := files.FileCreateWithSpecifiedNameAndContent(outFile, *bytes.NewBuffer(list.Bytes())); err != nil {
+				log.Fatalln(err)
+			}
+			fmt.Printf("wrote %d entries to %s\n", len(list.Entries), outFile)
+		} else if !upload {
+			fmt.Print(string(list.Bytes()))
+		}
+
+		if upload {
+			client := actions.WazctlClientFactory()
+			if err := uploadCdbList(client, name, list, dryRun); err != nil {
+				log.Fatalln(err)
+			}
+		}
+	},
+}
+
+func init() {
+	listsImportCmd.Flags().String("csv", "", "path to a CSV feed")
+	listsImportCmd.Flags().String("plain", "", "path to a feed with one IP or hash per line")
+	listsImportCmd.Flags().String("type", "any", "validate and normalise keys as [any, ip, hash]")
+	listsImportCmd.Flags().String("key-column", "0", "CSV column holding the key, by index or header name")
+	listsImportCmd.Flags().String("value-column", "", "CSV column holding the value, by index or header name")
+	listsImportCmd.Flags().String("value", "", "value written for every entry when no value column is used")
+	listsImportCmd.Flags().Bool("header", false, "treat the first CSV record as a header row")
+	listsImportCmd.Flags().String("out", "", "write the converted list to this file instead of stdout")
+	listsImportCmd.Flags().StringP("name", "n", "", "name of the list in the manager when uploading")
+	listsImportCmd.Flags().Bool("upload", false, "show the diff against the manager copy and upload the list")
+	listsImportCmd.Flags().Bool("dry-run", false, "with --upload, show the diff without uploading")
+}
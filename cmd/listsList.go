@@ -0,0 +1,60 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/spf13/cobra"
+)
+
+// listsListCmd represents the lists list command
+var listsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list CDB lists in the wazuh manager",
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+
+		client := actions.WazctlClientFactory()
+
+		lists, err := client.GetCdbListsFromWazuhManager()
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if format == printers.FormatJson {
+			printers.PrintJson(lists)
+			return
+		}
+
+		var rows [][]string
+		for _, l := range lists {
+			rows = append(rows, []string{l.Filename, l.RelativeDirname, strconv.Itoa(len(l.Items))})
+		}
+		printers.PrintTable([]string{"filename", "directory", "items"}, rows)
+	},
+}
+
+func init() {}
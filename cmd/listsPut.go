@@ -0,0 +1,125 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/EpykLab/wazctl/internal/cdb"
+	"github.com/EpykLab/wazctl/internal/files"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/spf13/cobra"
+)
+
+// listsPutCmd represents the lists put command
+var listsPutCmd = &cobra.Command{
+	Use:   "put <file>",
+	Short: "validate a local CDB list, show its diff against the manager and upload it",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := cmd.Flag("name").Value.String()
+		dryRun := cmd.Flag("dry-run").Changed
+		force := cmd.Flag("force").Changed
+
+		if name == "" {
+			name = filepath.Base(args[0])
+		}
+
+		content, err := files.ReadFileFromSpecifiedPath(args[0])
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		list, problems := cdb.Parse(content)
+		if len(problems) > 0 {
+			printListProblems(problems)
+			if !force {
+				fmt.Println("refusing to upload an invalid list. use --force to upload anyway")
+				os.Exit(1)
+			}
+		}
+
+		client := actions.WazctlClientFactory()
+		if err := uploadCdbList(client, name, list, dryRun); err != nil {
+			log.Fatalln(err)
+		}
+	},
+}
+
+// uploadCdbList prints the diff between list and the manager's copy of name
+// and uploads list unless dryRun is set.
+func uploadCdbList(client *actions.WazctlClient, name string, list *cdb.List, dryRun bool) error {
+	existing, err := client.GetCdbListsFromWazuhManager()
+	if err != nil {
+		return err
+	}
+
+	exists := slices.ContainsFunc(existing, func(l actions.CdbListSummary) bool {
+		return l.Filename == name
+	})
+
+	remote := &cdb.List{}
+	if exists {
+		content, err := client.GetCdbListFileFromWazuhManager(name)
+		if err != nil {
+			return err
+		}
+		remote, _ = cdb.Parse(content)
+	} else {
+		fmt.Printf("%s does not exist in the manager and will be created\n", name)
+	}
+
+	changes := cdb.Diff(remote, list)
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	fmt.Printf("%d change(s) to %s\n", len(changes), name)
+
+	if dryRun || (exists && len(changes) == 0) {
+		return nil
+	}
+
+	resp, err := client.PutCdbListFileInWazuhManager(name, list.Bytes(), exists)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(resp))
+
+	return nil
+}
+
+func printListProblems(problems []cdb.Problem) {
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	fmt.Printf("%d problem(s) found\n", len(problems))
+}
+
+func init() {
+	listsPutCmd.Flags().StringP("name", "n", "", "name of the list in the manager (defaults to the file name)")
+	listsPutCmd.Flags().Bool("dry-run", false, "show the diff without uploading")
+	listsPutCmd.Flags().Bool("force", false, "upload even if the list has invalid or duplicate keys")
+}
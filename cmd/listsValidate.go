@@ -0,0 +1,74 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/EpykLab/wazctl/internal/cdb"
+	"github.com/EpykLab/wazctl/internal/files"
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/spf13/cobra"
+)
+
+// listsValidateCmd represents the lists validate command
+var listsValidateCmd = &cobra.Command{
+	Use:   "validate <file>...",
+	Short: "check local CDB lists for invalid and duplicate keys",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+
+		failed := false
+		results := make(map[string][]cdb.Problem)
+		for _, path := range args {
+			content, err := files.ReadFileFromSpecifiedPath(path)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			list, problems := cdb.Parse(content)
+			results[path] = problems
+			if len(problems) > 0 {
+				failed = true
+			}
+
+			if format == printers.FormatTable {
+				fmt.Printf("%s: %d entries, %d problem(s)\n", path, len(list.Entries), len(problems))
+				for _, p := range problems {
+					fmt.Printf("  %s\n", p)
+				}
+			}
+		}
+
+		if format == printers.FormatJson {
+			printers.PrintJson(results)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {}
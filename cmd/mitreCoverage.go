@@ -0,0 +1,177 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EpykLab/wazctl/internal/files"
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/internal/timeframe"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/mitre"
+	"github.com/spf13/cobra"
+)
+
+// mitreCoverageCmd represents the mitre coverage command
+var mitreCoverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "export ATT&CK technique coverage of active rules as a Navigator layer and text matrix",
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		layerPath := cmd.Flag("layer").Value.String()
+		layerName := cmd.Flag("name").Value.String()
+		weighted := cmd.Flag("weight-alerts").Changed
+
+		since, err := timeframe.ParseTime(cmd.Flag("since").Value.String(), time.Now())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		client := actions.WazctlClientFactory()
+
+		tactics, err := client.GetMitreTacticsFromWazuhManager()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		techniques, err := client.GetMitreTechniquesFromWazuhManager()
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		query := url.Values{}
+		query.Set("status", "enabled")
+		rules, err := client.GetRulesFromWazuhManager(query)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		refs := make(map[string][]string)
+		for _, r := range rules {
+			if r.Level == 0 {
+				continue
+			}
+			for _, id := range r.Mitre {
+				refs[id] = append(refs[id], strconv.Itoa(r.Id))
+			}
+		}
+
+		var counts map[string]int
+		if weighted {
+			indexer := actions.IndexerClientFactory()
+			buckets, err := indexer.AlertTermCounts("rule.mitre.id", len(techniques)+len(refs), []any{actions.TimestampSince(since)})
+			if err != nil {
+				log.Fatalln(err)
+			}
+			counts = make(map[string]int, len(buckets))
+			for _, b := range buckets {
+				counts[b.String()] = b.DocCount
+			}
+		}
+
+		report := mitre.Coverage(tactics, techniques, refs, counts)
+
+		if layerPath != "" {
+			layer, err := json.MarshalIndent(mitre.NewLayer(layerName, report, weighted), "", "  ")
+			if err != nil {
+				log.Fatalln(err)
+			}
+			if err := files.FileCreateWithSpecifiedNameAndContent(layerPath, *bytes.NewBuffer(layer)); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		if format == printers.FormatJson {
+			printers.PrintJson(report)
+			return
+		}
+
+		printMitreMatrix(report, weighted)
+		if layerPath != "" {
+			fmt.Printf("\nNavigator layer written to %s\n", layerPath)
+		}
+	},
+}
+
+// printMitreMatrix prints one row per tactic with its covered techniques.
+func printMitreMatrix(report mitre.Report, weighted bool) {
+	var rows [][]string
+	for _, tactic := range report.Tactics {
+		var covered []string
+		for _, tech := range tactic.Techniques {
+			if len(tech.Rules) == 0 {
+				continue
+			}
+			detail := fmt.Sprintf("%s(%d)", tech.Id, len(tech.Rules))
+			if weighted {
+				detail = fmt.Sprintf("%s(%d/%d)", tech.Id, len(tech.Rules), tech.Alerts)
+			}
+			covered = append(covered, detail)
+		}
+
+		rows = append(rows, []string{
+			fmt.Sprintf("%s %s", tactic.Id, tactic.Name),
+			fmt.Sprintf("%d/%d", tactic.Covered, len(tactic.Techniques)),
+			coverageBar(tactic.Covered, len(tactic.Techniques), 20),
+			strings.Join(covered, " "),
+		})
+	}
+
+	legend := "covered techniques (rules)"
+	if weighted {
+		legend = "covered techniques (rules/alerts)"
+	}
+	printers.PrintTable([]string{"tactic", "covered", "", legend}, rows)
+
+	fmt.Printf("\n%d of %d techniques covered by active rules\n", report.Covered, report.Techniques)
+	if len(report.Unknown) > 0 {
+		fmt.Printf("technique ids referenced by rules but unknown to the manager: %s\n", strings.Join(report.Unknown, ", "))
+	}
+}
+
+// coverageBar renders covered/total as a fixed width bar.
+func coverageBar(covered, total, width int) string {
+	if total == 0 {
+		return strings.Repeat("░", width)
+	}
+	filled := covered * width / total
+	if covered > 0 && filled == 0 {
+		filled = 1
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+func init() {
+	mitreCoverageCmd.Flags().String("layer", "wazctl-coverage-layer.json", "write the ATT&CK Navigator layer to this file (empty to skip)")
+	mitreCoverageCmd.Flags().String("name", "Wazuh rule coverage", "name of the Navigator layer")
+	mitreCoverageCmd.Flags().Bool("weight-alerts", false, "score techniques by alert counts from the indexer instead of rule counts")
+	mitreCoverageCmd.Flags().String("since", "30d", "alert window used with --weight-alerts, as a duration or RFC3339 timestamp")
+}
@@ -22,8 +22,10 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"fmt"
 	"os"
 
+	"github.com/EpykLab/wazctl/internal/printers"
 	"github.com/spf13/cobra"
 )
 
@@ -45,6 +47,19 @@ func Execute() {
 	}
 }
 
+// outputFormat returns the validated value of the persistent --output flag.
+func outputFormat(cmd *cobra.Command) printers.Format {
+	format, err := printers.ParseFormat(cmd.Flag("output").Value.String())
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	return format
+}
+
 func init() {
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+
+	rootCmd.PersistentFlags().StringP("output", "o", "table", "output format for commands that support it [table, json]")
 }
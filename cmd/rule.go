@@ -24,9 +24,15 @@ package cmd
 import (
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"strconv"
 
+	"github.com/EpykLab/wazctl/config"
 	"github.com/EpykLab/wazctl/internal/files"
+	"github.com/EpykLab/wazctl/internal/rulexml"
 	"github.com/EpykLab/wazctl/internal/templates/rules"
+	"github.com/EpykLab/wazctl/pkg/actions"
 	"github.com/spf13/cobra"
 )
 
@@ -36,10 +42,64 @@ var ruleCmd = &cobra.Command{
 	Short: "create wazctl rule file",
 	Run: func(cmd *cobra.Command, args []string) {
 		name := cmd.Flag("name").Value.String()
+		fromRule := cmd.Flag("from-rule").Value.String()
+		author := cmd.Flag("author").Value.String()
+		outputDir := cmd.Flag("output-dir").Value.String()
+
+		if name == "" && fromRule == "" {
+			fmt.Println("no file name provided. use the [-n --name] flag to name the rule test file")
+			os.Exit(1)
+		}
+
+		data := rules.ExampleRuleTest()
+
+		if fromRule == "" {
+			rulesDirs, _ := cmd.Flags().GetStringSlice("rules-dir")
+			offline := cmd.Flag("offline").Changed
+			if conf, _ := config.LoadOptional(); conf == nil && !offline {
+				// init rule scaffolds without a manager; only check it when configured
+				log.Println("no wazctl config found, allocating the rule id from local rule files only")
+				offline = true
+			}
+
+			idRange, err := rulexml.ParseIdRange(cmd.Flag("range").Value.String())
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			ids, err := nextFreeRuleIds(rulesDirs, offline, idRange, 1)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			data.RuleId = strconv.Itoa(ids[0])
+			data.RuleContent = rules.ExampleRule(data.RuleId)
+		} else {
+			rule, err := fetchManagerRule(actions.WazctlClientFactory(), fromRule)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			data = rules.RuleTestFromRule(*rule, data.RuleAuthor)
+			if name == "" {
+				name = fmt.Sprintf("rule_%s", rule.Id)
+			}
+		}
+
+		if author != "" {
+			data.RuleAuthor = author
+		}
+
+		if outputDir != "" {
+			if err := os.MkdirAll(outputDir, 0755); err != nil {
+				log.Fatalln(err)
+			}
+		}
 
 		err := files.FileCreateWithSpecifiedNameAndContent(
-			fmt.Sprintf("%s.yaml", name),
-			rules.ScaffoldFromTempl())
+			filepath.Join(outputDir, fmt.Sprintf("%s.yaml", name)),
+			rules.ScaffoldFromTempl(data))
 
 		if err != nil {
 			log.Println(err)
@@ -48,10 +108,52 @@ var ruleCmd = &cobra.Command{
 	},
 }
 
-func init() {
-	rootCmd.AddCommand(ruleCmd)
+// fetchManagerRule looks up a rule in the manager and parses its definition
+// out of the rule file it was loaded from.
+func fetchManagerRule(client *actions.WazctlClient, id string) (*rulexml.Rule, error) {
+	meta, err := client.GetRuleFromWazuhManager(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseManagerRule(client, meta)
+}
+
+// parseManagerRule parses the definition of a rule out of the rule file the
+// manager loaded it from.
+func parseManagerRule(client *actions.WazctlClient, meta *actions.WazuhRule) (*rulexml.Rule, error) {
+	id := strconv.Itoa(meta.Id)
+
+	content, err := client.GetRuleFileFromWazuhManager(meta.Filename, meta.RelativeDirname)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := rulexml.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s/%s: %w", meta.RelativeDirname, meta.Filename, err)
+	}
 
-	ruleCmd.Flags().StringP("name", "n", "", "name of new rule file")
+	for _, rule := range parsed {
+		if rule.Id == id {
+			rule.File = fmt.Sprintf("%s/%s", meta.RelativeDirname, meta.Filename)
+			return &rule, nil
+		}
+	}
+
+	return nil, fmt.Errorf("rule %s not found in %s/%s", id, meta.RelativeDirname, meta.Filename)
+}
+
+func init() {
+	addRuleScaffoldFlags(ruleCmd)
+}
 
-	ruleCmd.MarkFlagRequired("name")
+// addRuleScaffoldFlags registers the flags of init rule, which the deprecated
+// top level rule form accepts too.
+func addRuleScaffoldFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("name", "n", "", "name of new rule file (defaults to rule_<id> with --from-rule)")
+	cmd.Flags().String("from-rule", "", "scaffold the test from this rule id in the manager")
+	cmd.Flags().String("author", "", "rule author written to the scaffold")
+	cmd.Flags().String("output-dir", "", "directory to write the rule test file to")
+	addRuleIdAllocationFlags(cmd)
 }
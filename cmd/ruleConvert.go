@@ -0,0 +1,242 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/EpykLab/wazctl/internal/files"
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/internal/rulexml"
+	"github.com/EpykLab/wazctl/internal/templates/rules"
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"github.com/EpykLab/wazctl/pkg/sigma"
+	"github.com/spf13/cobra"
+)
+
+// ruleConvertCmd represents the rule convert command
+var ruleConvertCmd = &cobra.Command{
+	Use:   "convert --from sigma <file>...",
+	Short: "convert Sigma rules into Wazuh rule XML and rule tests",
+	Long: `Translates the detection logic of Sigma rules into Wazuh rules. Each file
+produces <name>.xml, holding one rule per branch of the detection condition
+with newly allocated ids, and <name>.yaml, a rule test scaffold for them.
+
+Logsources, field names and levels are mapped through the built-in mapping
+for Windows eventchannel, Linux auditd/syslog, proxy and web server logs,
+which --mapping extends. Constructs Wazuh rules cannot express are listed
+in the report, and the condition branches using them are left out.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		from := cmd.Flag("from").Value.String()
+		outputDir := cmd.Flag("output-dir").Value.String()
+		author := cmd.Flag("author").Value.String()
+		force := cmd.Flag("force").Changed
+		strict := cmd.Flag("strict").Changed
+
+		if from != "sigma" {
+			fmt.Println("[--from] must be sigma")
+			os.Exit(1)
+		}
+
+		mapping, err := sigma.LoadMapping(cmd.Flag("mapping").Value.String())
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		type converted struct {
+			Sigma  string        `json:"sigma"`
+			Xml    string        `json:"xml,omitempty"`
+			Test   string        `json:"test,omitempty"`
+			Rules  []int         `json:"rules,omitempty"`
+			Issues []sigma.Issue `json:"issues,omitempty"`
+			Error  string        `json:"error,omitempty"`
+		}
+
+		report := make([]converted, len(args))
+		conversions := make([]*sigma.Conversion, len(args))
+		needed := 0
+		for i, path := range args {
+			report[i].Sigma = path
+
+			content, err := files.ReadFileFromSpecifiedPath(path)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			rule, err := sigma.Parse(content)
+			if err != nil {
+				report[i].Error = err.Error()
+				continue
+			}
+
+			c := sigma.Convert(rule, mapping)
+			report[i].Issues = c.Issues
+			if len(c.Branches) == 0 {
+				report[i].Error = "nothing could be converted"
+				continue
+			}
+
+			base := filepath.Join(outputDir, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+			report[i].Xml, report[i].Test = base+".xml", base+".yaml"
+			if !force {
+				if existing := existingFile(report[i].Xml, report[i].Test); existing != "" {
+					report[i].Error = fmt.Sprintf("%s exists, use --force to overwrite", existing)
+					continue
+				}
+			}
+
+			conversions[i] = c
+			needed += len(c.Branches)
+		}
+
+		var ids []int
+		if needed > 0 {
+			rulesDirs, _ := cmd.Flags().GetStringSlice("rules-dir")
+			idRange, err := rulexml.ParseIdRange(cmd.Flag("range").Value.String())
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if ids, err = nextFreeRuleIds(rulesDirs, cmd.Flag("offline").Changed, idRange, needed); err != nil {
+				log.Fatalln(err)
+			}
+			if err := os.MkdirAll(outputDir, 0755); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		for i, c := range conversions {
+			if c == nil {
+				continue
+			}
+			ruleIds := ids[:len(c.Branches)]
+			ids = ids[len(c.Branches):]
+
+			xml := c.XML(ruleIds)
+			test, err := sigmaRuleTest(c, xml, author)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			if err := files.FileCreateWithSpecifiedNameAndContent(report[i].Xml, *bytes.NewBufferString(xml)); err != nil {
+				log.Fatalln(err)
+			}
+			if err := files.FileCreateWithSpecifiedNameAndContent(report[i].Test, rules.ScaffoldFromTempl(test)); err != nil {
+				log.Fatalln(err)
+			}
+			report[i].Rules = ruleIds
+		}
+
+		failed := false
+		for _, r := range report {
+			if r.Error != "" || strict && len(r.Issues) > 0 {
+				failed = true
+			}
+		}
+
+		if format == printers.FormatJson {
+			printers.PrintJson(report)
+		} else {
+			var rows [][]string
+			for _, r := range report {
+				var ids []string
+				for _, id := range r.Rules {
+					ids = append(ids, strconv.Itoa(id))
+				}
+				rows = append(rows, []string{r.Sigma, r.Xml, strings.Join(ids, ","), strconv.Itoa(len(r.Issues)), r.Error})
+			}
+			printers.PrintTable([]string{"sigma", "xml", "rules", "unsupported", "error"}, rows)
+
+			for _, r := range report {
+				for _, issue := range r.Issues {
+					fmt.Printf("%s: %s\n", r.Sigma, issue)
+				}
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// sigmaRuleTest scaffolds a rule test for the rules of a conversion, with
+// placeholder edges for the conditions of every rule.
+func sigmaRuleTest(c *sigma.Conversion, xml, author string) (v1.SchemaJson, error) {
+	parsed, err := rulexml.Parse([]byte(xml))
+	if err != nil {
+		return v1.SchemaJson{}, fmt.Errorf("parsing converted rules: %w", err)
+	}
+
+	if author == "" {
+		author = c.Rule.Author
+	}
+	test := rules.RuleTestFromRule(parsed[0], author)
+	test.RuleName = c.Rule.Title
+	test.RuleContent = strings.TrimSpace(xml)
+	if desc := strings.Join(strings.Fields(c.Rule.Description), " "); desc != "" {
+		test.Description = desc
+	}
+
+	for _, rule := range parsed[1:] {
+		for _, edge := range rules.EdgesFromRule(rule) {
+			edge.RuleId = rule.Id
+			test.Edges = append(test.Edges, edge)
+		}
+	}
+	if logFormat := c.Parent.LogFormat; logFormat != "" {
+		for _, edge := range test.Edges {
+			if edge.Log != nil {
+				edge.Log.LogFormat = logFormat
+			}
+		}
+	}
+
+	return test, nil
+}
+
+// existingFile returns the first of paths that exists.
+func existingFile(paths ...string) string {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func init() {
+	ruleConvertCmd.Flags().String("from", "", "format of the input rules (sigma)")
+	ruleConvertCmd.Flags().String("mapping", "", "YAML file of logsource, field and level mappings layered over the built-in ones")
+	ruleConvertCmd.Flags().String("output-dir", ".", "directory to write the rule XML and rule test files to")
+	ruleConvertCmd.Flags().String("author", "", "rule author written to the rule tests (defaults to the Sigma author)")
+	ruleConvertCmd.Flags().Bool("force", false, "overwrite existing files")
+	ruleConvertCmd.Flags().Bool("strict", false, "exit non-zero when any construct could not be converted")
+	addRuleIdAllocationFlags(ruleConvertCmd)
+}
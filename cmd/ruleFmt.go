@@ -0,0 +1,223 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/EpykLab/wazctl/internal/files"
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/internal/rulexml"
+	"github.com/EpykLab/wazctl/pkg/ruletest"
+	"github.com/spf13/cobra"
+)
+
+// ruleFmtCmd represents the rule fmt command
+var ruleFmtCmd = &cobra.Command{
+	Use:   "fmt [file or dir]...",
+	Short: "canonically format rule and decoder XML and test ruleContent blocks",
+	Long: `Formats Wazuh rule and decoder XML files, and the ruleContent block of rule
+test YAML files, with two space indentation, one element per line and
+attributes in a fixed order (id, name, level, frequency, timeframe, ...).
+Comments, blank lines between blocks, several root elements and the text
+of every condition are kept. Directories are walked for rule and decoder
+XML (a <group>, <rule>, <decoder> or <var> root) and for rule tests with a
+ruleContent or decoderContent block; other XML and YAML files are left
+alone. The default is the current directory.
+
+Files are rewritten in place and listed; with --check nothing is written
+and the command exits non-zero when a file is not formatted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		check := cmd.Flag("check").Changed
+		showDiff := cmd.Flag("diff").Changed
+
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		paths, err := discoverFmtFiles(args)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		type formatted struct {
+			File    string   `json:"file"`
+			Changed bool     `json:"changed"`
+			Diff    []string `json:"diff,omitempty"`
+			Error   string   `json:"error,omitempty"`
+		}
+
+		var report []formatted
+		failed := false
+		for _, path := range paths {
+			entry := formatted{File: path}
+
+			out, before, err := formatRuleFile(path)
+			if err != nil {
+				entry.Error = err.Error()
+				report = append(report, entry)
+				failed = true
+				continue
+			}
+			if bytes.Equal(out, before) {
+				continue
+			}
+
+			entry.Changed = true
+			if showDiff {
+				entry.Diff = ruletest.DiffLines(strings.Split(string(before), "\n"), strings.Split(string(out), "\n"))
+			}
+			if check {
+				failed = true
+			} else if err := files.FileCreateWithSpecifiedNameAndContent(path, *bytes.NewBuffer(out)); err != nil {
+				entry.Error = err.Error()
+				failed = true
+			}
+			report = append(report, entry)
+		}
+
+		if format == printers.FormatJson {
+			if report == nil {
+				report = []formatted{}
+			}
+			printers.PrintJson(report)
+		} else {
+			for _, r := range report {
+				if r.Error != "" {
+					fmt.Printf("%s: %s\n", r.File, r.Error)
+					continue
+				}
+				fmt.Println(r.File)
+				printFmtDiff(r.Diff)
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// formatRuleFile returns the formatted and the original content of a rule
+// XML or rule test file.
+func formatRuleFile(path string) ([]byte, []byte, error) {
+	content, err := files.ReadFileFromSpecifiedPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out []byte
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		out, err = rulexml.Format(content)
+	} else {
+		out, err = ruletest.FormatRuleContent(content)
+	}
+	return out, content, err
+}
+
+func printFmtDiff(diff []string) {
+	for _, line := range diff {
+		switch {
+		case strings.HasPrefix(line, "- "):
+			fmt.Println(printers.Red(line))
+		case strings.HasPrefix(line, "+ "):
+			fmt.Println(printers.Green(line))
+		default:
+			fmt.Println(printers.Dim(line))
+		}
+	}
+}
+
+// discoverFmtFiles expands paths into XML and YAML files, walking
+// directories and skipping hidden files and directories below them. Files
+// found by walking are only kept when they are rule or decoder XML or rule
+// tests with a ruleContent or decoderContent block.
+func discoverFmtFiles(paths []string) ([]string, error) {
+	var found []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			found = append(found, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if p != path && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			ok, err := isFmtTarget(p)
+			if err != nil {
+				return err
+			}
+			if ok {
+				found = append(found, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(found)
+	return found, nil
+}
+
+func init() {
+	ruleFmtCmd.Flags().Bool("check", false, "only report unformatted files and exit non-zero if there are any")
+	ruleFmtCmd.Flags().BoolP("diff", "d", false, "print the changes formatting makes")
+}
+
+// isFmtTarget reports whether rule fmt formats the file at path.
+func isFmtTarget(path string) (bool, error) {
+	isXml := strings.EqualFold(filepath.Ext(path), ".xml")
+	if !isXml && !ruletest.IsTestFile(path) {
+		return false, nil
+	}
+
+	content, err := files.ReadFileFromSpecifiedPath(path)
+	if err != nil {
+		return false, err
+	}
+	if isXml {
+		return rulexml.IsRuleset(content), nil
+	}
+	return ruletest.HasRuleContent(content), nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// ruleGroupCmd represents the rule command
+var ruleGroupCmd = &cobra.Command{
+	Use:   "rule",
+	Short: "test, convert and format rules (scaffold new rule tests with init rule)",
+	Long: `Tests, converts and formats rules with the subcommands below.
+
+Run with the init rule flags and no subcommand, rule still scaffolds a rule
+test file as it used to; that form is deprecated in favour of init rule.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !cmd.Flags().Changed("name") && !cmd.Flags().Changed("from-rule") {
+			_ = cmd.Help()
+			return
+		}
+		fmt.Fprintln(os.Stderr, "wazctl rule -n is deprecated, use wazctl init rule -n")
+		ruleCmd.Run(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ruleGroupCmd)
+	addRuleScaffoldFlags(ruleGroupCmd)
+
+	ruleGroupCmd.AddCommand(ruleTestCmd)
+	ruleGroupCmd.AddCommand(ruleConvertCmd)
+	ruleGroupCmd.AddCommand(ruleFmtCmd)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/internal/rulexml"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/ruletest"
+	"github.com/spf13/cobra"
+)
+
+// ruleTestCoverageCmd represents the rule test coverage command
+var ruleTestCoverageCmd = &cobra.Command{
+	Use:   "coverage [test file or dir]...",
+	Short: "report which custom rules are asserted by rule test files",
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		rulesDirs, _ := cmd.Flags().GetStringSlice("rules-dir")
+		fromManager := cmd.Flag("from-manager").Changed
+		minPercent, _ := cmd.Flags().GetFloat64("min")
+
+		if len(rulesDirs) == 0 && !fromManager {
+			fmt.Println("no custom rules source provided. use [--rules-dir] and/or [--from-manager]")
+			os.Exit(1)
+		}
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+
+		var custom []string
+		for _, dir := range rulesDirs {
+			rules, err := parseRulesDir(dir)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			custom = append(custom, rulexml.Ids(rules)...)
+		}
+		if fromManager {
+			client := actions.WazctlClientFactory()
+			rules, err := client.GetCustomRulesFromWazuhManager()
+			if err != nil {
+				log.Fatalln(err)
+			}
+			custom = append(custom, actions.WazuhRuleIds(rules)...)
+		}
+
+		paths, err := ruletest.Discover(args)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		var tested, loadErrors []string
+		for _, path := range paths {
+			test, err := ruletest.Load(path)
+			if err != nil {
+				loadErrors = append(loadErrors, err.Error())
+				continue
+			}
+			tested = append(tested, ruletest.AssertedRuleIds(test)...)
+		}
+
+		report := ruletest.Coverage(custom, tested)
+		report.LoadErrors = loadErrors
+
+		if format == printers.FormatJson {
+			printers.PrintJson(report)
+		} else {
+			fmt.Printf("untested rules (%d): %s\n", len(report.Untested), strings.Join(report.Untested, ", "))
+			fmt.Printf("tested but missing rules (%d): %s\n", len(report.TestedButMissing), strings.Join(report.TestedButMissing, ", "))
+			fmt.Printf("coverage: %d/%d custom rules (%.1f%%)\n", report.CoveredRules, report.CustomRules, report.Percent)
+			for _, e := range report.LoadErrors {
+				fmt.Println(printers.Red(e))
+			}
+		}
+
+		failed := false
+		if len(report.LoadErrors) > 0 {
+			// a broken test file would otherwise just lower coverage, or not
+			// even that when its rules are asserted elsewhere
+			fmt.Fprintf(os.Stderr, "%d test files failed to load\n", len(report.LoadErrors))
+			failed = true
+		}
+		if report.Percent < minPercent {
+			fmt.Fprintf(os.Stderr, "coverage %.1f%% is below the required %.1f%%\n", report.Percent, minPercent)
+			failed = true
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ruleTestCoverageCmd.Flags().StringSlice("rules-dir", nil, "directory of local custom rule XML files (repeatable)")
+	ruleTestCoverageCmd.Flags().Bool("from-manager", false, "include the custom rules loaded by the manager from etc/rules")
+	ruleTestCoverageCmd.Flags().Float64("min", 0, "fail when coverage is below this percentage")
+}
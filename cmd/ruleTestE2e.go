@@ -0,0 +1,107 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/ruletest"
+	"github.com/spf13/cobra"
+)
+
+// ruleTestE2eCmd represents the rule test e2e command
+var ruleTestE2eCmd = &cobra.Command{
+	Use:   "e2e <file or dir>...",
+	Short: "run edge commands and confirm the expected alerts reach the indexer",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		container := cmd.Flag("container").Value.String()
+		agent := cmd.Flag("agent").Value.String()
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		interval, _ := cmd.Flags().GetDuration("poll-interval")
+
+		paths, err := ruletest.Discover(args)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		client := actions.IndexerClientFactory()
+		opts := ruletest.E2EOptions{
+			Container:    container,
+			Agent:        agent,
+			Timeout:      timeout,
+			PollInterval: interval,
+		}
+
+		var results []ruletest.E2EResult
+		failed := false
+		for _, path := range paths {
+			test, err := ruletest.Load(path)
+			if err != nil {
+				log.Println(err)
+				failed = true
+				continue
+			}
+			results = append(results, ruletest.RunE2E(ctx, path, test, client, opts)...)
+		}
+
+		if format == printers.FormatJson {
+			printers.PrintJson(results)
+		} else {
+			var rows [][]string
+			for _, r := range results {
+				latency := ""
+				if r.Status == ruletest.StatusPass {
+					latency = r.Latency.String()
+				}
+				rows = append(rows, []string{r.File, r.RuleId, r.Edge, string(r.Status), latency, r.Message})
+			}
+			printers.PrintTable([]string{"file", "rule", "edge", "status", "latency", "message"}, rows)
+		}
+
+		for _, r := range results {
+			if r.Status == ruletest.StatusFail {
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ruleTestE2eCmd.Flags().StringP("container", "c", "", "docker container to run edge commands in (runs locally when empty)")
+	ruleTestE2eCmd.Flags().String("agent", "", "only accept alerts raised by this agent name")
+	ruleTestE2eCmd.Flags().Duration("timeout", 2*time.Minute, "how long to wait for an alert after each command")
+	ruleTestE2eCmd.Flags().Duration("poll-interval", 5*time.Second, "delay between indexer queries")
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/EpykLab/wazctl/internal/files"
+	"github.com/EpykLab/wazctl/internal/templates/rules"
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/ruletest"
+	"github.com/spf13/cobra"
+)
+
+// ruleTestFromAlertCmd represents the rule test from-alert command
+var ruleTestFromAlertCmd = &cobra.Command{
+	Use:   "from-alert <alert _id>",
+	Short: "freeze an alert from the indexer as a rule test edge",
+	Long: `Fetches an alert from wazuh-alerts-* and appends an edge that replays its
+full_log and location through logtest, expecting the same rule id, level and
+decoder. The edge is added to --file, which is created when missing.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		docId := args[0]
+		path := cmd.Flag("file").Value.String()
+		author := cmd.Flag("author").Value.String()
+		title := cmd.Flag("title").Value.String()
+
+		alert, err := actions.IndexerClientFactory().GetAlertById(docId)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		edge, err := ruletest.EdgeFromAlert(docId, alert)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if title != "" {
+			edge.Title = title
+		}
+		if path == "" {
+			path = fmt.Sprintf("rule_%s.yaml", alert.Rule.Id)
+		}
+
+		content, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			out, err := ruletest.AppendEdge(content, edge)
+			if err != nil {
+				fmt.Printf("%s: %v\n", path, err)
+				os.Exit(1)
+			}
+			if err := files.FileCreateWithSpecifiedNameAndContent(path, *bytes.NewBuffer(out)); err != nil {
+				log.Fatalln(err)
+			}
+			fmt.Printf("added edge %q to %s\n", edge.Title, path)
+		case os.IsNotExist(err):
+			// The edge asserts the file's own rule, no override needed
+			edge.RuleId = ""
+			description := strings.TrimSpace(alert.Rule.Description)
+			if description == "" {
+				description = fmt.Sprintf("Rule %s", alert.Rule.Id)
+			}
+			test := v1.SchemaJson{
+				RuleId:      alert.Rule.Id,
+				RuleName:    description,
+				RuleAuthor:  author,
+				Description: fmt.Sprintf("Regression tests for rule %s frozen from real alerts", alert.Rule.Id),
+				Edges:       []v1.SchemaJsonEdgesElem{edge},
+			}
+			if err := files.FileCreateWithSpecifiedNameAndContent(path, rules.ScaffoldFromTempl(test)); err != nil {
+				log.Fatalln(err)
+			}
+			fmt.Printf("created %s with edge %q\n", path, edge.Title)
+		default:
+			log.Fatalln(err)
+		}
+	},
+}
+
+func init() {
+	ruleTestFromAlertCmd.Flags().StringP("file", "f", "", "rule test file to add the edge to (defaults to rule_<id>.yaml)")
+	ruleTestFromAlertCmd.Flags().String("title", "", "title of the new edge (defaults to Alert <_id>)")
+	ruleTestFromAlertCmd.Flags().String("author", "", "rule author written to a new test file")
+}
@@ -0,0 +1,168 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EpykLab/wazctl/internal/files"
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/internal/templates/rules"
+	"github.com/EpykLab/wazctl/pkg/ruletest"
+	"github.com/spf13/cobra"
+)
+
+// ruleTestImportIniCmd represents the rule test import-ini command
+var ruleTestImportIniCmd = &cobra.Command{
+	Use:   "import-ini <file or dir>...",
+	Short: "convert Wazuh ruleset .ini tests into rule test YAML files",
+	Long: `Converts the .ini test files used by the Wazuh ruleset's runtests.py
+into rule test files. Each "log N pass" line becomes an edge expecting the
+section's rule to fire with its alert level and decoder; each "log N fail"
+line becomes an edge expecting the rule not to fire.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		outputDir := cmd.Flag("output-dir").Value.String()
+		author := cmd.Flag("author").Value.String()
+		force := cmd.Flag("force").Changed
+
+		inis, err := discoverIniFiles(args)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if len(inis) == 0 {
+			fmt.Println("no .ini files found")
+			os.Exit(1)
+		}
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			log.Fatalln(err)
+		}
+
+		type imported struct {
+			Ini      string   `json:"ini"`
+			Yaml     string   `json:"yaml,omitempty"`
+			Edges    int      `json:"edges"`
+			Warnings []string `json:"warnings,omitempty"`
+			Error    string   `json:"error,omitempty"`
+		}
+
+		var report []imported
+		failed := false
+		for _, path := range inis {
+			entry := imported{Ini: path}
+
+			f, err := os.Open(path)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			sections, err := ruletest.ParseIni(f)
+			f.Close()
+			if err != nil {
+				entry.Error = err.Error()
+				report = append(report, entry)
+				failed = true
+				continue
+			}
+
+			test, warnings := ruletest.FromIni(path, sections, author)
+			entry.Warnings = warnings
+			entry.Edges = len(test.Edges)
+			if len(test.Edges) == 0 {
+				entry.Error = "no convertible test cases"
+				report = append(report, entry)
+				continue
+			}
+
+			out := filepath.Join(outputDir, test.RuleName+".yaml")
+			if _, err := os.Stat(out); err == nil && !force {
+				entry.Error = fmt.Sprintf("%s exists, use --force to overwrite", out)
+				report = append(report, entry)
+				failed = true
+				continue
+			}
+			if err := files.FileCreateWithSpecifiedNameAndContent(out, rules.ScaffoldFromTempl(*test)); err != nil {
+				log.Fatalln(err)
+			}
+			entry.Yaml = out
+			report = append(report, entry)
+		}
+
+		if format == printers.FormatJson {
+			printers.PrintJson(report)
+		} else {
+			var rows [][]string
+			for _, r := range report {
+				rows = append(rows, []string{r.Ini, r.Yaml, strconv.Itoa(r.Edges), strconv.Itoa(len(r.Warnings)), r.Error})
+			}
+			printers.PrintTable([]string{"ini", "yaml", "edges", "warnings", "error"}, rows)
+
+			for _, r := range report {
+				for _, w := range r.Warnings {
+					fmt.Printf("%s: %s\n", r.Ini, w)
+				}
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// discoverIniFiles expands paths into .ini files, walking directories.
+func discoverIniFiles(paths []string) ([]string, error) {
+	var found []string
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".ini") {
+				found = append(found, p)
+			}
+			return nil
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%s does not exist", path)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(found)
+	return found, nil
+}
+
+func init() {
+	ruleTestImportIniCmd.Flags().String("output-dir", ".", "directory to write the rule test files to")
+	ruleTestImportIniCmd.Flags().String("author", "Wazuh", "rule author written to the imported tests")
+	ruleTestImportIniCmd.Flags().Bool("force", false, "overwrite existing rule test files")
+}
@@ -0,0 +1,140 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/mutate"
+	"github.com/EpykLab/wazctl/pkg/ruletest"
+	"github.com/spf13/cobra"
+)
+
+// ruleTestMutateCmd represents the rule test mutate command
+var ruleTestMutateCmd = &cobra.Command{
+	Use:   "mutate [file or dir]...",
+	Short: "check whether trivially mutated test logs still trigger their rules",
+	Long: `Takes the log events of every passing edge that expects its rule to fire
+and replays mutated copies through logtest: case changes, extra whitespace,
+reordered key=value pairs, IPv6 instead of IPv4 addresses, Cyrillic
+homoglyphs and truncation. Mutations after which the rule no longer fires
+are reported as evasions.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		names, _ := cmd.Flags().GetStringSlice("mutator")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		showDetected := cmd.Flag("show-detected").Changed
+		failOnEvasion := cmd.Flag("fail-on-evasion").Changed
+
+		mutators, err := mutate.Find(names)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if parallel < 1 {
+			fmt.Println("[--parallel] must be at least 1")
+			os.Exit(1)
+		}
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		files, failed := loadRuleTestFiles(args)
+		results := ruletest.RunMutationFiles(ctx, actions.WazctlClientFactory(), files,
+			ruletest.RunOptions{Parallel: parallel}, mutators)
+
+		if format == printers.FormatJson {
+			printers.PrintJson(results)
+		} else {
+			printMutationReport(results, mutators, showDetected)
+		}
+
+		evaded := false
+		for _, r := range results {
+			evaded = evaded || r.Evaded
+		}
+		if failed || (failOnEvasion && evaded) {
+			os.Exit(1)
+		}
+	},
+}
+
+func printMutationReport(results []ruletest.MutationResult, mutators []mutate.Mutator, showDetected bool) {
+	var rows [][]string
+	for _, r := range results {
+		if !r.Evaded && r.Error == "" && !showDetected {
+			continue
+		}
+		outcome := "detected"
+		switch {
+		case r.Error != "":
+			outcome = "error"
+		case r.Evaded:
+			outcome = "EVADED"
+		}
+		instead := r.FiredInstead
+		if r.Error != "" {
+			instead = r.Error
+		}
+		rows = append(rows, []string{r.File, r.RuleId, r.Edge, r.Mutation, outcome, instead})
+	}
+	if len(rows) > 0 {
+		printers.PrintTable([]string{"file", "rule", "edge", "mutation", "result", "fired instead"}, rows)
+		fmt.Println()
+	}
+
+	var summary [][]string
+	for _, m := range mutators {
+		tried, evaded := 0, 0
+		for _, r := range results {
+			if r.Mutation != m.Name || r.Error != "" {
+				continue
+			}
+			tried++
+			if r.Evaded {
+				evaded++
+			}
+		}
+		rate := "-"
+		if tried > 0 {
+			rate = fmt.Sprintf("%d/%d (%.0f%%)", evaded, tried, float64(evaded)*100/float64(tried))
+		}
+		summary = append(summary, []string{m.Name, m.Description, rate})
+	}
+	printers.PrintTable([]string{"mutation", "description", "evaded"}, summary)
+}
+
+func init() {
+	ruleTestMutateCmd.Flags().StringSlice("mutator", nil, fmt.Sprintf("mutators to apply (repeatable, default all) [%s]", strings.Join(mutate.Names(), ", ")))
+	ruleTestMutateCmd.Flags().IntP("parallel", "p", 1, "number of test files to run concurrently")
+	ruleTestMutateCmd.Flags().Bool("show-detected", false, "also list mutations that still trigger the rule")
+	ruleTestMutateCmd.Flags().Bool("fail-on-evasion", false, "exit non-zero when any mutation evades its rule")
+}
@@ -0,0 +1,292 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/internal/rulexml"
+	"github.com/EpykLab/wazctl/internal/watch"
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/ruletest"
+	"github.com/spf13/cobra"
+)
+
+// ruleTestRunCmd represents the rule test run command
+var ruleTestRunCmd = &cobra.Command{
+	Use:   "run [file or dir]...",
+	Short: "replay edge log events through the manager's logtest and check the expected rules fire",
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		watching := cmd.Flag("watch").Changed
+		rulesDirs, _ := cmd.Flags().GetStringSlice("rules-dir")
+		noUpload := cmd.Flag("no-upload").Changed
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		failFast := cmd.Flag("fail-fast").Changed
+
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		if parallel < 1 {
+			fmt.Println("[--parallel] must be at least 1")
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		client := actions.WazctlClientFactory()
+		opts := ruletest.RunOptions{Parallel: parallel, FailFast: failFast}
+
+		if !watching {
+			results, failed := runRuleTestFiles(ctx, client, args, opts)
+			printRuleTestResults(format, results)
+			if failed || ruletest.Failed(results) {
+				os.Exit(1)
+			}
+			return
+		}
+
+		if err := watchRuleTests(ctx, client, args, rulesDirs, !noUpload, opts); err != nil {
+			log.Fatalln(err)
+		}
+	},
+}
+
+// runRuleTestFiles discovers and runs every test file below paths. The bool
+// reports whether a file could not be loaded.
+func runRuleTestFiles(ctx context.Context, client *actions.WazctlClient, paths []string, opts ruletest.RunOptions) ([]ruletest.Result, bool) {
+	files, failed := loadRuleTestFiles(paths)
+	return ruletest.RunLogtestFiles(ctx, client, files, opts), failed
+}
+
+// loadRuleTestFiles discovers and loads every test file below paths. Files
+// that fail to load are logged and reported through the bool.
+func loadRuleTestFiles(paths []string) ([]ruletest.TestFile, bool) {
+	found, err := ruletest.Discover(paths)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var files []ruletest.TestFile
+	failed := false
+	for _, path := range found {
+		test, err := ruletest.Load(path)
+		if err != nil {
+			log.Println(err)
+			failed = true
+			continue
+		}
+		files = append(files, ruletest.TestFile{Path: path, Test: test})
+	}
+
+	return files, failed
+}
+
+func printRuleTestResults(format printers.Format, results []ruletest.Result) {
+	if format == printers.FormatJson {
+		printers.PrintJson(results)
+		return
+	}
+
+	var rows [][]string
+	for _, r := range results {
+		rows = append(rows, []string{r.File, r.RuleId, r.Edge, string(r.Status), r.Message})
+	}
+	printers.PrintTable([]string{"file", "rule", "edge", "status", "message"}, rows)
+}
+
+// watchRuleTests runs every test once and then re-runs the affected tests
+// whenever a test file or a rule file in rulesDirs changes. Changed rule
+// files are uploaded to the manager first when upload is set.
+func watchRuleTests(ctx context.Context, client *actions.WazctlClient, testPaths, rulesDirs []string, upload bool, opts ruletest.RunOptions) error {
+	tests := make(map[string]*v1.SchemaJson)
+	files, err := ruletest.Discover(testPaths)
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		loadWatchedTest(tests, path)
+	}
+
+	// Rule ids defined by each rule file, so ids removed from a file still
+	// select the tests that asserted them
+	ruleFileIds := make(map[string][]string)
+	for _, dir := range rulesDirs {
+		rules, err := parseRulesDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			ruleFileIds[rule.File] = append(ruleFileIds[rule.File], rule.Id)
+		}
+	}
+
+	w, err := watch.New(append(append([]string{}, testPaths...), rulesDirs...))
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	runWatchCycle(ctx, client, tests, sortedKeys(tests), opts)
+
+	go func() {
+		for err := range w.Errors() {
+			log.Println(err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		w.Close()
+	}()
+
+	for {
+		changed := watch.Collect(w, 300*time.Millisecond)
+		if changed == nil {
+			return nil
+		}
+
+		var changedTests, changedRuleIds []string
+		for _, path := range changed {
+			switch {
+			case ruletest.IsTestFile(path):
+				loadWatchedTest(tests, path)
+				changedTests = append(changedTests, path)
+			case ruletest.IsRuleFile(path):
+				changedRuleIds = append(changedRuleIds, ruleFileIds[path]...)
+				rules, err := rulexml.ParseFile(path)
+				if err != nil {
+					fmt.Println(printers.Red(fmt.Sprintf("✘ %s: %v", path, err)))
+					delete(ruleFileIds, path)
+					continue
+				}
+				ruleFileIds[path] = rulexml.Ids(rules)
+				changedRuleIds = append(changedRuleIds, ruleFileIds[path]...)
+
+				if upload {
+					if err := uploadRuleFile(client, path); err != nil {
+						fmt.Println(printers.Red(fmt.Sprintf("✘ upload %s: %v", path, err)))
+						continue
+					}
+					fmt.Println(printers.Dim(fmt.Sprintf("↑ uploaded %s", filepath.Base(path))))
+				}
+			}
+		}
+
+		if affected := ruletest.Affected(tests, changedTests, changedRuleIds); len(affected) > 0 {
+			runWatchCycle(ctx, client, tests, affected, opts)
+		}
+	}
+}
+
+// loadWatchedTest refreshes a test file in tests, dropping it when it was
+// removed or no longer loads.
+func loadWatchedTest(tests map[string]*v1.SchemaJson, path string) {
+	if _, err := os.Stat(path); err != nil {
+		delete(tests, path)
+		return
+	}
+	test, err := ruletest.Load(path)
+	if err != nil {
+		fmt.Println(printers.Red(fmt.Sprintf("✘ %v", err)))
+		delete(tests, path)
+		return
+	}
+	tests[path] = test
+}
+
+func uploadRuleFile(client *actions.WazctlClient, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		// Removed locally; leave the manager copy alone
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	_, err = client.PutRuleFileInWazuhManager(filepath.Base(path), content, true)
+	return err
+}
+
+// runWatchCycle runs the given tests and prints a compact summary listing
+// only the edges that did not pass.
+func runWatchCycle(ctx context.Context, client *actions.WazctlClient, tests map[string]*v1.SchemaJson, paths []string, opts ruletest.RunOptions) {
+	files := make([]ruletest.TestFile, 0, len(paths))
+	for _, path := range paths {
+		files = append(files, ruletest.TestFile{Path: path, Test: tests[path]})
+	}
+	results := ruletest.RunLogtestFiles(ctx, client, files, opts)
+
+	passed, failed, skipped := 0, 0, 0
+	for _, r := range results {
+		switch r.Status {
+		case ruletest.StatusPass:
+			passed++
+		case ruletest.StatusFail:
+			failed++
+			fmt.Println(printers.Red(fmt.Sprintf("  ✘ %s › %s: %s", r.File, r.Edge, r.Message)))
+		default:
+			skipped++
+		}
+	}
+
+	summary := fmt.Sprintf("%s %s  %s  %s  (%d files)",
+		time.Now().Format("15:04:05"),
+		printers.Green(fmt.Sprintf("✔ %d passed", passed)),
+		colorIf(failed > 0, printers.Red, fmt.Sprintf("✘ %d failed", failed)),
+		printers.Yellow(fmt.Sprintf("- %d skipped", skipped)),
+		len(paths))
+	fmt.Println(summary)
+}
+
+func colorIf(cond bool, color func(string) string, s string) string {
+	if cond {
+		return color(s)
+	}
+	return printers.Dim(s)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	ruleTestRunCmd.Flags().Bool("watch", false, "re-run affected tests whenever a test or rule file changes")
+	ruleTestRunCmd.Flags().StringSlice("rules-dir", nil, "local rule XML directory to watch and upload from (repeatable)")
+	ruleTestRunCmd.Flags().Bool("no-upload", false, "do not upload changed rule files to the manager in watch mode")
+	ruleTestRunCmd.Flags().IntP("parallel", "p", 1, "number of test files to run concurrently, each with its own logtest session")
+	ruleTestRunCmd.Flags().Bool("fail-fast", false, "stop starting new tests after the first failure")
+}
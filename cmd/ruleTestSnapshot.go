@@ -0,0 +1,99 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/ruletest"
+	"github.com/spf13/cobra"
+)
+
+// ruleTestSnapshotCmd represents the rule test snapshot command
+var ruleTestSnapshotCmd = &cobra.Command{
+	Use:   "snapshot [file or dir]...",
+	Short: "compare the full logtest output of every edge with golden files next to the tests",
+	Long: `Replays every edge log event through logtest and compares the decoded
+fields and rule output with <test>.golden.json next to each test file.
+Timestamps, alert ids and manager names are ignored. Edges without a
+recorded snapshot are recorded on the first run; use --update to refresh
+every snapshot after an intentional change.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		update := cmd.Flag("update").Changed
+		parallel, _ := cmd.Flags().GetInt("parallel")
+
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		if parallel < 1 {
+			fmt.Println("[--parallel] must be at least 1")
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		files, failed := loadRuleTestFiles(args)
+		results := ruletest.RunSnapshotFiles(ctx, actions.WazctlClientFactory(), files,
+			ruletest.RunOptions{Parallel: parallel}, update)
+
+		printRuleTestResults(format, results)
+		if format != printers.FormatJson {
+			printSnapshotDiffs(results)
+		}
+
+		if failed || ruletest.Failed(results) {
+			os.Exit(1)
+		}
+	},
+}
+
+func printSnapshotDiffs(results []ruletest.Result) {
+	for _, r := range results {
+		if len(r.Diff) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s › %s\n", r.File, r.Edge)
+		for _, line := range r.Diff {
+			switch {
+			case strings.HasPrefix(line, "- "):
+				fmt.Println(printers.Red(line))
+			case strings.HasPrefix(line, "+ "):
+				fmt.Println(printers.Green(line))
+			default:
+				fmt.Println(printers.Dim(line))
+			}
+		}
+	}
+}
+
+func init() {
+	ruleTestSnapshotCmd.Flags().Bool("update", false, "rewrite the golden files with the current output")
+	ruleTestSnapshotCmd.Flags().IntP("parallel", "p", 1, "number of test files to run concurrently")
+}
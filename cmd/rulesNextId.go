@@ -0,0 +1,115 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/EpykLab/wazctl/config"
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/internal/rulexml"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/spf13/cobra"
+)
+
+// rulesNextIdCmd represents the rules next-id command
+var rulesNextIdCmd = &cobra.Command{
+	Use:   "next-id",
+	Short: "find unused rule ids in the custom range",
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		count, _ := cmd.Flags().GetInt("count")
+		rulesDirs, _ := cmd.Flags().GetStringSlice("rules-dir")
+		offline := cmd.Flag("offline").Changed
+
+		idRange, err := rulexml.ParseIdRange(cmd.Flag("range").Value.String())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		ids, err := nextFreeRuleIds(rulesDirs, offline, idRange, count)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if format == printers.FormatJson {
+			printers.PrintJson(ids)
+			return
+		}
+		for _, id := range ids {
+			fmt.Println(id)
+		}
+	},
+}
+
+// nextFreeRuleIds collects the rule ids used in rulesDirs and, unless offline
+// is set, in the manager's rule catalogue, and returns count unused ids.
+func nextFreeRuleIds(rulesDirs []string, offline bool, idRange rulexml.IdRange, count int) ([]int, error) {
+	var used []string
+	for _, dir := range rulesDirs {
+		rules, err := parseRulesDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		used = append(used, rulexml.Ids(rules)...)
+	}
+
+	if !offline {
+		if _, err := config.New(); err != nil {
+			return nil, fmt.Errorf("%w; use --offline to only scan local rule files", err)
+		}
+		client := actions.WazctlClientFactory()
+		rules, err := client.GetRulesFromWazuhManager(nil)
+		if err != nil {
+			return nil, fmt.Errorf("reading the manager's rule catalogue (use --offline to only scan local rule files): %w", err)
+		}
+		used = append(used, actions.WazuhRuleIds(rules)...)
+	}
+
+	return rulexml.NextFreeIds(used, idRange, count)
+}
+
+// parseRulesDir parses the rule files below dir, warning about XML files
+// that are not rule files instead of failing on them.
+func parseRulesDir(dir string) ([]rulexml.Rule, error) {
+	rules, skipped, err := rulexml.ParseDir(dir)
+	for _, err := range skipped {
+		log.Printf("skipping %v", err)
+	}
+	return rules, err
+}
+
+// addRuleIdAllocationFlags registers the flags shared by commands that
+// allocate rule ids.
+func addRuleIdAllocationFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("rules-dir", []string{"."}, "directory of local rule XML files to scan for used ids (repeatable)")
+	cmd.Flags().String("range", rulexml.DefaultCustomRange.String(), "inclusive range of ids to allocate from")
+	cmd.Flags().Bool("offline", false, "only scan local rule files, not the manager's rule catalogue")
+}
+
+func init() {
+	rulesNextIdCmd.Flags().IntP("count", "c", 1, "number of ids to return")
+	addRuleIdAllocationFlags(rulesNextIdCmd)
+}
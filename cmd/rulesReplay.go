@@ -0,0 +1,190 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/internal/timeframe"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+	"github.com/EpykLab/wazctl/pkg/replay"
+	"github.com/spf13/cobra"
+)
+
+// maxReplaySample is the largest page a single search may return.
+const maxReplaySample = 10000
+
+// rulesReplayCmd represents the rules replay command
+var rulesReplayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "replay sampled production events through logtest and report classification changes",
+	Long: `Pulls a random sample of events with a full_log from the alerts (or
+archives) index and runs each through logtest on the configured manager,
+typically a staging manager carrying the ruleset change. Samples whose rule
+or level changed are listed, followed by a summary of gained, lost and
+re-levelled samples per rule id.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+		sampleSize, _ := cmd.Flags().GetInt("sample")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		seed, _ := cmd.Flags().GetInt64("seed")
+		showAll := cmd.Flag("all").Changed
+
+		since, err := timeframe.ParseTime(cmd.Flag("since").Value.String(), time.Now())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if sampleSize < 1 || sampleSize > maxReplaySample {
+			fmt.Printf("[--sample] must be between 1 and %d\n", maxReplaySample)
+			os.Exit(1)
+		}
+		if parallel < 1 {
+			fmt.Println("[--parallel] must be at least 1")
+			os.Exit(1)
+		}
+
+		var index string
+		switch cmd.Flag("index").Value.String() {
+		case "alerts":
+			index = string(opensearch.AlertsIndexPattern)
+		case "archives":
+			index = string(opensearch.ArchivesIndexPattern)
+		default:
+			fmt.Println("[--index] must be one of [alerts, archives]")
+			os.Exit(1)
+		}
+		if !cmd.Flag("seed").Changed {
+			seed = time.Now().UnixNano()
+		}
+
+		hits, err := actions.IndexerClientFactory().SampleAlerts(index, since, sampleSize, seed)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		samples := make([]replay.Sample, 0, len(hits))
+		for _, hit := range hits {
+			alert, err := hit.Alert()
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			samples = append(samples, replay.Sample{DocId: hit.Id, Alert: *alert})
+		}
+		if len(samples) == 0 {
+			fmt.Printf("no events with a full_log in %s since %s\n", index, since.Format(time.RFC3339))
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		outcomes := replay.Run(ctx, actions.WazctlClientFactory(), samples, parallel)
+		summary := replay.Summarize(outcomes)
+
+		if format == printers.FormatJson {
+			printers.PrintJson(map[string]any{
+				"index":    index,
+				"since":    since,
+				"seed":     seed,
+				"outcomes": outcomes,
+				"summary":  summary,
+			})
+			return
+		}
+
+		printReplayReport(outcomes, summary, showAll)
+		fmt.Printf("\nreplayed %d samples from %s since %s (seed %d)\n",
+			len(outcomes), index, since.Format(time.RFC3339), seed)
+	},
+}
+
+func printReplayReport(outcomes []replay.Outcome, summary []replay.RuleSummary, showAll bool) {
+	counts := make(map[replay.Kind]int)
+	var rows [][]string
+	for _, o := range outcomes {
+		counts[o.Kind]++
+		if o.Kind == replay.KindUnchanged && !showAll {
+			continue
+		}
+		rows = append(rows, []string{
+			o.DocId,
+			string(o.Kind),
+			replayMatch(o.Before),
+			replayMatch(o.After),
+			o.Error,
+		})
+	}
+	if len(rows) > 0 {
+		printers.PrintTable([]string{"sample", "change", "before", "after", "error"}, rows)
+		fmt.Println()
+	}
+
+	rows = nil
+	for _, s := range summary {
+		if !s.Changed() && !showAll {
+			continue
+		}
+		rows = append(rows, []string{
+			s.RuleId,
+			strconv.Itoa(s.Before),
+			strconv.Itoa(s.After),
+			fmt.Sprintf("%+d", s.After-s.Before),
+			strconv.Itoa(s.Gained),
+			strconv.Itoa(s.Lost),
+			strconv.Itoa(s.Relevelled),
+		})
+	}
+	if len(rows) == 0 {
+		fmt.Println(printers.Green("no classification changes"))
+	} else {
+		printers.PrintTable([]string{"rule", "before", "after", "net", "gained", "lost", "relevelled"}, rows)
+	}
+
+	fmt.Printf("\nunchanged %d, relevelled %d, reclassified %d, lost %d, gained %d, errors %d\n",
+		counts[replay.KindUnchanged], counts[replay.KindRelevelled], counts[replay.KindReclassified],
+		counts[replay.KindLost], counts[replay.KindGained], counts[replay.KindError])
+}
+
+func replayMatch(m replay.Match) string {
+	if m.RuleId == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%d)", m.RuleId, m.Level)
+}
+
+func init() {
+	rulesReplayCmd.Flags().String("since", "24h", "sample events newer than this, as a duration or RFC3339 timestamp")
+	rulesReplayCmd.Flags().Int("sample", 100, "number of events to sample")
+	rulesReplayCmd.Flags().String("index", "alerts", "index to sample from [alerts, archives]")
+	rulesReplayCmd.Flags().Int64("seed", 0, "random seed, to replay the same sample again (defaults to a new seed)")
+	rulesReplayCmd.Flags().IntP("parallel", "p", 4, "number of events replayed concurrently")
+	rulesReplayCmd.Flags().Bool("all", false, "also list unchanged samples and rules")
+}
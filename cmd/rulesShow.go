@@ -0,0 +1,195 @@
+/*
+Copyright © 2025 stllr
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/EpykLab/wazctl/internal/printers"
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/ruletree"
+	"github.com/spf13/cobra"
+)
+
+// maxGroupMembers is how many rules of an if_group parent are listed before
+// the rest are summarised.
+const maxGroupMembers = 5
+
+// ruleShowReport is the JSON output of rules show.
+type ruleShowReport struct {
+	Rule     actions.WazuhRule `json:"rule"`
+	Ancestry []ruletree.Node   `json:"ancestry"`
+	Children []ruletree.Child  `json:"children"`
+	Xml      string            `json:"xml"`
+	XmlError string            `json:"xml_error,omitempty"`
+}
+
+// rulesShowCmd represents the rules show command
+var rulesShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "show a rule with its ancestry, children and XML",
+	Long: `Fetches a rule from the manager and prints its file, level, groups,
+compliance mappings and MITRE ids, the chain of rules it is evaluated below
+(following if_sid, if_matched_sid, if_group and if_matched_group up to the
+root rules), the rules evaluated directly below it and its XML definition.
+Groups named by if_group are listed with their member rules, and the first
+few members are walked in turn. A rule whose parents are already shown is
+marked "see above" instead of being walked again.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat(cmd)
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Printf("invalid rule id %q\n", args[0])
+			os.Exit(1)
+		}
+
+		client := actions.WazctlClientFactory()
+		rules, err := client.GetRulesFromWazuhManager(nil)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		tree := ruletree.New(rules)
+		rule, ok := tree.Rule(id)
+		if !ok {
+			fmt.Printf("rule %d not found in the manager\n", id)
+			os.Exit(1)
+		}
+
+		report := ruleShowReport{
+			Rule:     rule,
+			Ancestry: tree.Ancestry(id),
+			Children: tree.Children(id),
+		}
+		if parsed, err := parseManagerRule(client, &rule); err != nil {
+			report.XmlError = err.Error()
+		} else {
+			report.Xml = parsed.Raw
+		}
+
+		if format == printers.FormatJson {
+			printers.PrintJson(report)
+			return
+		}
+
+		printRuleShow(report)
+	},
+}
+
+// printRuleShow prints the rule details, its ancestry as a tree, a table of
+// children and the rule XML.
+func printRuleShow(report ruleShowReport) {
+	r := report.Rule
+
+	fmt.Printf("Rule %d (level %d)\n", r.Id, r.Level)
+	for _, line := range [][2]string{
+		{"description", r.Description},
+		{"file", r.Filename},
+		{"path", r.RelativeDirname},
+		{"status", r.Status},
+		{"groups", strings.Join(r.Groups, ", ")},
+		{"mitre", strings.Join(r.Mitre, ", ")},
+		{"pci_dss", strings.Join(r.PciDss, ", ")},
+		{"gdpr", strings.Join(r.Gdpr, ", ")},
+		{"hipaa", strings.Join(r.Hipaa, ", ")},
+		{"nist_800_53", strings.Join(r.Nist80053, ", ")},
+		{"gpg13", strings.Join(r.Gpg13, ", ")},
+		{"tsc", strings.Join(r.Tsc, ", ")},
+	} {
+		if line[1] != "" {
+			fmt.Printf("  %-12s %s\n", line[0]+":", line[1])
+		}
+	}
+
+	fmt.Println("\nAncestry")
+	fmt.Printf("  %d %s\n", r.Id, r.Description)
+	if len(report.Ancestry) == 0 {
+		fmt.Println(printers.Dim("  root rule, not evaluated below another rule"))
+	}
+	printAncestry(report.Ancestry, "  ")
+
+	fmt.Println("\nChildren")
+	if len(report.Children) == 0 {
+		fmt.Println(printers.Dim("  no rules are evaluated below this rule"))
+	} else {
+		var rows [][]string
+		for _, c := range report.Children {
+			rows = append(rows, []string{strconv.Itoa(c.Id), strconv.Itoa(c.Level), c.Via + " " + c.Value, c.Description, c.File})
+		}
+		printers.PrintTable([]string{"id", "level", "via", "description", "file"}, rows)
+	}
+
+	fmt.Println("\nXML")
+	if report.XmlError != "" {
+		fmt.Println(printers.Red("  " + report.XmlError))
+		return
+	}
+	fmt.Println(report.Xml)
+}
+
+// printAncestry prints parent nodes as a tree below the rule they belong to.
+func printAncestry(nodes []ruletree.Node, indent string) {
+	for i, n := range nodes {
+		branch, next := "├─ ", "│  "
+		if i == len(nodes)-1 {
+			branch, next = "└─ ", "   "
+		}
+
+		switch {
+		case strings.HasSuffix(n.Via, "_group"):
+			fmt.Printf("%s%s%s %s: %s\n", indent, branch, n.Via, n.Value, groupMembers(n.Members))
+			printAncestry(n.Parents, indent+next)
+		case n.Rule == nil:
+			fmt.Printf("%s%s%s %s %s\n", indent, branch, n.Via, n.Value, printers.Red("not loaded in the manager"))
+		case n.Cycle:
+			fmt.Printf("%s%s%s %d %s %s\n", indent, branch, n.Via, n.Rule.Id, n.Rule.Description, printers.Dim("(cycle)"))
+		case n.Seen:
+			fmt.Printf("%s%s%s %d %s %s\n", indent, branch, n.Via, n.Rule.Id, n.Rule.Description, printers.Dim("(see above)"))
+		default:
+			fmt.Printf("%s%s%s %d %s\n", indent, branch, n.Via, n.Rule.Id, n.Rule.Description)
+			printAncestry(n.Parents, indent+next)
+		}
+	}
+}
+
+// groupMembers summarises the rules of an if_group parent.
+func groupMembers(members []ruletree.Ref) string {
+	if len(members) == 0 {
+		return printers.Red("no rules in group")
+	}
+
+	var ids []string
+	for _, m := range members[:min(len(members), maxGroupMembers)] {
+		ids = append(ids, strconv.Itoa(m.Id))
+	}
+	summary := fmt.Sprintf("%d rules (%s", len(members), strings.Join(ids, ", "))
+	if len(members) > maxGroupMembers {
+		summary += fmt.Sprintf(", +%d more", len(members)-maxGroupMembers)
+	}
+	return summary + ")"
+}
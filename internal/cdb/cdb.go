@@ -0,0 +1,200 @@
+package cdb
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Entry is a single key:value line of a CDB list.
+type Entry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Line the entry was read from, 0 when the entry was generated
+	Line int `json:"line,omitempty"`
+}
+
+// List is a CDB list in file order.
+type List struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Problem describes an entry that Wazuh would reject or silently shadow.
+type Problem struct {
+	Line   int    `json:"line"`
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%q: %s", p.Key, p.Reason)
+	}
+	return fmt.Sprintf("line %d: %q: %s", p.Line, p.Key, p.Reason)
+}
+
+// Parse reads a CDB list in the key:value format used by the Wazuh manager.
+// Keys containing a colon must be wrapped in double quotes. Every entry is
+// returned, including invalid and duplicate ones, alongside the problems found.
+func Parse(content []byte) (*List, []Problem) {
+	list := &List{}
+	var problems []Problem
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		key, value, err := splitLine(line)
+		if err != nil {
+			problems = append(problems, Problem{Line: lineNo, Key: line, Reason: err.Error()})
+			continue
+		}
+
+		list.Entries = append(list.Entries, Entry{Key: key, Value: value, Line: lineNo})
+	}
+
+	return list, append(problems, list.Validate()...)
+}
+
+// splitLine separates the key from the value, honouring quoted keys.
+func splitLine(line string) (string, string, error) {
+	if strings.HasPrefix(line, `"`) {
+		end := strings.Index(line[1:], `"`)
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated quoted key")
+		}
+		key := line[1 : end+1]
+		rest := line[end+2:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", fmt.Errorf("missing ':' after quoted key")
+		}
+		return key, rest[1:], nil
+	}
+
+	key, value, found := strings.Cut(line, ":")
+	if !found {
+		return "", "", fmt.Errorf("missing ':' separator")
+	}
+	return key, value, nil
+}
+
+// Validate reports invalid keys and keys that appear more than once.
+func (l *List) Validate() []Problem {
+	var problems []Problem
+	seen := make(map[string]int)
+
+	for _, e := range l.Entries {
+		if reason := invalidKey(e.Key); reason != "" {
+			problems = append(problems, Problem{Line: e.Line, Key: e.Key, Reason: reason})
+			continue
+		}
+		if first, ok := seen[e.Key]; ok {
+			problems = append(problems, Problem{
+				Line:   e.Line,
+				Key:    e.Key,
+				Reason: fmt.Sprintf("duplicate key (first defined on line %d)", first),
+			})
+			continue
+		}
+		seen[e.Key] = e.Line
+	}
+
+	return problems
+}
+
+func invalidKey(key string) string {
+	switch {
+	case key == "":
+		return "empty key"
+	case strings.TrimSpace(key) != key:
+		return "leading or trailing whitespace in key"
+	case strings.Contains(key, `"`):
+		return "double quote in key"
+	}
+	for _, r := range key {
+		if unicode.IsControl(r) {
+			return "control character in key"
+		}
+	}
+	return ""
+}
+
+// Map returns the entries keyed by key. Later duplicates are ignored, which
+// mirrors how the manager resolves lookups.
+func (l *List) Map() map[string]string {
+	m := make(map[string]string, len(l.Entries))
+	for _, e := range l.Entries {
+		if _, ok := m[e.Key]; !ok {
+			m[e.Key] = e.Value
+		}
+	}
+	return m
+}
+
+// Bytes renders the list in CDB format, quoting keys that contain a colon.
+// Keys are written as they are: the manager does not unescape quoted keys.
+func (l *List) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, e := range l.Entries {
+		if strings.Contains(e.Key, ":") {
+			fmt.Fprintf(&buf, "\"%s\":%s\n", e.Key, e.Value)
+		} else {
+			fmt.Fprintf(&buf, "%s:%s\n", e.Key, e.Value)
+		}
+	}
+	return buf.Bytes()
+}
+
+// Change is a single difference between two lists.
+type Change struct {
+	// One of "+" (added), "-" (removed) or "~" (value changed)
+	Op       string `json:"op"`
+	Key      string `json:"key"`
+	OldValue string `json:"old_value,omitempty"`
+	NewValue string `json:"new_value,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Op {
+	case "+":
+		return fmt.Sprintf("+ %s:%s", c.Key, c.NewValue)
+	case "-":
+		return fmt.Sprintf("- %s:%s", c.Key, c.OldValue)
+	default:
+		return fmt.Sprintf("~ %s:%s -> %s", c.Key, c.OldValue, c.NewValue)
+	}
+}
+
+// Diff returns the changes needed to turn from into to, sorted by key.
+func Diff(from, to *List) []Change {
+	oldMap, newMap := from.Map(), to.Map()
+	var changes []Change
+
+	for key, newValue := range newMap {
+		oldValue, ok := oldMap[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Op: "+", Key: key, NewValue: newValue})
+		case oldValue != newValue:
+			changes = append(changes, Change{Op: "~", Key: key, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	for key, oldValue := range oldMap {
+		if _, ok := newMap[key]; !ok {
+			changes = append(changes, Change{Op: "-", Key: key, OldValue: oldValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
@@ -0,0 +1,159 @@
+package cdb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	content := `10.0.0.1:malicious
+"fe80::1":link-local
+10.0.0.1:again
+:nokey
+novalue
+192.168.:
+`
+	list, problems := Parse([]byte(content))
+
+	wantKeys := []string{"10.0.0.1", "fe80::1", "10.0.0.1", "", "192.168."}
+	var gotKeys []string
+	for _, e := range list.Entries {
+		gotKeys = append(gotKeys, e.Key)
+	}
+	if !reflect.DeepEqual(gotKeys, wantKeys) {
+		t.Errorf("Parse() keys = %v, want %v", gotKeys, wantKeys)
+	}
+
+	wantReasons := map[int]string{
+		3: "duplicate key",
+		4: "empty key",
+		5: "missing ':' separator",
+	}
+	if len(problems) != len(wantReasons) {
+		t.Fatalf("Parse() problems = %v, want %d", problems, len(wantReasons))
+	}
+	for _, p := range problems {
+		if !strings.Contains(p.Reason, wantReasons[p.Line]) {
+			t.Errorf("problem on line %d = %q, want %q", p.Line, p.Reason, wantReasons[p.Line])
+		}
+	}
+}
+
+func TestBytesRoundTrip(t *testing.T) {
+	list := &List{Entries: []Entry{
+		{Key: "fe80::1", Value: "v6"},
+		{Key: "evil.example.com", Value: ""},
+		{Key: `C:\Windows\x.exe`, Value: "lolbin"},
+	}}
+	want := "\"fe80::1\":v6\nevil.example.com:\n\"C:\\Windows\\x.exe\":lolbin\n"
+	if got := string(list.Bytes()); got != want {
+		t.Errorf("Bytes() = %q, want %q", got, want)
+	}
+
+	parsed, problems := Parse(list.Bytes())
+	if len(problems) != 0 {
+		t.Errorf("Parse(Bytes()) problems = %v", problems)
+	}
+	if !reflect.DeepEqual(parsed.Map(), list.Map()) {
+		t.Errorf("Parse(Bytes()) = %v, want %v", parsed.Map(), list.Map())
+	}
+}
+
+func TestDiff(t *testing.T) {
+	from, _ := Parse([]byte("a:1\nb:2\nc:3\n"))
+	to, _ := Parse([]byte("a:1\nb:20\nd:4\n"))
+
+	want := []Change{
+		{Op: "~", Key: "b", OldValue: "2", NewValue: "20"},
+		{Op: "-", Key: "c", OldValue: "3"},
+		{Op: "+", Key: "d", NewValue: "4"},
+	}
+	if got := Diff(from, to); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %v, want %v", got, want)
+	}
+}
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		kind    Kind
+		want    string
+		wantErr bool
+	}{
+		{name: "ipv4", key: "10.1.2.3", kind: KindIP, want: "10.1.2.3"},
+		{name: "ipv4 /24", key: "10.1.2.0/24", kind: KindIP, want: "10.1.2."},
+		{name: "ipv4 /16 host bits", key: "10.1.2.3/16", kind: KindIP, want: "10.1."},
+		{name: "ipv4 /32", key: "10.1.2.3/32", kind: KindIP, want: "10.1.2.3"},
+		{name: "ipv4 /20", key: "10.1.0.0/20", kind: KindIP, wantErr: true},
+		{name: "ipv6", key: "2001:DB8::1", kind: KindIP, want: "2001:db8::1"},
+		{name: "ipv6 network", key: "2001:db8::/32", kind: KindIP, wantErr: true},
+		{name: "not ip", key: "example.com", kind: KindIP, wantErr: true},
+		{name: "md5", key: "D41D8CD98F00B204E9800998ECF8427E", kind: KindHash, want: "d41d8cd98f00b204e9800998ecf8427e"},
+		{name: "bad hash length", key: "abcd", kind: KindHash, wantErr: true},
+		{name: "non hex", key: "zz1d8cd98f00b204e9800998ecf8427e", kind: KindHash, wantErr: true},
+		{name: "any", key: "anything goes", kind: KindAny, want: "anything goes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeKey(tt.key, tt.kind)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NormalizeKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	feed := `indicator,threat
+# comment line
+10.0.0.1,botnet
+10.0.0.0/24,scanner
+10.0.0.1,duplicate
+not-an-ip,junk
+`
+	list, problems, err := ImportCSV(strings.NewReader(feed), ImportOptions{
+		KeyColumn:   "indicator",
+		ValueColumn: "threat",
+		Header:      true,
+		Kind:        KindIP,
+	})
+	if err != nil {
+		t.Fatalf("ImportCSV() error = %v", err)
+	}
+
+	want := map[string]string{"10.0.0.1": "botnet", "10.0.0.": "scanner"}
+	if !reflect.DeepEqual(list.Map(), want) {
+		t.Errorf("ImportCSV() = %v, want %v", list.Map(), want)
+	}
+	// the comment line still counts towards line numbers
+	if len(problems) != 2 || problems[0].Line != 5 || problems[1].Line != 6 {
+		t.Errorf("ImportCSV() problems = %v, want lines 5 and 6", problems)
+	}
+}
+
+func TestImportPlain(t *testing.T) {
+	feed := `# sha256 feed
+E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855 eicar
+
+e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+`
+	list, problems, err := ImportPlain(strings.NewReader(feed), ImportOptions{
+		Kind:         KindHash,
+		DefaultValue: "malware",
+	})
+	if err != nil {
+		t.Fatalf("ImportPlain() error = %v", err)
+	}
+	if len(list.Entries) != 1 || list.Entries[0].Value != "malware" {
+		t.Errorf("ImportPlain() = %+v", list.Entries)
+	}
+	if len(problems) != 1 || problems[0].Line != 4 {
+		t.Errorf("ImportPlain() problems = %v, want duplicate on line 4", problems)
+	}
+}
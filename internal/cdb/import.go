@@ -0,0 +1,241 @@
+package cdb
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Kind restricts and normalises the keys accepted during an import.
+type Kind string
+
+const (
+	KindAny  Kind = "any"
+	KindIP   Kind = "ip"
+	KindHash Kind = "hash"
+)
+
+// ParseKind validates a user supplied key kind.
+func ParseKind(value string) (Kind, error) {
+	switch Kind(strings.ToLower(value)) {
+	case KindAny, "":
+		return KindAny, nil
+	case KindIP:
+		return KindIP, nil
+	case KindHash:
+		return KindHash, nil
+	default:
+		return "", fmt.Errorf("unknown key type %q. Must be one of [any, ip, hash]", value)
+	}
+}
+
+// ImportOptions controls how a feed is turned into CDB entries.
+type ImportOptions struct {
+	// Column holding the key: a zero-based index or a header name
+	KeyColumn string
+	// Column holding the value, empty to use DefaultValue for every entry
+	ValueColumn string
+	// Value written for entries without a value column
+	DefaultValue string
+	// Whether the first CSV record is a header row
+	Header bool
+	// Validation and normalisation applied to keys
+	Kind Kind
+}
+
+// ImportCSV converts CSV records into a CDB list. Records whose key fails
+// validation for the requested kind, and duplicate keys, are reported as
+// problems and left out of the list.
+func ImportCSV(r io.Reader, opts ImportOptions) (*List, []Problem, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	// read records one at a time to report the line each starts on, which
+	// differs from the record number once comments or quoted newlines appear
+	var records [][]string
+	var lines []int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading csv: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+
+	var header []string
+	if opts.Header && len(records) > 0 {
+		header, records, lines = records[0], records[1:], lines[1:]
+	}
+
+	keyIdx, err := columnIndex(opts.KeyColumn, header)
+	if err != nil {
+		return nil, nil, err
+	}
+	valueIdx := -1
+	if opts.ValueColumn != "" {
+		if valueIdx, err = columnIndex(opts.ValueColumn, header); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	imp := newImporter(opts)
+	for i, record := range records {
+		line := lines[i]
+		if keyIdx >= len(record) {
+			imp.problems = append(imp.problems, Problem{Line: line, Reason: fmt.Sprintf("record has no column %d", keyIdx)})
+			continue
+		}
+		value := opts.DefaultValue
+		if valueIdx >= 0 && valueIdx < len(record) {
+			value = record[valueIdx]
+		}
+		imp.add(line, record[keyIdx], value)
+	}
+
+	return imp.list, imp.problems, nil
+}
+
+// ImportPlain converts a feed with one key per line, such as an IP or hash
+// block list. Blank lines and lines starting with '#' or ';' are skipped and
+// anything after the first whitespace is ignored.
+func ImportPlain(r io.Reader, opts ImportOptions) (*List, []Problem, error) {
+	imp := newImporter(opts)
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+		imp.add(line, strings.Fields(text)[0], opts.DefaultValue)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading feed: %w", err)
+	}
+
+	return imp.list, imp.problems, nil
+}
+
+type importer struct {
+	opts     ImportOptions
+	list     *List
+	problems []Problem
+	seen     map[string]int
+}
+
+func newImporter(opts ImportOptions) *importer {
+	return &importer{opts: opts, list: &List{}, seen: make(map[string]int)}
+}
+
+func (imp *importer) add(line int, rawKey, value string) {
+	key, err := NormalizeKey(strings.TrimSpace(rawKey), imp.opts.Kind)
+	if err != nil {
+		imp.problems = append(imp.problems, Problem{Line: line, Key: rawKey, Reason: err.Error()})
+		return
+	}
+	if reason := invalidKey(key); reason != "" {
+		imp.problems = append(imp.problems, Problem{Line: line, Key: rawKey, Reason: reason})
+		return
+	}
+	if first, ok := imp.seen[key]; ok {
+		imp.problems = append(imp.problems, Problem{
+			Line:   line,
+			Key:    key,
+			Reason: fmt.Sprintf("duplicate key (first defined on line %d)", first),
+		})
+		return
+	}
+	imp.seen[key] = line
+	imp.list.Entries = append(imp.list.Entries, Entry{Key: key, Value: strings.TrimSpace(value), Line: line})
+}
+
+// NormalizeKey checks a key against kind and returns it in the form the
+// manager expects. IPv4 networks on an octet boundary are converted to the
+// trailing-dot prefix notation used by address_match_key lists.
+func NormalizeKey(key string, kind Kind) (string, error) {
+	switch kind {
+	case KindIP:
+		return normalizeIP(key)
+	case KindHash:
+		return normalizeHash(key)
+	default:
+		return key, nil
+	}
+}
+
+func normalizeIP(key string) (string, error) {
+	if ip := net.ParseIP(key); ip != nil {
+		return ip.String(), nil
+	}
+
+	ip, network, err := net.ParseCIDR(key)
+	if err != nil {
+		return "", fmt.Errorf("not an IP address or CIDR network")
+	}
+	ones, bits := network.Mask.Size()
+	if ip.To4() == nil {
+		if ones == bits {
+			return ip.String(), nil
+		}
+		return "", fmt.Errorf("IPv6 networks cannot be expressed as a CDB key")
+	}
+
+	octets := strings.Split(network.IP.To4().String(), ".")
+	switch ones {
+	case 32:
+		return ip.String(), nil
+	case 8, 16, 24:
+		return strings.Join(octets[:ones/8], ".") + ".", nil
+	default:
+		return "", fmt.Errorf("/%d is not on an octet boundary and cannot be expressed as a CDB prefix", ones)
+	}
+}
+
+func normalizeHash(key string) (string, error) {
+	key = strings.ToLower(key)
+	if _, err := hex.DecodeString(key); err != nil {
+		return "", fmt.Errorf("not a hexadecimal hash")
+	}
+	switch len(key) {
+	case 32, 40, 64:
+		return key, nil
+	default:
+		return "", fmt.Errorf("hash length %d is not MD5, SHA1 or SHA256", len(key))
+	}
+}
+
+// columnIndex resolves a column given either as an index or a header name.
+func columnIndex(column string, header []string) (int, error) {
+	if column == "" {
+		return 0, nil
+	}
+	if idx, err := strconv.Atoi(column); err == nil {
+		if idx < 0 {
+			return 0, fmt.Errorf("column index %d must not be negative", idx)
+		}
+		return idx, nil
+	}
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i, nil
+		}
+	}
+	if header == nil {
+		return 0, fmt.Errorf("column %q given by name but the csv has no header row", column)
+	}
+	return 0, fmt.Errorf("column %q not found in header %v", column, header)
+}
@@ -0,0 +1,42 @@
+package printers
+
+import (
+	"os"
+)
+
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiDim    = "\033[2m"
+)
+
+// colorEnabled is decided once: colours are used when stdout is a terminal
+// and NO_COLOR is not set.
+var colorEnabled = func() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}()
+
+func colorize(code, s string) string {
+	if !colorEnabled {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// Red colours s red when writing to a terminal.
+func Red(s string) string { return colorize(ansiRed, s) }
+
+// Green colours s green when writing to a terminal.
+func Green(s string) string { return colorize(ansiGreen, s) }
+
+// Yellow colours s yellow when writing to a terminal.
+func Yellow(s string) string { return colorize(ansiYellow, s) }
+
+// Dim renders s faint when writing to a terminal.
+func Dim(s string) string { return colorize(ansiDim, s) }
@@ -0,0 +1,58 @@
+package printers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Format selects how a command renders its results.
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatJson  Format = "json"
+)
+
+// ParseFormat validates a user supplied output format.
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(value)) {
+	case FormatTable:
+		return FormatTable, nil
+	case FormatJson:
+		return FormatJson, nil
+	default:
+		return "", fmt.Errorf("unknown output format %q. Must be one of [table, json]", value)
+	}
+}
+
+// PrintTable writes rows as aligned columns underneath an upper-cased header.
+func PrintTable(headers []string, rows [][]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	upper := make([]string, len(headers))
+	for i, h := range headers {
+		upper[i] = strings.ToUpper(h)
+	}
+	fmt.Fprintln(w, strings.Join(upper, "\t"))
+
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	w.Flush()
+}
+
+// PrintJson marshals v with the same indentation as PrintJsonFormattedOrError.
+func PrintJson(v any) {
+	out, err := json.MarshalIndent(v, "", "	")
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	fmt.Println(string(out))
+}
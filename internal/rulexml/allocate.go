@@ -0,0 +1,65 @@
+package rulexml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IdRange is an inclusive range of rule ids.
+type IdRange struct {
+	Min int
+	Max int
+}
+
+// DefaultCustomRange is the range Wazuh reserves for user defined rules.
+var DefaultCustomRange = IdRange{Min: 100000, Max: 120000}
+
+func (r IdRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// ParseIdRange parses a range written as "min-max".
+func ParseIdRange(value string) (IdRange, error) {
+	lo, hi, found := strings.Cut(strings.TrimSpace(value), "-")
+	if !found {
+		return IdRange{}, fmt.Errorf("invalid range %q. Must be written as min-max", value)
+	}
+
+	min, err := strconv.Atoi(strings.TrimSpace(lo))
+	if err != nil {
+		return IdRange{}, fmt.Errorf("invalid range start %q: %w", lo, err)
+	}
+	max, err := strconv.Atoi(strings.TrimSpace(hi))
+	if err != nil {
+		return IdRange{}, fmt.Errorf("invalid range end %q: %w", hi, err)
+	}
+	if min < 0 || max < min {
+		return IdRange{}, fmt.Errorf("invalid range %q. Start must be positive and not greater than end", value)
+	}
+
+	return IdRange{Min: min, Max: max}, nil
+}
+
+// NextFreeIds returns the lowest count ids in r that are not in used.
+// Non-numeric ids in used are ignored.
+func NextFreeIds(used []string, r IdRange, count int) ([]int, error) {
+	taken := make(map[int]bool, len(used))
+	for _, id := range used {
+		if n, err := strconv.Atoi(strings.TrimSpace(id)); err == nil {
+			taken[n] = true
+		}
+	}
+
+	var free []int
+	for id := r.Min; id <= r.Max && len(free) < count; id++ {
+		if !taken[id] {
+			free = append(free, id)
+		}
+	}
+	if len(free) < count {
+		return nil, fmt.Errorf("only %d free rule id(s) left in range %s, %d requested", len(free), r, count)
+	}
+
+	return free, nil
+}
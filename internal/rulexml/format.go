@@ -0,0 +1,401 @@
+package rulexml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// attrOrder is the canonical position of well known rule and decoder
+// attributes; other attributes follow in name order.
+var attrOrder = map[string]int{
+	"id":        0,
+	"name":      1,
+	"level":     2,
+	"maxsize":   3,
+	"frequency": 4,
+	"timeframe": 5,
+	"ignore":    6,
+	"overwrite": 7,
+	"noalert":   8,
+	"offset":    9,
+	"type":      10,
+	"negate":    11,
+}
+
+const formatIndent = "  "
+
+// nodeKind is the kind of a formatter node.
+type nodeKind int
+
+const (
+	elementNode nodeKind = iota
+	textNode
+	// comments, processing instructions and CDATA, kept verbatim
+	rawNode
+)
+
+type attr struct {
+	Name  string
+	Value string
+}
+
+// node is an element, text or verbatim markup of a document. Text and
+// attribute values keep their original bytes, so regexes and entities are
+// never rewritten.
+type node struct {
+	Kind        nodeKind
+	Name        string
+	Attrs       []attr
+	SelfClosing bool
+	Children    []*node
+	Text        string
+	// blank line before the node in the original document
+	BlankBefore bool
+}
+
+// Format returns content canonically formatted: two space indentation,
+// one element per line, leaf element text kept verbatim, attributes in a
+// fixed order and runs of blank lines collapsed to one. Comments and the
+// several root elements Wazuh rule files allow are kept.
+func Format(content []byte) ([]byte, error) {
+	p := &formatParser{src: content}
+	roots, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	writeNodes(&b, roots, 0)
+	return b.Bytes(), nil
+}
+
+// rulesetRoots are the root elements of Wazuh rule and decoder files.
+var rulesetRoots = []string{"group", "rule", "decoder", "var"}
+
+// IsRuleset reports whether content is a Wazuh rule or decoder file, judged
+// by its first root element, so other XML files such as build or IDE
+// configuration can be told apart.
+func IsRuleset(content []byte) bool {
+	d := NewDecoder(bytes.NewReader(content))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return false
+		}
+		if t, ok := tok.(xml.StartElement); ok {
+			return slices.Contains(rulesetRoots, t.Name.Local)
+		}
+	}
+}
+
+// writeNodes writes sibling nodes, keeping a blank line where the original
+// had one and always separating consecutive block elements such as rules.
+func writeNodes(b *bytes.Buffer, nodes []*node, depth int) {
+	var prev *node
+	for _, n := range nodes {
+		if n.Kind == textNode && strings.TrimSpace(n.Text) == "" {
+			continue
+		}
+		if prev != nil && (n.BlankBefore || isBlock(prev) && isBlock(n)) {
+			b.WriteString("\n")
+		}
+		prev = n
+		writeNode(b, n, depth)
+	}
+}
+
+// isBlock reports whether n is written over several lines.
+func isBlock(n *node) bool {
+	return n.Kind == elementNode && !n.SelfClosing && !isLeaf(n)
+}
+
+func writeNode(b *bytes.Buffer, n *node, depth int) {
+	indent := strings.Repeat(formatIndent, depth)
+
+	switch n.Kind {
+	case textNode:
+		b.WriteString(indent + strings.TrimSpace(n.Text) + "\n")
+		return
+	case rawNode:
+		b.WriteString(indent + n.Text + "\n")
+		return
+	}
+
+	b.WriteString(indent + "<" + n.Name)
+	for _, a := range sortedAttrs(n.Attrs) {
+		quote := `"`
+		if strings.Contains(a.Value, `"`) {
+			quote = "'"
+		}
+		b.WriteString(" " + a.Name + "=" + quote + a.Value + quote)
+	}
+
+	switch {
+	case n.SelfClosing:
+		b.WriteString(" />\n")
+	case isLeaf(n):
+		b.WriteString(">")
+		for _, c := range n.Children {
+			b.WriteString(c.Text)
+		}
+		b.WriteString("</" + n.Name + ">\n")
+	default:
+		b.WriteString(">\n")
+		writeNodes(b, n.Children, depth+1)
+		b.WriteString(indent + "</" + n.Name + ">\n")
+	}
+}
+
+// isLeaf reports whether n holds only text, which is written verbatim on
+// the element's line.
+func isLeaf(n *node) bool {
+	for _, c := range n.Children {
+		if c.Kind != textNode {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedAttrs(attrs []attr) []attr {
+	sorted := append([]attr{}, attrs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, oki := attrOrder[sorted[i].Name]
+		pj, okj := attrOrder[sorted[j].Name]
+		switch {
+		case oki && okj:
+			return pi < pj
+		case oki != okj:
+			return oki
+		default:
+			return sorted[i].Name < sorted[j].Name
+		}
+	})
+	return sorted
+}
+
+// formatParser reads the XML subset used by Wazuh ruleset files. It is not
+// a validating parser: it only needs to find elements, attributes, text
+// and verbatim markup without altering their bytes.
+type formatParser struct {
+	src []byte
+	pos int
+}
+
+func (p *formatParser) errorf(format string, args ...any) error {
+	line := 1 + bytes.Count(p.src[:min(p.pos, len(p.src))], []byte("\n"))
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *formatParser) parse() ([]*node, error) {
+	roots, end, err := p.content("")
+	if err != nil {
+		return nil, err
+	}
+	if end != "" {
+		return nil, p.errorf("unexpected </%s>", end)
+	}
+	return roots, nil
+}
+
+// content parses nodes up to the closing tag of parent, or to the end of
+// the input when parent is empty, and returns the name of the closing tag
+// it stopped at.
+func (p *formatParser) content(parent string) ([]*node, string, error) {
+	var nodes []*node
+	blank := false
+
+	for p.pos < len(p.src) {
+		rest := p.src[p.pos:]
+
+		if rest[0] != '<' || !isMarkup(rest) {
+			end := p.pos + 1
+			for end < len(p.src) && !(p.src[end] == '<' && isMarkup(p.src[end:])) {
+				end++
+			}
+			text := string(p.src[p.pos:end])
+			p.pos = end
+			if strings.TrimSpace(text) == "" {
+				nodes = append(nodes, &node{Kind: textNode, Text: text})
+				blank = strings.Count(text, "\n") > 1
+				continue
+			}
+			nodes = append(nodes, &node{Kind: textNode, Text: text, BlankBefore: blank || leadingBlank(text)})
+			blank = false
+			continue
+		}
+
+		switch {
+		case bytes.HasPrefix(rest, []byte("</")):
+			end := bytes.IndexByte(rest, '>')
+			if end < 0 {
+				return nil, "", p.errorf("unterminated closing tag")
+			}
+			name := strings.TrimSpace(string(rest[2:end]))
+			if name != parent {
+				if parent == "" {
+					return nil, "", p.errorf("unexpected </%s>", name)
+				}
+				return nil, "", p.errorf("</%s> closes <%s>", name, parent)
+			}
+			p.pos += end + 1
+			return nodes, name, nil
+		case bytes.HasPrefix(rest, []byte("<!--")):
+			raw, err := p.until("-->")
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, &node{Kind: rawNode, Text: raw, BlankBefore: blank})
+		case bytes.HasPrefix(rest, []byte("<![CDATA[")):
+			raw, err := p.until("]]>")
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, &node{Kind: textNode, Text: raw, BlankBefore: blank})
+		case bytes.HasPrefix(rest, []byte("<?")):
+			raw, err := p.until("?>")
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, &node{Kind: rawNode, Text: raw, BlankBefore: blank})
+		case bytes.HasPrefix(rest, []byte("<!")):
+			raw, err := p.until(">")
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, &node{Kind: rawNode, Text: raw, BlankBefore: blank})
+		default:
+			n, err := p.element()
+			if err != nil {
+				return nil, "", err
+			}
+			n.BlankBefore = blank
+			nodes = append(nodes, n)
+		}
+		blank = false
+	}
+
+	if parent != "" {
+		return nil, "", p.errorf("<%s> is never closed", parent)
+	}
+	return nodes, "", nil
+}
+
+// leadingBlank reports whether text starts with a blank line.
+func leadingBlank(text string) bool {
+	trimmed := strings.TrimLeft(text, " \t\r\n")
+	return strings.Count(text[:len(text)-len(trimmed)], "\n") > 1
+}
+
+// isMarkup reports whether the '<' at the start of b opens a tag, comment
+// or declaration rather than being a literal character of a regex.
+func isMarkup(b []byte) bool {
+	if len(b) < 2 {
+		return false
+	}
+	c := b[1]
+	return c == '/' || c == '!' || c == '?' || c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// until consumes and returns the input up to and including end.
+func (p *formatParser) until(end string) (string, error) {
+	i := bytes.Index(p.src[p.pos:], []byte(end))
+	if i < 0 {
+		return "", p.errorf("missing %q", end)
+	}
+	raw := string(p.src[p.pos : p.pos+i+len(end)])
+	p.pos += i + len(end)
+	return raw, nil
+}
+
+// element parses a start tag and, unless it is self-closing, the content
+// and closing tag of the element.
+func (p *formatParser) element() (*node, error) {
+	p.pos++ // '<'
+	n := &node{Kind: elementNode, Name: p.name()}
+	if n.Name == "" {
+		return nil, p.errorf("missing element name")
+	}
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated <%s>", n.Name)
+		}
+		switch {
+		case p.src[p.pos] == '>':
+			p.pos++
+			children, _, err := p.content(n.Name)
+			if err != nil {
+				return nil, err
+			}
+			n.Children = children
+			return n, nil
+		case bytes.HasPrefix(p.src[p.pos:], []byte("/>")):
+			p.pos += 2
+			n.SelfClosing = true
+			return n, nil
+		}
+
+		a := attr{Name: p.name()}
+		if a.Name == "" {
+			return nil, p.errorf("unexpected %q in <%s>", p.src[p.pos], n.Name)
+		}
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == '=' {
+			p.pos++
+			p.skipSpace()
+			value, err := p.attrValue()
+			if err != nil {
+				return nil, err
+			}
+			a.Value = value
+		}
+		n.Attrs = append(n.Attrs, a)
+	}
+}
+
+func (p *formatParser) name() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '>' || c == '/' || c == '=' {
+			break
+		}
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+func (p *formatParser) attrValue() (string, error) {
+	if p.pos >= len(p.src) {
+		return "", p.errorf("missing attribute value")
+	}
+	quote := p.src[p.pos]
+	if quote != '"' && quote != '\'' {
+		// unquoted values, which the lenient decoder also accepts
+		start := p.pos
+		for p.pos < len(p.src) && !strings.ContainsRune(" \t\r\n>", rune(p.src[p.pos])) && !bytes.HasPrefix(p.src[p.pos:], []byte("/>")) {
+			p.pos++
+		}
+		return string(p.src[start:p.pos]), nil
+	}
+
+	end := bytes.IndexByte(p.src[p.pos+1:], quote)
+	if end < 0 {
+		return "", p.errorf("unterminated attribute value")
+	}
+	value := string(p.src[p.pos+1 : p.pos+1+end])
+	p.pos += end + 2
+	return value, nil
+}
+
+func (p *formatParser) skipSpace() {
+	for p.pos < len(p.src) && strings.ContainsRune(" \t\r\n", rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
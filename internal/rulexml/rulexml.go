@@ -0,0 +1,243 @@
+package rulexml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EpykLab/wazctl/internal/files"
+)
+
+// Pattern is a match or regex condition of a rule.
+type Pattern struct {
+	Value  string `xml:",chardata" json:"value"`
+	Type   string `xml:"type,attr" json:"type,omitempty"`
+	Negate string `xml:"negate,attr" json:"negate,omitempty"`
+}
+
+// Field is a <field name=""> condition on a decoded field.
+type Field struct {
+	Name   string `xml:"name,attr" json:"name"`
+	Value  string `xml:",chardata" json:"value"`
+	Type   string `xml:"type,attr" json:"type,omitempty"`
+	Negate string `xml:"negate,attr" json:"negate,omitempty"`
+}
+
+// Rule is the subset of a Wazuh rule definition wazctl reads.
+type Rule struct {
+	Id             string    `xml:"id,attr" json:"id"`
+	Level          string    `xml:"level,attr" json:"level"`
+	Frequency      string    `xml:"frequency,attr" json:"frequency,omitempty"`
+	Timeframe      string    `xml:"timeframe,attr" json:"timeframe,omitempty"`
+	IfSid          []string  `xml:"if_sid" json:"if_sid,omitempty"`
+	IfGroup        []string  `xml:"if_group" json:"if_group,omitempty"`
+	IfMatchedSid   []string  `xml:"if_matched_sid" json:"if_matched_sid,omitempty"`
+	IfMatchedGroup []string  `xml:"if_matched_group" json:"if_matched_group,omitempty"`
+	DecodedAs      []string  `xml:"decoded_as" json:"decoded_as,omitempty"`
+	Program        []Pattern `xml:"program_name" json:"program_name,omitempty"`
+	Match          []Pattern `xml:"match" json:"match,omitempty"`
+	Regex          []Pattern `xml:"regex" json:"regex,omitempty"`
+	Fields         []Field   `xml:"field" json:"field,omitempty"`
+	Description    string    `xml:"description" json:"description"`
+	Groups         []string  `xml:"group" json:"group,omitempty"`
+	Mitre          []string  `xml:"mitre>id" json:"mitre,omitempty"`
+
+	// Groups from the enclosing <group name=""> element
+	ParentGroups []string `xml:"-" json:"parent_groups,omitempty"`
+	// File the rule was read from, empty when parsed from memory
+	File string `xml:"-" json:"file,omitempty"`
+	// Original XML of the rule element
+	Raw string `xml:"-" json:"-"`
+}
+
+// IdInt returns the numeric rule id.
+func (r *Rule) IdInt() (int, error) {
+	return strconv.Atoi(strings.TrimSpace(r.Id))
+}
+
+// ParentIds returns the ids listed in if_sid and if_matched_sid, which may
+// be comma or space separated.
+func (r *Rule) ParentIds() []string {
+	return splitList(append(append([]string{}, r.IfSid...), r.IfMatchedSid...))
+}
+
+// AllGroups returns the groups of the rule including inherited ones.
+func (r *Rule) AllGroups() []string {
+	return splitList(append(append([]string{}, r.ParentGroups...), r.Groups...))
+}
+
+// splitList flattens comma and whitespace separated values.
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n'
+		}) {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// NewDecoder returns an XML decoder lenient enough for Wazuh ruleset files,
+// which allow several root elements and unescaped characters in regexes.
+func NewDecoder(r io.Reader) *xml.Decoder {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+	return d
+}
+
+// Parse returns every <rule> in content, in document order.
+func Parse(content []byte) ([]Rule, error) {
+	d := NewDecoder(bytes.NewReader(content))
+
+	var rules []Rule
+	var parentGroups []string
+	for {
+		offset := d.InputOffset()
+		tok, err := d.Token()
+		if err == io.EOF {
+			return rules, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "group":
+				parentGroups = nil
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "name" {
+						parentGroups = []string{attr.Value}
+					}
+				}
+			case "rule":
+				var rule Rule
+				if err := d.DecodeElement(&rule, &t); err != nil {
+					return nil, fmt.Errorf("decoding rule at offset %d: %w", offset, err)
+				}
+				rule.ParentGroups = parentGroups
+				rule.Raw = dedent(string(content[offset:d.InputOffset()]), lineIndent(content, offset))
+				rules = append(rules, rule)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "group" {
+				parentGroups = nil
+			}
+		}
+	}
+}
+
+// lineIndent returns the whitespace preceding offset on its line.
+func lineIndent(content []byte, offset int64) string {
+	start := bytes.LastIndexByte(content[:offset], '\n') + 1
+	prefix := content[start:offset]
+	if len(bytes.TrimSpace(prefix)) != 0 {
+		return ""
+	}
+	return string(prefix)
+}
+
+// dedent removes indent from every line after the first so an element cut
+// out of a nested document keeps its relative indentation.
+func dedent(raw, indent string) string {
+	if indent == "" {
+		return raw
+	}
+	lines := strings.Split(raw, "\n")
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.TrimPrefix(lines[i], indent)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseFile parses the rules in a single file.
+func ParseFile(path string) ([]Rule, error) {
+	content, err := files.ReadFileFromSpecifiedPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range rules {
+		rules[i].File = path
+	}
+
+	return rules, nil
+}
+
+// ParseDir parses every .xml file below dir, skipping hidden directories.
+// Files that are not valid rule XML, such as configuration or build files in
+// the same tree, are skipped and their errors returned in skipped.
+func ParseDir(dir string) (rules []Rule, skipped []error, err error) {
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".xml") {
+			return nil
+		}
+
+		found, err := ParseFile(path)
+		if err != nil {
+			skipped = append(skipped, err)
+			return nil
+		}
+		rules = append(rules, found...)
+		return nil
+	})
+
+	return rules, skipped, err
+}
+
+// Ids returns the sorted, de-duplicated ids of rules.
+func Ids(rules []Rule) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, r := range rules {
+		id := strings.TrimSpace(r.Id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	SortIds(ids)
+	return ids
+}
+
+// SortIds sorts rule ids numerically, placing non-numeric ids last.
+func SortIds(ids []string) {
+	sort.SliceStable(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		switch {
+		case errA == nil && errB == nil:
+			return a < b
+		case errA == nil:
+			return true
+		case errB == nil:
+			return false
+		default:
+			return ids[i] < ids[j]
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package rulexml
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sample = `<!-- Local rules -->
+<group name="local,syslog,">
+  <rule id="100010" level="5">
+    <if_sid>5716, 5710</if_sid>
+    <match>Failed password</match>
+    <description>SSH failure from $(srcip)</description>
+    <mitre>
+      <id>T1110</id>
+    </mitre>
+  </rule>
+</group>
+
+<group name="web,">
+  <rule id="100002" level="0">
+    <if_group>web</if_group>
+    <field name="url" type="pcre2">^/health</field>
+    <regex>a & b</regex>
+    <description>Ignore health checks</description>
+    <group>noise,</group>
+  </rule>
+</group>
+`
+
+func TestParse(t *testing.T) {
+	rules, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("Parse() returned %d rules, want 2", len(rules))
+	}
+
+	first := rules[0]
+	if got, want := first.ParentIds(), []string{"5716", "5710"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParentIds() = %v, want %v", got, want)
+	}
+	if got, want := first.Mitre, []string{"T1110"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Mitre = %v, want %v", got, want)
+	}
+	if !strings.HasPrefix(first.Raw, `<rule id="100010"`) || !strings.HasSuffix(first.Raw, "\n</rule>") {
+		t.Errorf("Raw = %q, want dedented rule element", first.Raw)
+	}
+
+	second := rules[1]
+	if got, want := second.AllGroups(), []string{"web", "noise"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("AllGroups() = %v, want %v", got, want)
+	}
+	if len(second.Fields) != 1 || second.Fields[0].Name != "url" || second.Fields[0].Type != "pcre2" {
+		t.Errorf("Fields = %+v", second.Fields)
+	}
+	if len(second.Regex) != 1 || second.Regex[0].Value != "a & b" {
+		t.Errorf("Regex = %+v", second.Regex)
+	}
+
+	if got, want := Ids(rules), []string{"100002", "100010"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Ids() = %v, want %v", got, want)
+	}
+}
+
+func TestParseDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("local_rules.xml", sample)
+	write("broken.xml", `<rule id="100020" level="3"><description>cut off`)
+	write("pom.xml", `<project><version>1.0</version></project>`)
+	write(".git/rules.xml", `<rule id="100030" level="3"></rule>`)
+
+	rules, skipped, err := ParseDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := Ids(rules), []string{"100002", "100010"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Ids() = %v, want %v", got, want)
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0].Error(), "broken.xml") {
+		t.Errorf("skipped = %v, want only broken.xml", skipped)
+	}
+}
+
+func TestSortIds(t *testing.T) {
+	ids := []string{"100", "rule_001", "20", "3"}
+	SortIds(ids)
+	if want := []string{"3", "20", "100", "rule_001"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("SortIds() = %v, want %v", ids, want)
+	}
+}
+
+func TestNextFreeIds(t *testing.T) {
+	tests := []struct {
+		name    string
+		used    []string
+		r       IdRange
+		count   int
+		want    []int
+		wantErr bool
+	}{
+		{name: "skips used ids", used: []string{"100000", "100002", "rule_001"}, r: IdRange{100000, 100010}, count: 3, want: []int{100001, 100003, 100004}},
+		{name: "range exhausted", used: []string{"5", "6"}, r: IdRange{5, 7}, count: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NextFreeIds(tt.used, tt.r, tt.count)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NextFreeIds() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NextFreeIds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIdRange(t *testing.T) {
+	if got, err := ParseIdRange("100000-109999"); err != nil || got != (IdRange{100000, 109999}) {
+		t.Errorf("ParseIdRange() = %v, %v", got, err)
+	}
+	for _, bad := range []string{"100000", "b-1", "10-5"} {
+		if _, err := ParseIdRange(bad); err == nil {
+			t.Errorf("ParseIdRange(%q) expected error", bad)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	messy := `<!-- Local rules -->
+
+
+<group name="local,syslog,">
+  <rule level="5"   id="100010">
+        <if_sid>5716, 5710</if_sid>
+<match>Failed password</match>
+    <description>SSH failure from $(srcip)</description>
+    <mitre><id>T1110</id></mitre>
+  </rule>
+  <rule id="100011" frequency='4' level="10" timeframe="60"><if_matched_sid>100010</if_matched_sid><same_source_ip/>
+    <description>SSH brute force</description>
+  </rule>
+</group>
+<group name="web,">
+  <rule id="100002" level="0">
+    <if_group>web</if_group>
+    <field type="pcre2" name="url">^/health</field>
+    <regex>a & b <\S+></regex>
+    <description>Ignore health checks</description>
+    <group>noise,</group>
+  </rule>
+</group>
+`
+	want := `<!-- Local rules -->
+
+<group name="local,syslog,">
+  <rule id="100010" level="5">
+    <if_sid>5716, 5710</if_sid>
+    <match>Failed password</match>
+    <description>SSH failure from $(srcip)</description>
+    <mitre>
+      <id>T1110</id>
+    </mitre>
+  </rule>
+
+  <rule id="100011" level="10" frequency="4" timeframe="60">
+    <if_matched_sid>100010</if_matched_sid>
+    <same_source_ip />
+    <description>SSH brute force</description>
+  </rule>
+</group>
+
+<group name="web,">
+  <rule id="100002" level="0">
+    <if_group>web</if_group>
+    <field name="url" type="pcre2">^/health</field>
+    <regex>a & b <\S+></regex>
+    <description>Ignore health checks</description>
+    <group>noise,</group>
+  </rule>
+</group>
+`
+
+	got, err := Format([]byte(messy))
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("Format() =\n%s\nwant\n%s", got, want)
+	}
+
+	again, _ := Format(got)
+	if string(again) != string(got) {
+		t.Errorf("Format() is not idempotent:\n%s", again)
+	}
+
+	for _, bad := range []string{"<group><rule></group>", "<rule id=\"1\">", "</rule>", "<!-- open"} {
+		if _, err := Format([]byte(bad)); err == nil {
+			t.Errorf("Format(%q) accepted a malformed document", bad)
+		}
+	}
+}
+
+func TestIsRuleset(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{name: "rules", content: sample, want: true},
+		{name: "decoders", content: `<decoder name="sshd"><program_name>sshd</program_name></decoder>`, want: true},
+		{name: "vars first", content: `<?xml version="1.0"?><var name="BAD_WORDS">failure</var><group name="x"></group>`, want: true},
+		{name: "build file", content: `<?xml version="1.0"?><project><version>1.0</version></project>`, want: false},
+		{name: "not xml", content: `{"rule": 1}`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRuleset([]byte(tt.content)); got != tt.want {
+				t.Errorf("IsRuleset() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package rules
+
+import (
+	"fmt"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+)
+
+const exampleDecoder = `<decoder name="%[1]s">
+  <program_name>^%[1]s$</program_name>
+</decoder>
+
+<decoder name="%[1]s-fields">
+  <parent>%[1]s</parent>
+  <regex type="pcre2">user=(\S+) from (\S+)</regex>
+  <order>srcuser, srcip</order>
+</decoder>`
+
+const exampleChildDecoder = `<!-- Events decoded by a child decoder report the name of its parent, %[2]s -->
+<decoder name="%[1]s">
+  <parent>%[2]s</parent>
+  <regex type="pcre2">user=(\S+) from (\S+)</regex>
+  <order>srcuser, srcip</order>
+</decoder>`
+
+// ExampleDecoder returns a decoder skeleton named name. Without a parent it
+// is a parent decoder matching the program name with a child extracting
+// srcuser and srcip; with one it is a child of parent extracting them.
+func ExampleDecoder(name, parent string) string {
+	if parent == "" {
+		return fmt.Sprintf(exampleDecoder, name)
+	}
+	return fmt.Sprintf(exampleChildDecoder, name, parent)
+}
+
+// DecoderTest returns the decoder test written by init decoder, asserting
+// the decoder name and the fields extracted by ExampleDecoder.
+func DecoderTest(name, parent, author string) v1.SchemaJson {
+	// Wazuh reports the parent's name for events matched by a child decoder
+	reported := name
+	event := fmt.Sprintf("Oct 19 10:00:00 host %s[1234]: user=alice from 10.0.0.5", name)
+	if parent != "" {
+		reported = parent
+		event = fmt.Sprintf("REPLACE with a sample %s log containing user=alice from 10.0.0.5", parent)
+	}
+
+	return v1.SchemaJson{
+		Decoder:        reported,
+		RuleAuthor:     author,
+		DecoderContent: ExampleDecoder(name, parent),
+		Description:    fmt.Sprintf("Tests the fields extracted by the %s decoder", name),
+		Edges: []v1.SchemaJsonEdgesElem{
+			{
+				Title:       "Extracts user and source address",
+				Description: "Sample log decoded into srcuser and srcip",
+				Log:         &v1.SchemaJsonEdgesElemLog{Event: event, LogFormat: "syslog"},
+				Decoder: &v1.SchemaJsonEdgesElemDecoder{
+					Parent: reported,
+					Fields: map[string]string{"srcuser": "alice", "srcip": "10.0.0.5"},
+				},
+				ExpectedOutcome: fmt.Sprintf("Decoded by %s with srcuser and srcip extracted", reported),
+			},
+		},
+	}
+}
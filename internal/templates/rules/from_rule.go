@@ -0,0 +1,122 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/EpykLab/wazctl/internal/rulexml"
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+)
+
+// RuleTestFromRule builds a rule test scaffold for an existing rule, with one
+// edge per match, regex and field condition. Each edge carries a placeholder
+// sample log describing what the real log line has to contain.
+func RuleTestFromRule(rule rulexml.Rule, author string) v1.SchemaJson {
+	description := strings.TrimSpace(rule.Description)
+	if description == "" {
+		description = fmt.Sprintf("Rule %s", rule.Id)
+	}
+
+	data := v1.SchemaJson{
+		RuleId:      rule.Id,
+		RuleName:    description,
+		RuleAuthor:  author,
+		RuleContent: rule.Raw,
+		Description: fmt.Sprintf("Tests rule %s (level %s): %s", rule.Id, rule.Level, description),
+		Edges:       EdgesFromRule(rule),
+	}
+
+	return data
+}
+
+// EdgesFromRule returns placeholder edges for the conditions of rule.
+func EdgesFromRule(rule rulexml.Rule) []v1.SchemaJsonEdgesElem {
+	var edges []v1.SchemaJsonEdgesElem
+	outcome := fmt.Sprintf("Rule %s fires", rule.Id)
+
+	for _, m := range rule.Match {
+		verb := verbs(m.Negate, "containing", "not containing")
+		edges = append(edges, placeholderEdge(
+			fmt.Sprintf("%s %q", verbs(m.Negate, "Matches", "Does not match"), strings.TrimSpace(m.Value)),
+			fmt.Sprintf("Log %s the match pattern %q", verb, strings.TrimSpace(m.Value)),
+			fmt.Sprintf("REPLACE with a sample log %s %s", verb, strings.TrimSpace(m.Value)),
+			outcome))
+	}
+	for _, r := range rule.Regex {
+		verb := verbs(r.Negate, "matching", "not matching")
+		edges = append(edges, placeholderEdge(
+			fmt.Sprintf("%s regex %q", verbs(r.Negate, "Matches", "Does not match"), strings.TrimSpace(r.Value)),
+			fmt.Sprintf("Log %s the regex %q", verb, strings.TrimSpace(r.Value)),
+			fmt.Sprintf("REPLACE with a sample log %s %s", verb, strings.TrimSpace(r.Value)),
+			outcome))
+	}
+	for _, f := range rule.Fields {
+		verb := verbs(f.Negate, "matches", "does not match")
+		edges = append(edges, placeholderEdge(
+			fmt.Sprintf("Field %s %s %q", f.Name, verb, strings.TrimSpace(f.Value)),
+			fmt.Sprintf("Log whose decoded field %s %s %q", f.Name, verb, strings.TrimSpace(f.Value)),
+			fmt.Sprintf("REPLACE with a sample log where %s %s %s", f.Name, verb, strings.TrimSpace(f.Value)),
+			outcome))
+	}
+
+	if frequency, err := strconv.Atoi(rule.Frequency); err == nil && frequency > 1 {
+		edges = append(edges, sequenceEdge(rule, frequency, outcome))
+	}
+
+	if len(edges) == 0 {
+		edges = append(edges, placeholderEdge(
+			"Rule fires",
+			"Log that satisfies the parent rules of this rule",
+			"REPLACE with a sample log that triggers this rule",
+			outcome))
+	}
+
+	return edges
+}
+
+// verbs picks the wording for a condition with the given negate attribute.
+func verbs(negate, positive, negative string) string {
+	if strings.EqualFold(negate, "yes") {
+		return negative
+	}
+	return positive
+}
+
+func placeholderEdge(title, description, event, outcome string) v1.SchemaJsonEdgesElem {
+	return v1.SchemaJsonEdgesElem{
+		Title:           title,
+		Description:     description,
+		Log:             &v1.SchemaJsonEdgesElemLog{Event: event, LogFormat: "syslog"},
+		ExpectedOutcome: outcome,
+	}
+}
+
+// sequenceEdge scaffolds a frequency rule as frequency events sent through
+// one logtest session, the last of which triggers the rule.
+func sequenceEdge(rule rulexml.Rule, frequency int, outcome string) v1.SchemaJsonEdgesElem {
+	matched := strings.Join(append(append([]string{}, rule.IfMatchedSid...), rule.IfMatchedGroup...), ", ")
+	if matched == "" {
+		matched = "the matched rule"
+	}
+
+	events := make([]v1.SchemaJsonEdgesElemEventsElem, frequency)
+	for i := range events {
+		events[i] = v1.SchemaJsonEdgesElemEventsElem{
+			Event:     fmt.Sprintf("REPLACE with sample log %d of %d that triggers %s", i+1, frequency, matched),
+			LogFormat: "syslog",
+		}
+	}
+
+	description := fmt.Sprintf("%d events matching %s", frequency, matched)
+	if rule.Timeframe != "" {
+		description += fmt.Sprintf(" within %ss", rule.Timeframe)
+	}
+
+	return v1.SchemaJsonEdgesElem{
+		Title:           fmt.Sprintf("Fires after %d events", frequency),
+		Description:     description,
+		Events:          events,
+		ExpectedOutcome: outcome + " on the last event",
+	}
+}
@@ -2,41 +2,33 @@ package rules
 
 import (
 	"bytes"
-	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"fmt"
+	"strings"
 	"text/template"
-)
 
-const exampleRule = `<rule id="100234" level="3">
-    <if_sid>230</if_sid>
-    <field name="alert_type">normal</field>
-    <description>The file limit set for this agent is $(file_limit). Now, $(file_count) files are being monitored.</description>
-   <group>syscheck,fim_db_state,</group>
- </rule>`
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"gopkg.in/yaml.v3"
+)
 
-func ScaffoldFromTempl() bytes.Buffer {
+const exampleRule = `<rule id="%s" level="3">
+  <if_sid>230</if_sid>
+  <field name="alert_type">normal</field>
+  <description>The file limit set for this agent is $(file_limit). Now, $(file_count) files are being monitored.</description>
+  <group>syscheck,fim_db_state,</group>
+</rule>`
 
-	tmpl := `ruleId: {{.RuleId}}
-ruleName: {{.RuleName}}
-ruleAuthor: {{.RuleAuthor}}
-ruleContent: |-
- {{.RuleContent}}
-description: {{.Description}}
-edges:
-{{- range .Edges}}
-  - title: {{.Title}}
-    description: {{.Description}}
-    command:
-      type: {{.Command.Type}}
-      value: |-
-       {{.Command.Value}}
-    expected_outcome: {{.ExpectedOutcome}}
-{{- end}}`
+// ExampleRule returns the example rule XML using the given rule id.
+func ExampleRule(ruleId string) string {
+	return fmt.Sprintf(exampleRule, ruleId)
+}
 
-	data := v1.SchemaJson{
+// ExampleRuleTest returns the example rule test written by init rule.
+func ExampleRuleTest() v1.SchemaJson {
+	return v1.SchemaJson{
 		RuleId:      "rule_001",
 		RuleName:    "Unauthorized Access",
 		RuleAuthor:  "John Doe",
-		RuleContent: exampleRule,
+		RuleContent: ExampleRule("100234"),
 		Description: "Tests unauthorized access attempts",
 		Edges: []v1.SchemaJsonEdgesElem{
 			{
@@ -47,10 +39,125 @@ edges:
 			},
 		},
 	}
+}
+
+// templateFuncs keeps generated values valid YAML whatever they contain.
+var templateFuncs = template.FuncMap{
+	// yaml renders value unchanged as a single line scalar, quoting it when
+	// needed; values spanning lines are double quoted with escaped breaks
+	"yaml": func(value string) string {
+		node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+		if strings.Contains(value, "\n") {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+		out, err := yaml.Marshal(node)
+		if err != nil {
+			return fmt.Sprintf("%q", value)
+		}
+		return strings.TrimSuffix(string(out), "\n")
+	},
+	// indent prefixes every line of a block scalar
+	"indent": func(spaces int, value string) string {
+		pad := strings.Repeat(" ", spaces)
+		lines := strings.Split(strings.TrimRight(value, "\n"), "\n")
+		for i, line := range lines {
+			if strings.TrimSpace(line) != "" {
+				lines[i] = pad + line
+			}
+		}
+		return strings.Join(lines, "\n")
+	},
+}
+
+func ScaffoldFromTempl(data v1.SchemaJson) bytes.Buffer {
+
+	tmpl := `{{- if .RuleId}}ruleId: {{yaml .RuleId}}
+ruleName: {{yaml .RuleName}}
+{{end}}
+{{- if .Decoder}}decoder: {{yaml .Decoder}}
+{{end -}}
+ruleAuthor: {{yaml .RuleAuthor}}
+{{- if .RuleContent}}
+ruleContent: |-
+{{indent 2 .RuleContent}}
+{{- end}}
+{{- if .DecoderContent}}
+decoderContent: |-
+{{indent 2 .DecoderContent}}
+{{- end}}
+description: {{yaml .Description}}
+edges:
+{{- range .Edges}}
+  - title: {{yaml .Title}}
+    description: {{yaml .Description}}
+{{- if .Command.Value}}
+    command:
+      type: {{.Command.Type}}
+      value: |-
+{{indent 8 .Command.Value}}
+{{- end}}
+{{- with .Log}}
+    log:
+      event: {{yaml .Event}}
+{{- if .Location}}
+      location: {{yaml .Location}}
+{{- end}}
+{{- if .LogFormat}}
+      log_format: {{yaml .LogFormat}}
+{{- end}}
+{{- end}}
+{{- if .Events}}
+    events:
+{{- range .Events}}
+      - event: {{yaml .Event}}
+{{- if .Location}}
+        location: {{yaml .Location}}
+{{- end}}
+{{- if .LogFormat}}
+        log_format: {{yaml .LogFormat}}
+{{- end}}
+{{- if .Delay}}
+        delay: {{yaml .Delay}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- with .TriggerEvent}}
+    trigger_event: {{.}}
+{{- end}}
+{{- if .RuleId}}
+    rule_id: {{yaml .RuleId}}
+{{- end}}
+{{- if .Expect}}
+    expect: {{.Expect}}
+{{- end}}
+{{- with .MaxLevel}}
+    max_level: {{.}}
+{{- end}}
+{{- with .Level}}
+    level: {{.}}
+{{- end}}
+{{- with .Decoder}}
+    decoder:
+{{- if .Name}}
+      name: {{yaml .Name}}
+{{- end}}
+{{- if .Parent}}
+      parent: {{yaml .Parent}}
+{{- end}}
+{{- if .Fields}}
+      fields:
+{{- range $name, $value := .Fields}}
+        {{yaml $name}}: {{yaml $value}}
+{{- end}}
+{{- end}}
+{{- end}}
+    expected_outcome: {{yaml .ExpectedOutcome}}
+{{- end}}
+`
 
 	var buf bytes.Buffer
 
-	t := template.Must(template.New("ruleTest").Parse(tmpl))
+	t := template.Must(template.New("ruleTest").Funcs(templateFuncs).Parse(tmpl))
 	t.Execute(&buf, data)
 
 	return buf
@@ -0,0 +1,154 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/EpykLab/wazctl/internal/rulexml"
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"gopkg.in/yaml.v3"
+)
+
+func TestScaffoldFromRule(t *testing.T) {
+	parsed, err := rulexml.Parse([]byte(`<group name="sshd,">
+  <rule id="100010" level="10">
+    <if_sid>5716</if_sid>
+    <match>Failed password</match>
+    <field name="srcuser">^root$</field>
+    <description>sshd: root login failure: $(srcip)</description>
+  </rule>
+</group>`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	data := RuleTestFromRule(parsed[0], "Jane Roe")
+	buf := ScaffoldFromTempl(data)
+
+	var got v1.SchemaJson
+	if err := yaml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("scaffold is not valid YAML: %v\n%s", err, buf.String())
+	}
+
+	if got.RuleId != "100010" || got.RuleAuthor != "Jane Roe" || got.RuleName != "sshd: root login failure: $(srcip)" {
+		t.Errorf("scaffold header = %+v", got)
+	}
+	if got.RuleContent != parsed[0].Raw {
+		t.Errorf("ruleContent = %q, want %q", got.RuleContent, parsed[0].Raw)
+	}
+	if len(got.Edges) != 2 {
+		t.Fatalf("scaffold has %d edges, want 2", len(got.Edges))
+	}
+	for _, edge := range got.Edges {
+		if edge.Log == nil || edge.Log.Event == "" {
+			t.Errorf("edge %q has no placeholder log", edge.Title)
+		}
+	}
+}
+
+func TestScaffoldExample(t *testing.T) {
+	buf := ScaffoldFromTempl(ExampleRuleTest())
+
+	var got v1.SchemaJson
+	if err := yaml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("scaffold is not valid YAML: %v\n%s", err, buf.String())
+	}
+	if got.Edges[0].Command.Value != "ssh invalid@server" {
+		t.Errorf("command = %q", got.Edges[0].Command.Value)
+	}
+}
+
+func TestScaffoldFrequencyRule(t *testing.T) {
+	parsed, err := rulexml.Parse([]byte(`<group name="sshd,">
+  <rule id="100011" level="10" frequency="4" timeframe="120">
+    <if_matched_sid>100010</if_matched_sid>
+    <same_source_ip />
+    <description>sshd: repeated root login failures</description>
+  </rule>
+</group>`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	buf := ScaffoldFromTempl(RuleTestFromRule(parsed[0], "Jane Roe"))
+
+	var got v1.SchemaJson
+	if err := yaml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("scaffold is not valid YAML: %v\n%s", err, buf.String())
+	}
+	if len(got.Edges) != 1 || len(got.Edges[0].Events) != 4 {
+		t.Fatalf("scaffold edges = %+v, want one edge with 4 events", got.Edges)
+	}
+	if got.Edges[0].Log != nil {
+		t.Errorf("sequence edge also has a log")
+	}
+}
+
+func TestEdgesFromRuleNegated(t *testing.T) {
+	parsed, err := rulexml.Parse([]byte(`<rule id="100012" level="5">
+  <field name="srcuser" negate="yes">^backup$</field>
+  <description>sshd: login by anyone but backup</description>
+</rule>`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	edges := EdgesFromRule(parsed[0])
+	if len(edges) != 1 || edges[0].Title != `Field srcuser does not match "^backup$"` {
+		t.Errorf("EdgesFromRule() = %+v", edges)
+	}
+}
+
+func TestScaffoldDecoderTest(t *testing.T) {
+	for _, parent := range []string{"", "sshd"} {
+		buf := ScaffoldFromTempl(DecoderTest("myapp", parent, "Jane Roe"))
+
+		var got v1.SchemaJson
+		if err := yaml.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("scaffold is not valid YAML: %v\n%s", err, buf.String())
+		}
+
+		want := "myapp"
+		if parent != "" {
+			want = parent
+		}
+		if got.RuleId != "" || got.Decoder != want {
+			t.Errorf("parent %q: header = ruleId %q, decoder %q, want decoder %q", parent, got.RuleId, got.Decoder, want)
+		}
+		if got.DecoderContent != ExampleDecoder("myapp", parent) {
+			t.Errorf("parent %q: decoderContent = %q", parent, got.DecoderContent)
+		}
+		decoder := got.Edges[0].Decoder
+		if decoder == nil || decoder.Name != "" || decoder.Fields["srcip"] != "10.0.0.5" || decoder.Fields["srcuser"] != "alice" {
+			t.Errorf("parent %q: edge decoder = %+v", parent, decoder)
+		}
+	}
+}
+
+func TestScaffoldKeepsEventsVerbatim(t *testing.T) {
+	events := []string{
+		"Oct  9 10:00:00 host sshd[1]: Failed password for root",
+		"host\tapp:  two  spaces",
+		"first line\n  second line",
+		"  {\"user\": \"a: b\"}  ",
+	}
+	data := v1.SchemaJson{RuleId: "100010", RuleName: "x", RuleAuthor: "Jane Roe"}
+	for _, event := range events {
+		data.Edges = append(data.Edges, v1.SchemaJsonEdgesElem{
+			Title:           "edge",
+			Description:     "d",
+			ExpectedOutcome: "o",
+			Log:             &v1.SchemaJsonEdgesElemLog{Event: event},
+		})
+	}
+	buf := ScaffoldFromTempl(data)
+
+	var got v1.SchemaJson
+	if err := yaml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("scaffold is not valid YAML: %v\n%s", err, buf.String())
+	}
+	for i, event := range events {
+		if got.Edges[i].Log == nil || got.Edges[i].Log.Event != event {
+			t.Errorf("event %d = %+v, want %q", i+1, got.Edges[i].Log, event)
+		}
+	}
+}
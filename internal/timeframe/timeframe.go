@@ -0,0 +1,61 @@
+package timeframe
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration extends time.ParseDuration with day (d) and week (w) units,
+// e.g. "7d" or "1w2d".
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+
+	var total time.Duration
+	rest := value
+	for rest != "" {
+		i := strings.IndexAny(rest, "dw")
+		if i < 0 {
+			break
+		}
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil {
+			// Not a leading day or week component, let time.ParseDuration decide
+			break
+		}
+		unit := 24 * time.Hour
+		if rest[i] == 'w' {
+			unit *= 7
+		}
+		total += time.Duration(n) * unit
+		rest = rest[i+1:]
+	}
+
+	if rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: use units such as s, m, h, d or w", value)
+		}
+		total += d
+	}
+
+	return total, nil
+}
+
+// ParseTime resolves a point in time given either as a duration before now
+// ("24h", "7d") or as an RFC3339 timestamp or date.
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return t, nil
+	}
+
+	d, err := ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use a duration like 24h or 7d, or an RFC3339 timestamp", value)
+	}
+	return now.Add(-d), nil
+}
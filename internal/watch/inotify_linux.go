@@ -0,0 +1,139 @@
+//go:build linux
+
+package watch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+type inotifyWatcher struct {
+	file   *os.File
+	fd     int
+	events chan string
+	errors chan error
+	done   chan struct{}
+	closer sync.Once
+
+	mu    sync.Mutex
+	paths map[int]string
+}
+
+func newWatcher(roots []string) (Watcher, error) {
+	watched, err := dirs(roots)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify: %w", err)
+	}
+
+	w := &inotifyWatcher{
+		// A non-blocking descriptor is driven by the runtime poller, so
+		// closing the file unblocks a pending read
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		fd:     fd,
+		events: make(chan string),
+		errors: make(chan error),
+		done:   make(chan struct{}),
+		paths:  make(map[int]string),
+	}
+	for _, dir := range watched {
+		if err := w.add(dir); err != nil {
+			w.file.Close()
+			return nil, err
+		}
+	}
+
+	go w.read()
+	return w, nil
+}
+
+func (w *inotifyWatcher) add(dir string) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, inotifyMask)
+	if err != nil {
+		return fmt.Errorf("watching %s: %w", dir, err)
+	}
+	w.mu.Lock()
+	w.paths[wd] = dir
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *inotifyWatcher) read() {
+	defer close(w.events)
+	defer close(w.errors)
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				send(w, w.errors, err)
+			}
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			name := string(bytes.TrimRight(buf[nameStart:nameStart+int(raw.Len)], "\x00"))
+			offset = nameStart + int(raw.Len)
+
+			w.mu.Lock()
+			dir, ok := w.paths[int(raw.Wd)]
+			w.mu.Unlock()
+			if !ok || name == "" {
+				continue
+			}
+
+			path := filepath.Join(dir, name)
+			if hidden(path) {
+				continue
+			}
+			if raw.Mask&syscall.IN_ISDIR != 0 {
+				if raw.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+					if err := w.add(path); err != nil && !send(w, w.errors, err) {
+						return
+					}
+				}
+				continue
+			}
+			if !send(w, w.events, path) {
+				return
+			}
+		}
+	}
+}
+
+// send delivers v unless the watcher is closed first.
+func send[T any](w *inotifyWatcher, ch chan T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+func (w *inotifyWatcher) Events() <-chan string { return w.events }
+func (w *inotifyWatcher) Errors() <-chan error  { return w.errors }
+func (w *inotifyWatcher) Close() error {
+	var err error
+	w.closer.Do(func() {
+		close(w.done)
+		err = w.file.Close()
+	})
+	return err
+}
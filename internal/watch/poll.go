@@ -0,0 +1,117 @@
+//go:build !linux
+
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// pollInterval is how often the fallback watcher rescans the directories.
+const pollInterval = time.Second
+
+type pollWatcher struct {
+	roots  []string
+	events chan string
+	errors chan error
+	done   chan struct{}
+	closer sync.Once
+}
+
+func newWatcher(roots []string) (Watcher, error) {
+	w := &pollWatcher{
+		roots:  roots,
+		events: make(chan string),
+		errors: make(chan error),
+		done:   make(chan struct{}),
+	}
+
+	state, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	go w.poll(state)
+	return w, nil
+}
+
+// scan records the modification time of every file below the roots.
+func (w *pollWatcher) scan() (map[string]time.Time, error) {
+	watched, err := dirs(w.roots)
+	if err != nil {
+		return nil, err
+	}
+
+	state := make(map[string]time.Time)
+	for _, dir := range watched {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			path := filepath.Join(dir, e.Name())
+			if e.IsDir() || hidden(path) {
+				continue
+			}
+			if info, err := e.Info(); err == nil {
+				state[path] = info.ModTime()
+			}
+		}
+	}
+	return state, nil
+}
+
+func (w *pollWatcher) poll(state map[string]time.Time) {
+	defer close(w.events)
+	defer close(w.errors)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		next, err := w.scan()
+		if err != nil {
+			select {
+			case w.errors <- err:
+			case <-w.done:
+				return
+			}
+			continue
+		}
+
+		var changed []string
+		for path, mod := range next {
+			if prev, ok := state[path]; !ok || !prev.Equal(mod) {
+				changed = append(changed, path)
+			}
+		}
+		for path := range state {
+			if _, ok := next[path]; !ok {
+				changed = append(changed, path)
+			}
+		}
+		state = next
+
+		for _, path := range changed {
+			select {
+			case w.events <- path:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+func (w *pollWatcher) Events() <-chan string { return w.events }
+func (w *pollWatcher) Errors() <-chan error  { return w.errors }
+func (w *pollWatcher) Close() error {
+	w.closer.Do(func() { close(w.done) })
+	return nil
+}
@@ -0,0 +1,95 @@
+// Package watch reports files that change under a set of directories.
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Watcher delivers the paths of files that were written, created, renamed
+// or removed below the watched directories.
+type Watcher interface {
+	Events() <-chan string
+	Errors() <-chan error
+	Close() error
+}
+
+// New watches every directory below roots. It uses the most efficient
+// mechanism the platform offers and falls back to polling otherwise.
+func New(roots []string) (Watcher, error) {
+	return newWatcher(roots)
+}
+
+// Collect waits for a first change and then gathers further changes until
+// quiet passes without one, so an editor saving several files triggers a
+// single cycle. It returns nil once the watcher is closed.
+func Collect(w Watcher, quiet time.Duration) []string {
+	first, ok := <-w.Events()
+	if !ok {
+		return nil
+	}
+	seen := map[string]bool{first: true}
+	changed := []string{first}
+
+	timer := time.NewTimer(quiet)
+	defer timer.Stop()
+	for {
+		select {
+		case path, ok := <-w.Events():
+			if !ok {
+				return changed
+			}
+			if !seen[path] {
+				seen[path] = true
+				changed = append(changed, path)
+			}
+			timer.Reset(quiet)
+		case <-timer.C:
+			return changed
+		}
+	}
+}
+
+// dirs returns every directory below roots, skipping hidden ones. A root
+// that is a file contributes its parent directory.
+func dirs(roots []string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			root = filepath.Dir(root)
+		}
+		err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			if path != root && hidden(path) {
+				return filepath.SkipDir
+			}
+			if !seen[path] {
+				seen[path] = true
+				out = append(out, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// hidden reports whether a path names a dot file, including editor swap files.
+func hidden(path string) bool {
+	base := filepath.Base(path)
+	return strings.HasPrefix(base, ".") || strings.HasSuffix(base, "~")
+}
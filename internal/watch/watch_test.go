@@ -0,0 +1,45 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatcherReportsWrites(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "rules"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := New([]string{root})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer w.Close()
+
+	// The polling fallback compares modification times, so give the
+	// initial scan a moment before writing
+	time.Sleep(50 * time.Millisecond)
+	path := filepath.Join(root, "rules", "local_rules.xml")
+	if err := os.WriteFile(path, []byte("<group/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".swap"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan []string)
+	go func() { done <- Collect(w, 200*time.Millisecond) }()
+
+	select {
+	case got := <-done:
+		if want := []string{path}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Collect() = %v, want %v", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+}
@@ -7,12 +7,21 @@ import "reflect"
 // Schema for defining Wazuh rule test cases using edge cases with executable
 // commands
 type SchemaJson struct {
+	// Decoder the test file exercises; its edges then need no rule id
+	Decoder string `json:"decoder,omitempty" yaml:"decoder,omitempty" mapstructure:"decoder,omitempty"`
+
+	// XML decoder content
+	DecoderContent string `json:"decoder_content,omitempty" yaml:"decoderContent,omitempty" mapstructure:"decoderContent,omitempty"`
+
 	// Description of the rule and its purpose
 	Description string `json:"description" yaml:"description" mapstructure:"description"`
 
 	// List of edge cases to test the rule
 	Edges []SchemaJsonEdgesElem `json:"edges" yaml:"edges" mapstructure:"edges"`
 
+	// Values every edge is expanded with; each combination becomes a case
+	Matrix map[string][]string `json:"matrix,omitempty" yaml:"matrix,omitempty" mapstructure:"matrix,omitempty"`
+
 	// Author of the rule
 	RuleAuthor string `json:"rule_author,omitempty" yaml:"ruleAuthor,omitempty" mapstructure:"ruleAuthor,omitempty"`
 
@@ -24,20 +33,150 @@ type SchemaJson struct {
 
 	// XML Rule content
 	RuleContent string `json:"rule_content" yaml:"ruleContent" mapstructure:"ruleName"`
+
+	// Render every edge as a template, including edges without vars or a matrix
+	Templates bool `json:"templates,omitempty" yaml:"templates,omitempty" mapstructure:"templates,omitempty"`
+
+	// Named values available to the templates of every edge
+	Vars map[string]string `json:"vars,omitempty" yaml:"vars,omitempty" mapstructure:"vars,omitempty"`
 }
 
 type SchemaJsonEdgesElem struct {
 	// Command to execute to trigger the rule
-	Command SchemaJsonEdgesElemCommand `json:"command" yaml:"command" mapstructure:"command"`
+	Command SchemaJsonEdgesElemCommand `json:"command,omitempty" yaml:"command,omitempty" mapstructure:"command,omitempty"`
+
+	// Decoder expected to decode the trigger event
+	Decoder *SchemaJsonEdgesElemDecoder `json:"decoder,omitempty" yaml:"decoder,omitempty" mapstructure:"decoder,omitempty"`
 
 	// Description of the edge case and expected behavior
 	Description string `json:"description" yaml:"description" mapstructure:"description"`
 
+	// Ordered log events replayed through a single logtest session, for
+	// composite rules such as frequency, timeframe and if_matched_sid
+	Events []SchemaJsonEdgesElemEventsElem `json:"events,omitempty" yaml:"events,omitempty" mapstructure:"events,omitempty"`
+
+	// Whether the rule must fire or must not fire (defaults to fired, or to no
+	// rule assertion when max_level is set)
+	Expect SchemaJsonEdgesElemExpect `json:"expect,omitempty" yaml:"expect,omitempty" mapstructure:"expect,omitempty"`
+
 	// Expected outcome when the command is executed (e.g., rule triggered or not)
 	ExpectedOutcome string `json:"expected_outcome" yaml:"expected_outcome" mapstructure:"expected_outcome"`
 
+	// Level the rule is expected to fire with
+	Level *int `json:"level,omitempty" yaml:"level,omitempty" mapstructure:"level,omitempty"`
+
+	// Log event replayed through logtest to trigger the rule
+	Log *SchemaJsonEdgesElemLog `json:"log,omitempty" yaml:"log,omitempty" mapstructure:"log,omitempty"`
+
+	// Values this edge is expanded with, added to the test's matrix
+	Matrix map[string][]string `json:"matrix,omitempty" yaml:"matrix,omitempty" mapstructure:"matrix,omitempty"`
+
+	// Highest rule level any event of the edge may produce
+	MaxLevel *int `json:"max_level,omitempty" yaml:"max_level,omitempty" mapstructure:"max_level,omitempty"`
+
+	// Rule the edge asserts on (defaults to the test's rule id)
+	RuleId string `json:"rule_id,omitempty" yaml:"rule_id,omitempty" mapstructure:"rule_id,omitempty"`
+
 	// Title of the edge case
 	Title string `json:"title" yaml:"title" mapstructure:"title"`
+
+	// 1-based position of the event in events expected to trigger the rule
+	// (defaults to the last event)
+	TriggerEvent *int `json:"trigger_event,omitempty" yaml:"trigger_event,omitempty" mapstructure:"trigger_event,omitempty"`
+
+	// Named values available to this edge's templates, overriding the test's vars
+	Vars map[string]string `json:"vars,omitempty" yaml:"vars,omitempty" mapstructure:"vars,omitempty"`
+}
+
+// A log event in an ordered sequence
+type SchemaJsonEdgesElemEventsElem struct {
+	// Time to wait before sending the event (e.g. 500ms, 2s)
+	Delay string `json:"delay,omitempty" yaml:"delay,omitempty" mapstructure:"delay,omitempty"`
+
+	// The raw log line
+	Event string `json:"event" yaml:"event" mapstructure:"event"`
+
+	// Location reported for the event (e.g. a log file path)
+	Location string `json:"location,omitempty" yaml:"location,omitempty" mapstructure:"location,omitempty"`
+
+	// Log format used to pre-decode the event (defaults to syslog)
+	LogFormat string `json:"log_format,omitempty" yaml:"log_format,omitempty" mapstructure:"log_format,omitempty"`
+}
+
+// Log event replayed through logtest to trigger the rule
+type SchemaJsonEdgesElemLog struct {
+	// The raw log line
+	Event string `json:"event" yaml:"event" mapstructure:"event"`
+
+	// Location reported for the event (e.g. a log file path)
+	Location string `json:"location,omitempty" yaml:"location,omitempty" mapstructure:"location,omitempty"`
+
+	// Log format used to pre-decode the event (defaults to syslog)
+	LogFormat string `json:"log_format,omitempty" yaml:"log_format,omitempty" mapstructure:"log_format,omitempty"`
+}
+
+// Decoder expected to decode the trigger event
+type SchemaJsonEdgesElemDecoder struct {
+	// Decoded field values, by field name (e.g. srcip, srcuser, or a dotted
+	// path such as predecoder.program_name)
+	Fields map[string]string `json:"fields,omitempty" yaml:"fields,omitempty" mapstructure:"fields,omitempty"`
+
+	// Name of the decoder
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+
+	// Parent decoder reported for the event
+	Parent string `json:"parent,omitempty" yaml:"parent,omitempty" mapstructure:"parent,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *SchemaJsonEdgesElemDecoder) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["name"]; raw != nil && !ok {
+		return fmt.Errorf("field name in SchemaJsonEdgesElemDecoder: required")
+	}
+	type Plain SchemaJsonEdgesElemDecoder
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	if len(plain.Name) < 1 {
+		return fmt.Errorf("field %s length: must be >= %d", "name", 1)
+	}
+	*j = SchemaJsonEdgesElemDecoder(plain)
+	return nil
+}
+
+type SchemaJsonEdgesElemExpect string
+
+const SchemaJsonEdgesElemExpectFired SchemaJsonEdgesElemExpect = "fired"
+const SchemaJsonEdgesElemExpectNotFired SchemaJsonEdgesElemExpect = "not_fired"
+
+var enumValues_SchemaJsonEdgesElemExpect = []interface{}{
+	"fired",
+	"not_fired",
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *SchemaJsonEdgesElemExpect) UnmarshalJSON(value []byte) error {
+	var v string
+	if err := json.Unmarshal(value, &v); err != nil {
+		return err
+	}
+	var ok bool
+	for _, expected := range enumValues_SchemaJsonEdgesElemExpect {
+		if reflect.DeepEqual(v, expected) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("invalid value (expected one of %#v): %#v", enumValues_SchemaJsonEdgesElemExpect, v)
+	}
+	*j = SchemaJsonEdgesElemExpect(v)
+	return nil
 }
 
 // Command to execute to trigger the rule
@@ -103,14 +242,59 @@ func (j *SchemaJsonEdgesElemCommand) UnmarshalJSON(value []byte) error {
 	return nil
 }
 
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *SchemaJsonEdgesElemLog) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["event"]; raw != nil && !ok {
+		return fmt.Errorf("field event in SchemaJsonEdgesElemLog: required")
+	}
+	type Plain SchemaJsonEdgesElemLog
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	if len(plain.Event) < 1 {
+		return fmt.Errorf("field %s length: must be >= %d", "event", 1)
+	}
+	*j = SchemaJsonEdgesElemLog(plain)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *SchemaJsonEdgesElemEventsElem) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["event"]; raw != nil && !ok {
+		return fmt.Errorf("field event in SchemaJsonEdgesElemEventsElem: required")
+	}
+	type Plain SchemaJsonEdgesElemEventsElem
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	if len(plain.Event) < 1 {
+		return fmt.Errorf("field %s length: must be >= %d", "event", 1)
+	}
+	*j = SchemaJsonEdgesElemEventsElem(plain)
+	return nil
+}
+
 // UnmarshalJSON implements json.Unmarshaler.
 func (j *SchemaJsonEdgesElem) UnmarshalJSON(value []byte) error {
 	var raw map[string]interface{}
 	if err := json.Unmarshal(value, &raw); err != nil {
 		return err
 	}
-	if _, ok := raw["command"]; raw != nil && !ok {
-		return fmt.Errorf("field command in SchemaJsonEdgesElem: required")
+	_, hasCommand := raw["command"]
+	_, hasLog := raw["log"]
+	_, hasEvents := raw["events"]
+	if raw != nil && !hasCommand && !hasLog && !hasEvents {
+		return fmt.Errorf("field command, log or events in SchemaJsonEdgesElem: required")
 	}
 	if _, ok := raw["description"]; raw != nil && !ok {
 		return fmt.Errorf("field description in SchemaJsonEdgesElem: required")
@@ -129,12 +313,24 @@ func (j *SchemaJsonEdgesElem) UnmarshalJSON(value []byte) error {
 	if len(plain.Description) < 1 {
 		return fmt.Errorf("field %s length: must be >= %d", "description", 1)
 	}
+	if plain.Events != nil && len(plain.Events) < 1 {
+		return fmt.Errorf("field %s length: must be >= %d", "events", 1)
+	}
 	if len(plain.ExpectedOutcome) < 1 {
 		return fmt.Errorf("field %s length: must be >= %d", "expected_outcome", 1)
 	}
 	if len(plain.Title) < 1 {
 		return fmt.Errorf("field %s length: must be >= %d", "title", 1)
 	}
+	if plain.Level != nil && 0 > *plain.Level {
+		return fmt.Errorf("field %s: must be >= %v", "level", 0)
+	}
+	if plain.MaxLevel != nil && 0 > *plain.MaxLevel {
+		return fmt.Errorf("field %s: must be >= %v", "max_level", 0)
+	}
+	if plain.TriggerEvent != nil && 1 > *plain.TriggerEvent {
+		return fmt.Errorf("field %s: must be >= %v", "trigger_event", 1)
+	}
 	*j = SchemaJsonEdgesElem(plain)
 	return nil
 }
@@ -151,11 +347,13 @@ func (j *SchemaJson) UnmarshalJSON(value []byte) error {
 	if _, ok := raw["edges"]; raw != nil && !ok {
 		return fmt.Errorf("field edges in SchemaJson: required")
 	}
-	if _, ok := raw["rule_id"]; raw != nil && !ok {
-		return fmt.Errorf("field rule_id in SchemaJson: required")
-	}
-	if _, ok := raw["rule_name"]; raw != nil && !ok {
-		return fmt.Errorf("field rule_name in SchemaJson: required")
+	if _, decoder := raw["decoder"]; !decoder {
+		if _, ok := raw["rule_id"]; raw != nil && !ok {
+			return fmt.Errorf("field rule_id in SchemaJson: required")
+		}
+		if _, ok := raw["rule_name"]; raw != nil && !ok {
+			return fmt.Errorf("field rule_name in SchemaJson: required")
+		}
 	}
 	type Plain SchemaJson
 	var plain Plain
@@ -171,10 +369,10 @@ func (j *SchemaJson) UnmarshalJSON(value []byte) error {
 	if len(plain.RuleAuthor) < 1 {
 		return fmt.Errorf("field %s length: must be >= %d", "rule_author", 1)
 	}
-	if len(plain.RuleId) < 1 {
+	if len(plain.RuleId) < 1 && plain.Decoder == "" {
 		return fmt.Errorf("field %s length: must be >= %d", "rule_id", 1)
 	}
-	if len(plain.RuleName) < 1 {
+	if len(plain.RuleName) < 1 && plain.Decoder == "" {
 		return fmt.Errorf("field %s length: must be >= %d", "rule_name", 1)
 	}
 	*j = SchemaJson(plain)
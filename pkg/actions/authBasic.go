@@ -67,6 +67,11 @@ func AuthWithUsernameAndPassword(config configurations.WazuhCtlConfig) *Auth {
 
 func (a *Auth) JWT() *Auth {
 
+	// authentication failed and was already reported
+	if a == nil {
+		return &Auth{}
+	}
+
 	var response Response
 
 	err := json.Unmarshal(a.b, &response)
@@ -0,0 +1,47 @@
+package actions
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// CdbListSummary describes a CDB list known to the manager.
+type CdbListSummary struct {
+	Filename        string `json:"filename"`
+	RelativeDirname string `json:"relative_dirname"`
+	Items           []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	} `json:"items"`
+}
+
+// GetCdbListsFromWazuhManager returns every CDB list with its items.
+func (ctl *WazctlClient) GetCdbListsFromWazuhManager() ([]CdbListSummary, error) {
+
+	return wazuhPaginatedItems[CdbListSummary](ctl, "/lists", nil)
+}
+
+// GetCdbListFileFromWazuhManager returns the raw key:value content of a list.
+func (ctl *WazctlClient) GetCdbListFileFromWazuhManager(filename string) ([]byte, error) {
+
+	query := url.Values{}
+	query.Set("raw", "true")
+
+	return ctl.wazuhApiRequest(http.MethodGet, "/lists/files/"+url.PathEscape(filename), query, nil, "")
+}
+
+// PutCdbListFileInWazuhManager uploads a list, replacing it when overwrite is set.
+func (ctl *WazctlClient) PutCdbListFileInWazuhManager(filename string, content []byte, overwrite bool) ([]byte, error) {
+
+	query := url.Values{}
+	query.Set("overwrite", strconv.FormatBool(overwrite))
+
+	return ctl.wazuhApiRequest(http.MethodPut, "/lists/files/"+url.PathEscape(filename), query, content, "application/octet-stream")
+}
+
+// DeleteCdbListFileFromWazuhManager removes a list from the manager.
+func (ctl *WazctlClient) DeleteCdbListFileFromWazuhManager(filename string) ([]byte, error) {
+
+	return ctl.wazuhApiRequest(http.MethodDelete, "/lists/files/"+url.PathEscape(filename), nil, nil, "")
+}
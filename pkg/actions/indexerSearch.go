@@ -0,0 +1,261 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+)
+
+// Search runs query against index and decodes the response.
+func (ctl *IndexerClient) Search(index string, query any) (*opensearch.SearchResponse, error) {
+
+	uri := fmt.Sprintf("%s/%s/%s",
+		ctl.oSConfig.Address,
+		index,
+		opensearch.SearchURI)
+
+	body, err := ctl.indexerDo(query, uri, http.MethodPost)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp opensearch.SearchResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("decoding search response: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// OpenPointInTime creates a point in time on index, kept alive for keepAlive
+// after each search, and returns its id.
+func (ctl *IndexerClient) OpenPointInTime(index string, keepAlive time.Duration) (string, error) {
+
+	uri := fmt.Sprintf("%s/%s/%s?keep_alive=%dms",
+		ctl.oSConfig.Address,
+		index,
+		opensearch.PointInTimeURI,
+		keepAlive.Milliseconds())
+
+	body, err := ctl.indexerDo(map[string]any{}, uri, http.MethodPost)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		PitId string `json:"pit_id"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("decoding point in time response: %w", err)
+	}
+	if resp.PitId == "" {
+		return "", fmt.Errorf("indexer returned no point in time id")
+	}
+
+	return resp.PitId, nil
+}
+
+// SearchPointInTime runs a query naming a point in time in its pit section;
+// such searches are sent without an index.
+func (ctl *IndexerClient) SearchPointInTime(query any) (*opensearch.SearchResponse, error) {
+
+	uri := fmt.Sprintf("%s/%s",
+		ctl.oSConfig.Address,
+		opensearch.SearchURI)
+
+	body, err := ctl.indexerDo(query, uri, http.MethodPost)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp opensearch.SearchResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("decoding search response: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// ClosePointInTime deletes a point in time, releasing the resources the
+// indexer keeps for it.
+func (ctl *IndexerClient) ClosePointInTime(id string) error {
+
+	uri := fmt.Sprintf("%s/%s",
+		ctl.oSConfig.Address,
+		opensearch.PointInTimeURI)
+
+	_, err := ctl.indexerDo(map[string]any{"pit_id": []string{id}}, uri, http.MethodDelete)
+	return err
+}
+
+// FindAlertForRule returns the earliest alert for ruleId raised at or after
+// since, optionally restricted to a single agent name, and its document id.
+// It returns nil when no such alert has been indexed yet.
+func (ctl *IndexerClient) FindAlertForRule(ruleId, agent string, since time.Time) (*opensearch.Alert, string, error) {
+
+	filters := []any{
+		map[string]any{"term": map[string]any{"rule.id": ruleId}},
+		TimestampSince(since),
+	}
+	if agent != "" {
+		filters = append(filters, map[string]any{"term": map[string]any{"agent.name": agent}})
+	}
+
+	resp, err := ctl.Search(string(opensearch.AlertsIndexPattern), map[string]any{
+		"size":  1,
+		"sort":  []any{map[string]any{"timestamp": "asc"}},
+		"query": map[string]any{"bool": map[string]any{"filter": filters}},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if len(resp.Hits.Hits) == 0 {
+		return nil, "", nil
+	}
+
+	alert, err := resp.Hits.Hits[0].Alert()
+	return alert, resp.Hits.Hits[0].Id, err
+}
+
+// GetAlertById returns the alert document with the given _id from any of the
+// alert indices.
+func (ctl *IndexerClient) GetAlertById(id string) (*opensearch.Alert, error) {
+
+	resp, err := ctl.Search(string(opensearch.AlertsIndexPattern), map[string]any{
+		"size":  1,
+		"query": map[string]any{"ids": map[string]any{"values": []string{id}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Hits.Hits) == 0 {
+		return nil, fmt.Errorf("no alert with _id %s in %s", id, opensearch.AlertsIndexPattern)
+	}
+
+	return resp.Hits.Hits[0].Alert()
+}
+
+// SampleAlerts returns up to size random documents with a full_log from
+// index raised at or after since. The same seed returns the same sample as
+// long as the indices do not change.
+func (ctl *IndexerClient) SampleAlerts(index string, since time.Time, size int, seed int64) ([]opensearch.SearchHit, error) {
+
+	resp, err := ctl.Search(index, map[string]any{
+		"size": size,
+		"query": map[string]any{
+			"function_score": map[string]any{
+				"query": map[string]any{"bool": map[string]any{"filter": []any{
+					TimestampSince(since),
+					map[string]any{"exists": map[string]any{"field": "full_log"}},
+				}}},
+				"random_score": map[string]any{"seed": seed, "field": "_seq_no"},
+				"boost_mode":   "replace",
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Hits.Hits, nil
+}
+
+// AlertTermCounts returns how many alerts matching filters fall under each of
+// the top size values of field.
+func (ctl *IndexerClient) AlertTermCounts(field string, size int, filters []any) ([]opensearch.TermBucket, error) {
+
+	resp, err := ctl.Search(string(opensearch.AlertsIndexPattern), map[string]any{
+		"size":  0,
+		"query": map[string]any{"bool": map[string]any{"filter": filters}},
+		"aggs": map[string]any{
+			"terms": map[string]any{"terms": map[string]any{"field": field, "size": size}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var aggs struct {
+		Terms opensearch.TermsAggregation `json:"terms"`
+	}
+	if err := json.Unmarshal(resp.Aggregations, &aggs); err != nil {
+		return nil, fmt.Errorf("decoding terms aggregation: %w", err)
+	}
+
+	return aggs.Terms.Buckets, nil
+}
+
+// AlertAggregations runs aggs over the alerts matching filters and returns
+// the response, whose total counts every matching alert.
+func (ctl *IndexerClient) AlertAggregations(filters []any, aggs map[string]any) (*opensearch.SearchResponse, error) {
+
+	return ctl.Search(string(opensearch.AlertsIndexPattern), map[string]any{
+		"size":             0,
+		"track_total_hits": true,
+		"query":            map[string]any{"bool": map[string]any{"filter": filters}},
+		"aggs":             aggs,
+	})
+}
+
+// FindLatestAlert returns the most recent alert matching filters and its
+// document id, or nil when there is none.
+func (ctl *IndexerClient) FindLatestAlert(filters []any) (*opensearch.Alert, string, error) {
+
+	resp, err := ctl.Search(string(opensearch.AlertsIndexPattern), map[string]any{
+		"size":  1,
+		"sort":  []any{map[string]any{"timestamp": "desc"}},
+		"query": map[string]any{"bool": map[string]any{"filter": filters}},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if len(resp.Hits.Hits) == 0 {
+		return nil, "", nil
+	}
+
+	alert, err := resp.Hits.Hits[0].Alert()
+	return alert, resp.Hits.Hits[0].Id, err
+}
+
+// TermFilter is a filter matching documents whose field equals value.
+func TermFilter(field string, value any) map[string]any {
+	return map[string]any{"term": map[string]any{field: value}}
+}
+
+// TimestampSince is a range filter on the alert timestamp.
+func TimestampSince(since time.Time) map[string]any {
+	return map[string]any{"range": map[string]any{"timestamp": map[string]any{
+		"gte":    since.UnixMilli(),
+		"format": "epoch_millis",
+	}}}
+}
+
+// indexerDo sends an authenticated request to the indexer and returns the
+// body, turning non-2xx responses into errors.
+func (ctl *IndexerClient) indexerDo(payload any, uri, method string) ([]byte, error) {
+
+	request, err := ctl.oSConfig.IndexerApiRequest(payload, uri, method)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ctl.oSConfig.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, uri, resp.Status, body)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,117 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// LogtestRequest is the body of a PUT /logtest call.
+type LogtestRequest struct {
+	// Session token, empty to start a new session
+	Token     string `json:"token,omitempty"`
+	LogFormat string `json:"log_format"`
+	Location  string `json:"location"`
+	Event     string `json:"event"`
+}
+
+// LogtestResult is the data returned by logtest for a single event.
+type LogtestResult struct {
+	// Session token to reuse for events that belong together
+	Token    string   `json:"token"`
+	Messages []string `json:"messages"`
+	// Full decoder and rule output, as returned by the manager
+	Output map[string]any `json:"output"`
+	// Whether the matching rule would raise an alert
+	Alert   bool `json:"alert"`
+	Codemsg int  `json:"codemsg"`
+}
+
+// RuleId returns the id of the rule that matched, empty when none did.
+func (r *LogtestResult) RuleId() string {
+	v, _ := r.Lookup("rule.id")
+	switch id := v.(type) {
+	case string:
+		return id
+	case float64:
+		return strconv.Itoa(int(id))
+	default:
+		return ""
+	}
+}
+
+// RuleLevel returns the level of the rule that matched, 0 when none did.
+func (r *LogtestResult) RuleLevel() int {
+	v, _ := r.Lookup("rule.level")
+	if level, ok := v.(float64); ok {
+		return int(level)
+	}
+	return 0
+}
+
+// RuleDescription returns the description of the rule that matched.
+func (r *LogtestResult) RuleDescription() string {
+	v, _ := r.Lookup("rule.description")
+	s, _ := v.(string)
+	return s
+}
+
+// DecoderName returns the name of the decoder that decoded the event.
+func (r *LogtestResult) DecoderName() string {
+	v, _ := r.Lookup("decoder.name")
+	s, _ := v.(string)
+	return s
+}
+
+// Lookup returns the value at a dot separated path of the output,
+// e.g. "decoder.name" or "data.srcip".
+func (r *LogtestResult) Lookup(path string) (any, bool) {
+	var current any = r.Output
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// RunLogtest sends a single event through the manager's logtest tool.
+func (ctl *WazctlClient) RunLogtest(req LogtestRequest) (*LogtestResult, error) {
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ctl.wazuhApiRequest(http.MethodPut, "/logtest", nil, payload, "application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data  LogtestResult `json:"data"`
+		Error int           `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("decoding logtest response: %w", err)
+	}
+	if resp.Data.Codemsg < 0 {
+		return nil, fmt.Errorf("logtest failed: %s", strings.Join(resp.Data.Messages, "; "))
+	}
+
+	return &resp.Data, nil
+}
+
+// EndLogtestSession releases a logtest session on the manager.
+func (ctl *WazctlClient) EndLogtestSession(token string) error {
+
+	_, err := ctl.wazuhApiRequest(http.MethodDelete, "/logtest/sessions/"+url.PathEscape(token), nil, nil, "")
+	return err
+}
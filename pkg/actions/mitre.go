@@ -0,0 +1,25 @@
+package actions
+
+import (
+	"net/url"
+
+	"github.com/EpykLab/wazctl/pkg/mitre"
+)
+
+// GetMitreTacticsFromWazuhManager returns the ATT&CK tactics known by the manager.
+func (ctl *WazctlClient) GetMitreTacticsFromWazuhManager() ([]mitre.Tactic, error) {
+
+	query := url.Values{}
+	query.Set("select", "id,external_id,name,short_name")
+
+	return wazuhPaginatedItems[mitre.Tactic](ctl, "/mitre/tactics", query)
+}
+
+// GetMitreTechniquesFromWazuhManager returns the ATT&CK techniques known by the manager.
+func (ctl *WazctlClient) GetMitreTechniquesFromWazuhManager() ([]mitre.Technique, error) {
+
+	query := url.Values{}
+	query.Set("select", "id,external_id,name,tactics,deprecated")
+
+	return wazuhPaginatedItems[mitre.Technique](ctl, "/mitre/techniques", query)
+}
@@ -0,0 +1,91 @@
+package actions
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// WazuhRule is a rule as returned by the /rules endpoint.
+type WazuhRule struct {
+	Id              int            `json:"id"`
+	Level           int            `json:"level"`
+	Filename        string         `json:"filename"`
+	RelativeDirname string         `json:"relative_dirname"`
+	Status          string         `json:"status"`
+	Description     string         `json:"description"`
+	Groups          []string       `json:"groups"`
+	Details         map[string]any `json:"details"`
+	Mitre           []string       `json:"mitre"`
+	PciDss          []string       `json:"pci_dss"`
+	Gdpr            []string       `json:"gdpr"`
+	Hipaa           []string       `json:"hipaa"`
+	Nist80053       []string       `json:"nist_800_53"`
+	Gpg13           []string       `json:"gpg13"`
+	Tsc             []string       `json:"tsc"`
+}
+
+// GetRulesFromWazuhManager returns every rule matching query, such as
+// relative_dirname=etc/rules for custom rules or rule_ids for a lookup.
+func (ctl *WazctlClient) GetRulesFromWazuhManager(query url.Values) ([]WazuhRule, error) {
+
+	return wazuhPaginatedItems[WazuhRule](ctl, "/rules", query)
+}
+
+// GetCustomRulesFromWazuhManager returns the rules loaded from etc/rules.
+func (ctl *WazctlClient) GetCustomRulesFromWazuhManager() ([]WazuhRule, error) {
+
+	query := url.Values{}
+	query.Set("relative_dirname", "etc/rules")
+
+	return ctl.GetRulesFromWazuhManager(query)
+}
+
+// WazuhRuleIds returns the ids of rules as strings.
+func WazuhRuleIds(rules []WazuhRule) []string {
+	ids := make([]string, 0, len(rules))
+	for _, r := range rules {
+		ids = append(ids, strconv.Itoa(r.Id))
+	}
+	return ids
+}
+
+// GetRuleFromWazuhManager returns a single rule by id.
+func (ctl *WazctlClient) GetRuleFromWazuhManager(id string) (*WazuhRule, error) {
+
+	query := url.Values{}
+	query.Set("rule_ids", id)
+
+	rules, err := ctl.GetRulesFromWazuhManager(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("rule %s not found in the manager", id)
+	}
+
+	return &rules[0], nil
+}
+
+// GetRuleFileFromWazuhManager returns the raw XML of a rule file.
+func (ctl *WazctlClient) GetRuleFileFromWazuhManager(filename, relativeDirname string) ([]byte, error) {
+
+	query := url.Values{}
+	query.Set("raw", "true")
+	if relativeDirname != "" {
+		query.Set("relative_dirname", relativeDirname)
+	}
+
+	return ctl.wazuhApiRequest(http.MethodGet, "/rules/files/"+url.PathEscape(filename), query, nil, "")
+}
+
+// PutRuleFileInWazuhManager uploads a rule file to etc/rules, replacing it
+// when overwrite is set.
+func (ctl *WazctlClient) PutRuleFileInWazuhManager(filename string, content []byte, overwrite bool) ([]byte, error) {
+
+	query := url.Values{}
+	query.Set("overwrite", strconv.FormatBool(overwrite))
+
+	return ctl.wazuhApiRequest(http.MethodPut, "/rules/files/"+url.PathEscape(filename), query, content, "application/octet-stream")
+}
@@ -40,7 +40,7 @@ func (ctl *WazctlClient) CreateNewUserInWazuhManager(opts *CreateNewWazuhUserOpt
 		ApiControllersSecurityControllerCreateUserRequest(*newUser).
 		Execute()
 	if err != nil {
-		return nil, fmt.Errorf(fmt.Sprintf("Error when calling `SecurityAPI.ApiControllersSecurityControllerCreateUser``: %v\n", err))
+		return nil, fmt.Errorf("Error when calling `SecurityAPI.ApiControllersSecurityControllerCreateUser``: %v", err)
 	}
 
 	return resp.MarshalJSON()
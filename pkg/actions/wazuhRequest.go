@@ -0,0 +1,127 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	api "github.com/EpykLab/wasabi"
+)
+
+// WazuhItems is the envelope the Wazuh API wraps around list responses.
+type WazuhItems[T any] struct {
+	Data struct {
+		AffectedItems      []T `json:"affected_items"`
+		TotalAffectedItems int `json:"total_affected_items"`
+		FailedItems        []struct {
+			Error struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+			ID []string `json:"id"`
+		} `json:"failed_items"`
+	} `json:"data"`
+	Message string `json:"message"`
+	Error   int    `json:"error"`
+}
+
+// wazuhApiRequest sends a request to the Wazuh API using the same server,
+// HTTP client and token as the generated SDK. It is used for endpoints where
+// the SDK drops response data or only accepts file handles as request bodies.
+func (ctl *WazctlClient) wazuhApiRequest(method, path string, query url.Values, body []byte, contentType string) ([]byte, error) {
+	cfg := ctl.Client.GetConfig()
+	if len(cfg.Servers) == 0 {
+		return nil, fmt.Errorf("no Wazuh API server configured")
+	}
+
+	uri := strings.TrimSuffix(cfg.Servers[0].URL, "/") + path
+	if len(query) > 0 {
+		uri = fmt.Sprintf("%s?%s", uri, query.Encode())
+	}
+
+	ctx := ctl.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, uri, reader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	if token, ok := ctx.Value(api.ContextAccessToken).(string); ok {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	request.Header.Set("User-Agent", cfg.UserAgent)
+
+	resp, err := cfg.HTTPClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, wazuhErrorDetail(data))
+	}
+
+	return data, nil
+}
+
+// wazuhPaginatedItems collects every affected item of a list endpoint,
+// following offset pagination until the reported total is reached.
+func wazuhPaginatedItems[T any](ctl *WazctlClient, path string, query url.Values) ([]T, error) {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("limit", "500")
+
+	var items []T
+	for {
+		q.Set("offset", strconv.Itoa(len(items)))
+
+		body, err := ctl.wazuhApiRequest(http.MethodGet, path, q, nil, "")
+		if err != nil {
+			return nil, err
+		}
+
+		var resp WazuhItems[T]
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("decoding %s response: %w", path, err)
+		}
+
+		items = append(items, resp.Data.AffectedItems...)
+		if len(resp.Data.AffectedItems) == 0 || len(items) >= resp.Data.TotalAffectedItems {
+			return items, nil
+		}
+	}
+}
+
+// wazuhErrorDetail extracts the human readable part of a Wazuh API error body.
+func wazuhErrorDetail(body []byte) string {
+	var apiErr struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Detail != "" {
+		return apiErr.Detail
+	}
+	return strings.TrimSpace(string(body))
+}
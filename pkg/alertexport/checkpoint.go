@@ -0,0 +1,75 @@
+package alertexport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint records how far an export got, so an interrupted export can be
+// resumed with the same query and file settings.
+type Checkpoint struct {
+	Output string         `json:"output"`
+	Format Format         `json:"format"`
+	Gzip   bool           `json:"gzip"`
+	Fields []string       `json:"fields,omitempty"`
+	Query  map[string]any `json:"query"`
+	// Sort values of the last alert written
+	After []any `json:"after,omitempty"`
+	// Bytes of the output holding the alerts written
+	Offset   int64 `json:"offset"`
+	Exported int   `json:"exported"`
+	Total    int   `json:"total"`
+}
+
+// LoadCheckpoint reads the checkpoint at path, returning nil when there is
+// none.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// sort values are passed back to the indexer as they were returned
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var c Checkpoint
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("decoding checkpoint %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the checkpoint to path, replacing the previous one only once
+// the new one is complete.
+func (c *Checkpoint) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,244 @@
+package alertexport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+)
+
+// Format is the file format alerts are exported in.
+type Format string
+
+const (
+	// One search hit, with its _index, _id and _source, per line
+	FormatNdjson Format = "ndjson"
+	// A header and one row of the chosen fields per alert
+	FormatCsv Format = "csv"
+)
+
+// DefaultFields are the alert fields exported to CSV when none are chosen.
+var DefaultFields = []string{
+	"timestamp",
+	"_id",
+	"agent.id",
+	"agent.name",
+	"rule.id",
+	"rule.level",
+	"rule.description",
+	"location",
+	"full_log",
+}
+
+// ParseFormat validates a format name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatNdjson, FormatCsv:
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported format %q, expected ndjson or csv", name)
+}
+
+// Writer writes pages of alerts to an export file. Each page is written, and
+// with gzip compressed as its own gzip member, in a single write, so the file
+// can be cut back to the end of any page and appended to; gzip readers
+// decompress the concatenated members as one stream.
+type Writer struct {
+	w      io.Writer
+	format Format
+	gzip   bool
+	fields []string
+	offset int64
+	sync   func() error
+}
+
+// NewWriter returns a writer appending to w, which already holds offset bytes
+// of the export. The CSV header is written when offset is 0.
+func NewWriter(w io.Writer, format Format, compress bool, fields []string, offset int64) (*Writer, error) {
+	if format == FormatCsv && len(fields) == 0 {
+		fields = DefaultFields
+	}
+	out := &Writer{w: w, format: format, gzip: compress, fields: fields, offset: offset}
+
+	if format == FormatCsv && offset == 0 {
+		var b bytes.Buffer
+		cw := csv.NewWriter(&b)
+		_ = cw.Write(fields)
+		cw.Flush()
+		if err := out.write(b.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Open opens the export file at path, keeping its first offset bytes and
+// dropping anything an interrupted export wrote after them. An offset of 0
+// starts the file afresh.
+func Open(path string, format Format, compress bool, fields []string, offset int64) (*Writer, *os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if info.Size() < offset {
+		f.Close()
+		return nil, nil, fmt.Errorf("%s holds %d bytes but the checkpoint records %d", path, info.Size(), offset)
+	}
+	if err := f.Truncate(offset); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	w, err := NewWriter(f, format, compress, fields, offset)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	w.sync = f.Sync
+	return w, f, nil
+}
+
+// Offset returns the number of bytes of the export written so far.
+func (w *Writer) Offset() int64 {
+	return w.offset
+}
+
+// WritePage writes a page of alerts and, for files opened with Open, syncs
+// it to disk.
+func (w *Writer) WritePage(hits []opensearch.SearchHit) error {
+	var b bytes.Buffer
+
+	switch w.format {
+	case FormatCsv:
+		cw := csv.NewWriter(&b)
+		for _, hit := range hits {
+			row, err := Row(hit, w.fields)
+			if err != nil {
+				return err
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	default:
+		enc := json.NewEncoder(&b)
+		for _, hit := range hits {
+			hit.Sort = nil
+			if err := enc.Encode(hit); err != nil {
+				return err
+			}
+		}
+	}
+
+	return w.write(b.Bytes())
+}
+
+func (w *Writer) write(data []byte) error {
+	if w.gzip {
+		var b bytes.Buffer
+		zw := gzip.NewWriter(&b)
+		if _, err := zw.Write(data); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		data = b.Bytes()
+	}
+
+	n, err := w.w.Write(data)
+	w.offset += int64(n)
+	if err != nil {
+		return err
+	}
+	if w.sync != nil {
+		// a checkpoint must never record bytes that are not on disk
+		return w.sync()
+	}
+	return nil
+}
+
+// Row returns the values of fields in an alert. Fields are dotted paths into
+// the document, or _id and _index; missing fields are empty and objects and
+// arrays are written as JSON.
+func Row(hit opensearch.SearchHit, fields []string) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(hit.Source))
+	dec.UseNumber()
+	var source map[string]any
+	if err := dec.Decode(&source); err != nil {
+		return nil, fmt.Errorf("decoding alert %s: %w", hit.Id, err)
+	}
+
+	row := make([]string, len(fields))
+	for i, field := range fields {
+		switch field {
+		case "_id":
+			row[i] = hit.Id
+		case "_index":
+			row[i] = hit.Index
+		default:
+			value, err := format(lookup(source, field))
+			if err != nil {
+				return nil, err
+			}
+			row[i] = value
+		}
+	}
+	return row, nil
+}
+
+// lookup returns the value at a dotted path, also matching keys that contain
+// dots themselves.
+func lookup(doc map[string]any, path string) any {
+	if v, ok := doc[path]; ok {
+		return v
+	}
+	for i := strings.IndexByte(path, '.'); i >= 0; {
+		if nested, ok := doc[path[:i]].(map[string]any); ok {
+			if v := lookup(nested, path[i+1:]); v != nil {
+				return v
+			}
+		}
+		next := strings.IndexByte(path[i+1:], '.')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return nil
+}
+
+func format(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	}
+	out, err := json.Marshal(value)
+	return string(out), err
+}
@@ -0,0 +1,108 @@
+package alertquery
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Node
+	}{
+		{"", nil},
+		{"sshd", Text{"sshd"}},
+		{`"Failed password"`, Text{"Failed password"}},
+		{"agent:web-1", Term{"agent.name", ":", "web-1"}},
+		{`data.url:"/a b"`, Term{"data.url", ":", "/a b"}},
+		{"level:>=10", Term{"rule.level", ">=", "10"}},
+		{"location:C:\\logs", Term{"location", ":", "C:\\logs"}},
+		{"a b", And{Text{"a"}, Text{"b"}}},
+		{"a AND b OR c", Or{And{Text{"a"}, Text{"b"}}, Text{"c"}}},
+		{"a AND (b OR c)", And{Text{"a"}, Or{Text{"b"}, Text{"c"}}}},
+		{"NOT group:sshd -agent:db", And{Not{Term{"rule.groups", ":", "sshd"}}, Not{Term{"agent.name", ":", "db"}}}},
+		{`"OR"`, Text{"OR"}},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.query)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tt.query, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"a AND", "(a OR b", "a)", `"open`, "OR a", "agent:", "NOT"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) accepted an invalid query", bad)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	since := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	q, err := Build(Options{
+		Rules:  []string{"5710", "5712"},
+		Agents: []string{"001", "web-*"},
+		Level:  ">=10",
+		Since:  since,
+		Query:  `srcip:10.0.0.5 NOT "health check"`,
+	})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	want := `{"bool":{"filter":[` +
+		`{"range":{"timestamp":{"format":"epoch_millis","gte":1792368000000}}},` +
+		`{"terms":{"rule.id":["5710","5712"]}},` +
+		`{"bool":{"minimum_should_match":1,"should":[{"terms":{"agent.id":["001"]}},{"wildcard":{"agent.name":{"value":"web-*"}}}]}},` +
+		`{"range":{"rule.level":{"gte":10}}},` +
+		`{"bool":{"filter":[{"match_phrase":{"srcip":"10.0.0.5"}},` +
+		`{"bool":{"must_not":[{"multi_match":{"fields":["full_log","rule.description"],"query":"health check","type":"phrase"}}]}}]}}` +
+		`]}}`
+	got, _ := json.Marshal(q)
+	if string(got) != want {
+		t.Errorf("Build() =\n%s\nwant\n%s", got, want)
+	}
+
+	if q, _ := Build(Options{}); q["match_all"] == nil {
+		t.Errorf("Build() of no options = %v, want match_all", q)
+	}
+	if _, err := Build(Options{Query: "(a"}); err == nil {
+		t.Error("Build() accepted an invalid query")
+	}
+}
+
+func TestLevelFilter(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"12", `{"match_phrase":{"rule.level":"12"}}`},
+		{"=12", `{"match_phrase":{"rule.level":"12"}}`},
+		{">= 10", `{"range":{"rule.level":{"gte":10}}}`},
+		{"<3", `{"range":{"rule.level":{"lt":3}}}`},
+		{"5-10", `{"range":{"rule.level":{"gte":5,"lte":10}}}`},
+	}
+	for _, tt := range tests {
+		f, err := LevelFilter(tt.expr)
+		if err != nil {
+			t.Errorf("LevelFilter(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if got, _ := json.Marshal(f); string(got) != tt.want {
+			t.Errorf("LevelFilter(%q) = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"high", ">=", "10-5"} {
+		if _, err := LevelFilter(bad); err == nil {
+			t.Errorf("LevelFilter(%q) accepted an invalid level", bad)
+		}
+	}
+}
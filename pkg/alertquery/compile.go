@@ -0,0 +1,216 @@
+package alertquery
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options are the filters of an alert search. Values of the same filter
+// are alternatives; different filters must all match.
+type Options struct {
+	Rules  []string
+	Agents []string
+	Groups []string
+	Mitre  []string
+	// Level comparison such as 10, >=10, <3 or 5-10
+	Level string
+	// Time window; a zero time leaves that side open
+	Since time.Time
+	Until time.Time
+	// Query in the syntax read by Parse
+	Query string
+}
+
+// Build compiles opts into an OpenSearch query.
+func Build(opts Options) (map[string]any, error) {
+	var filters []any
+
+	if r := timeRange(opts.Since, opts.Until); r != nil {
+		filters = append(filters, r)
+	}
+	if len(opts.Rules) > 0 {
+		filters = append(filters, terms("rule.id", opts.Rules))
+	}
+	if len(opts.Agents) > 0 {
+		// agent ids are numeric, names rarely are
+		var ids, names []string
+		for _, a := range opts.Agents {
+			if isAgentId(a) {
+				ids = append(ids, a)
+			} else {
+				names = append(names, a)
+			}
+		}
+		var agents []any
+		if len(ids) > 0 {
+			agents = append(agents, terms("agent.id", ids))
+		}
+		for _, name := range names {
+			agents = append(agents, compileTerm(Term{Field: "agent.name", Op: ":", Value: name}))
+		}
+		filters = append(filters, should(agents))
+	}
+	if len(opts.Groups) > 0 {
+		filters = append(filters, terms("rule.groups", opts.Groups))
+	}
+	if len(opts.Mitre) > 0 {
+		filters = append(filters, terms("rule.mitre.id", opts.Mitre))
+	}
+	if opts.Level != "" {
+		level, err := LevelFilter(opts.Level)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, level)
+	}
+	if opts.Query != "" {
+		n, err := Parse(opts.Query)
+		if err != nil {
+			return nil, fmt.Errorf("query: %w", err)
+		}
+		if n != nil {
+			filters = append(filters, Compile(n))
+		}
+	}
+
+	if len(filters) == 0 {
+		return map[string]any{"match_all": map[string]any{}}, nil
+	}
+	return map[string]any{"bool": map[string]any{"filter": filters}}, nil
+}
+
+var agentIdPattern = regexp.MustCompile(`^\d{3,}$`)
+
+// isAgentId reports whether value looks like an agent id such as 001.
+func isAgentId(value string) bool {
+	return agentIdPattern.MatchString(value)
+}
+
+var levelRange = regexp.MustCompile(`^(\d+)-(\d+)$`)
+
+// LevelFilter compiles a rule level comparison: an exact level, a level
+// prefixed with >, >=, < or <=, or an inclusive range such as 5-10.
+func LevelFilter(expr string) (map[string]any, error) {
+	expr = strings.ReplaceAll(expr, " ", "")
+	if m := levelRange.FindStringSubmatch(expr); m != nil {
+		low, _ := strconv.Atoi(m[1])
+		high, _ := strconv.Atoi(m[2])
+		if low > high {
+			return nil, fmt.Errorf("invalid level range %q", expr)
+		}
+		return rangeOf("rule.level", map[string]any{"gte": low, "lte": high}), nil
+	}
+
+	op, value := ":", expr
+	for _, prefix := range []string{">=", "<=", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(expr, prefix); ok {
+			op, value = prefix, rest
+			break
+		}
+	}
+	if _, err := strconv.Atoi(value); err != nil {
+		return nil, fmt.Errorf("invalid level %q, expected a level such as 10, >=10 or 5-10", expr)
+	}
+	if op == "=" {
+		op = ":"
+	}
+	return Compile(Term{Field: "rule.level", Op: op, Value: value}), nil
+}
+
+// Compile translates a parsed query into OpenSearch query DSL.
+func Compile(n Node) map[string]any {
+	switch n := n.(type) {
+	case And:
+		var filters []any
+		for _, operand := range n {
+			filters = append(filters, Compile(operand))
+		}
+		return map[string]any{"bool": map[string]any{"filter": filters}}
+	case Or:
+		var operands []any
+		for _, operand := range n {
+			operands = append(operands, Compile(operand))
+		}
+		return should(operands)
+	case Not:
+		return map[string]any{"bool": map[string]any{"must_not": []any{Compile(n.Node)}}}
+	case Text:
+		return map[string]any{"multi_match": map[string]any{
+			"query":  n.Value,
+			"fields": TextFields,
+			"type":   "phrase",
+		}}
+	case Term:
+		return compileTerm(n)
+	}
+	panic(fmt.Sprintf("alertquery: unknown node %T", n))
+}
+
+var rangeOps = map[string]string{">": "gt", ">=": "gte", "<": "lt", "<=": "lte"}
+
+func compileTerm(t Term) map[string]any {
+	if op, ok := rangeOps[t.Op]; ok {
+		return rangeOf(t.Field, map[string]any{op: number(t.Value)})
+	}
+	switch {
+	case t.Value == "*":
+		return map[string]any{"exists": map[string]any{"field": t.Field}}
+	case strings.ContainsAny(t.Value, "*?"):
+		return map[string]any{"wildcard": map[string]any{t.Field: map[string]any{"value": t.Value}}}
+	}
+	return match(t.Field, t.Value)
+}
+
+// number keeps numeric range bounds numeric so they are not compared as
+// strings; other bounds, such as dates, are passed through.
+func number(value string) any {
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		return n
+	}
+	return value
+}
+
+// match matches a keyword field exactly and a text field as a phrase.
+func match(field, value string) map[string]any {
+	return map[string]any{"match_phrase": map[string]any{field: value}}
+}
+
+func terms(field string, values []string) map[string]any {
+	return map[string]any{"terms": map[string]any{field: values}}
+}
+
+func should(queries []any) map[string]any {
+	if len(queries) == 1 {
+		return queries[0].(map[string]any)
+	}
+	return map[string]any{"bool": map[string]any{"should": queries, "minimum_should_match": 1}}
+}
+
+func rangeOf(field string, bounds map[string]any) map[string]any {
+	return map[string]any{"range": map[string]any{field: bounds}}
+}
+
+// timeRange filters on the alert timestamp, or returns nil when both ends
+// are open.
+func timeRange(since, until time.Time) map[string]any {
+	return timeRangeOf("timestamp", since, until)
+}
+
+// timeRangeOf filters on a date field, or returns nil when both ends are
+// open.
+func timeRangeOf(field string, since, until time.Time) map[string]any {
+	if since.IsZero() && until.IsZero() {
+		return nil
+	}
+	bounds := map[string]any{"format": "epoch_millis"}
+	if !since.IsZero() {
+		bounds["gte"] = since.UnixMilli()
+	}
+	if !until.IsZero() {
+		bounds["lt"] = until.UnixMilli()
+	}
+	return rangeOf(field, bounds)
+}
@@ -0,0 +1,104 @@
+package alertquery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+)
+
+// DefaultKeepAlive is how long the indexer keeps an export's point in time
+// open between pages.
+const DefaultKeepAlive = 5 * time.Minute
+
+// PointInTimeSearcher runs searches against a point in time of an index.
+type PointInTimeSearcher interface {
+	OpenPointInTime(index string, keepAlive time.Duration) (string, error)
+	SearchPointInTime(query any) (*opensearch.SearchResponse, error)
+	ClosePointInTime(id string) error
+}
+
+// Export pages through every alert matching a query, oldest first. The pages
+// are read from a point in time, so alerts indexed while exporting do not
+// shift them, and each page continues with search_after from the last alert
+// of the previous one, so exports are not limited by the result window.
+type Export struct {
+	searcher PointInTimeSearcher
+	index    string
+	query    map[string]any
+
+	// Sort values of the last alert exported; set to resume an export
+	After []any
+	// Alerts matching the query, known once the first page has been read
+	Total int
+
+	PageSize  int
+	KeepAlive time.Duration
+}
+
+// NewExport returns an export of the alerts in index matching query.
+func NewExport(searcher PointInTimeSearcher, index string, query map[string]any) *Export {
+	return &Export{
+		searcher:  searcher,
+		index:     index,
+		query:     query,
+		PageSize:  DefaultPageSize,
+		KeepAlive: DefaultKeepAlive,
+	}
+}
+
+// Run passes each page of alerts to page until every alert has been exported
+// or ctx is done, in which case it returns ctx.Err(). After is advanced past a
+// page once page has returned without error, so an export stopped at any
+// point can be resumed from After without losing or repeating alerts.
+func (e *Export) Run(ctx context.Context, page func(hits []opensearch.SearchHit) error) error {
+	pit, err := e.searcher.OpenPointInTime(e.index, e.KeepAlive)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = e.searcher.ClosePointInTime(pit) }()
+
+	for first := true; ; first = false {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		body := map[string]any{
+			"size":             e.PageSize,
+			"sort":             tailSort("asc"),
+			"query":            e.query,
+			"track_total_hits": first,
+			"pit": map[string]any{
+				"id":         pit,
+				"keep_alive": fmt.Sprintf("%dms", e.KeepAlive.Milliseconds()),
+			},
+		}
+		if e.After != nil {
+			body["search_after"] = e.After
+		}
+
+		resp, err := e.searcher.SearchPointInTime(body)
+		if err != nil {
+			return err
+		}
+		if resp.PitId != "" {
+			pit = resp.PitId
+		}
+		if first {
+			e.Total = resp.Hits.Total.Value
+		}
+
+		hits := resp.Hits.Hits
+		if len(hits) == 0 {
+			return nil
+		}
+		if err := page(hits); err != nil {
+			return err
+		}
+		e.After = hits[len(hits)-1].Sort
+		if len(hits) < e.PageSize {
+			return nil
+		}
+	}
+}
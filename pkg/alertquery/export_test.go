@@ -0,0 +1,91 @@
+package alertquery
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+)
+
+// pitIndex serves fakeIndex searches through a point in time and records
+// whether it was closed.
+type pitIndex struct {
+	fakeIndex
+	open bool
+}
+
+func (p *pitIndex) OpenPointInTime(index string, keepAlive time.Duration) (string, error) {
+	p.open = true
+	return "pit-1", nil
+}
+
+func (p *pitIndex) SearchPointInTime(query any) (*opensearch.SearchResponse, error) {
+	body := query.(map[string]any)
+	if pit := body["pit"].(map[string]any); pit["id"] == nil || !p.open {
+		return nil, errors.New("search without an open point in time")
+	}
+	resp, err := p.Search("", query)
+	if err != nil {
+		return nil, err
+	}
+	resp.Hits.Total.Value = len(p.docs)
+	return resp, nil
+}
+
+func (p *pitIndex) ClosePointInTime(id string) error {
+	p.open = false
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	index := &pitIndex{fakeIndex: fakeIndex{docs: []opensearch.SearchHit{
+		doc(1000, "a"), doc(2000, "b"), doc(2000, "c"), doc(3000, "d"), doc(4000, "e"),
+	}}}
+	query := map[string]any{"match_all": map[string]any{}}
+
+	// Stop after the second page, as an interrupted export would
+	ctx, cancel := context.WithCancel(context.Background())
+	export := NewExport(index, "wazuh-alerts-*", query)
+	export.PageSize = 2
+	var exported []string
+	err := export.Run(ctx, func(hits []opensearch.SearchHit) error {
+		exported = append(exported, ids(hits)...)
+		if len(exported) == 4 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+	if index.open {
+		t.Error("point in time left open")
+	}
+	if export.Total != 5 {
+		t.Errorf("Total = %d, want 5", export.Total)
+	}
+
+	// A page that fails to be written is exported again on resume
+	resumed := NewExport(index, "wazuh-alerts-*", query)
+	resumed.PageSize = 2
+	resumed.After = export.After
+	failed := resumed.Run(context.Background(), func(hits []opensearch.SearchHit) error {
+		return errors.New("disk full")
+	})
+	if failed == nil || !slices.Equal(resumed.After, export.After) {
+		t.Fatalf("Run() = %v advanced to %v after a failed page", failed, resumed.After)
+	}
+
+	if err := resumed.Run(context.Background(), func(hits []opensearch.SearchHit) error {
+		exported = append(exported, ids(hits)...)
+		return nil
+	}); err != nil {
+		t.Fatalf("resumed Run() error = %v", err)
+	}
+	if want := []string{"a", "b", "c", "d", "e"}; !slices.Equal(exported, want) {
+		t.Errorf("exported %v, want %v", exported, want)
+	}
+}
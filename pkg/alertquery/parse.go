@@ -0,0 +1,289 @@
+package alertquery
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Aliases are short names accepted for common alert fields in field:value
+// terms and the search flags.
+var Aliases = map[string]string{
+	"rule":     "rule.id",
+	"level":    "rule.level",
+	"group":    "rule.groups",
+	"mitre":    "rule.mitre.id",
+	"agent":    "agent.name",
+	"agent_id": "agent.id",
+	"decoder":  "decoder.name",
+	"manager":  "manager.name",
+}
+
+// TextFields are searched by free text terms.
+var TextFields = []string{"full_log", "rule.description"}
+
+// Node is a parsed query expression.
+type Node interface {
+	node()
+}
+
+// And matches alerts matching every operand.
+type And []Node
+
+// Or matches alerts matching any operand.
+type Or []Node
+
+// Not matches alerts not matching its operand.
+type Not struct{ Node Node }
+
+// Term is a field:value comparison. Op is ":" for a match, or one of >, >=,
+// < and <= for a range.
+type Term struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// Text is a free text term matched against TextFields.
+type Text struct{ Value string }
+
+func (And) node()  {}
+func (Or) node()   {}
+func (Not) node()  {}
+func (Term) node() {}
+func (Text) node() {}
+
+// token is a lexical token of a query. Quoted is set for words written in
+// double quotes, which are never operators or field:value terms.
+type token struct {
+	Value  string
+	Quoted bool
+	Pos    int
+}
+
+// Parse parses a query such as
+//
+//	agent:web-* AND (rule.level:>=10 OR group:authentication_failed) NOT "health check"
+//
+// Terms next to each other are joined with AND; AND binds tighter than OR,
+// and NOT or a leading - negates the following term or group. An empty query
+// returns a nil Node.
+func Parse(query string) (Node, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	p := &parser{tokens: tokens}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.errorf("unexpected %q", p.tokens[p.pos].Value)
+	}
+	return n, nil
+}
+
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, token{Value: string(r), Pos: i})
+			i++
+		case r == '"':
+			value, end, err := quoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{Value: value, Quoted: true, Pos: i})
+			i = end
+		default:
+			start := i
+			var b strings.Builder
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' {
+				if runes[i] == '"' {
+					// field:"quoted value"
+					value, end, err := quoted(runes, i)
+					if err != nil {
+						return nil, err
+					}
+					b.WriteString(value)
+					i = end
+					continue
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{Value: b.String(), Pos: start})
+		}
+	}
+	return tokens, nil
+}
+
+// quoted reads the double quoted string starting at runes[start] and returns
+// its unescaped value and the index after the closing quote.
+func quoted(runes []rune, start int) (string, int, error) {
+	var b strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+			}
+			b.WriteRune(runes[i])
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteRune(runes[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quote at position %d", start+1)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	if p.pos < len(p.tokens) {
+		return fmt.Errorf("position %d: %s", p.tokens[p.pos].Pos+1, fmt.Sprintf(format, args...))
+	}
+	return fmt.Errorf("end of query: %s", fmt.Sprintf(format, args...))
+}
+
+// keyword reports whether the next token is the unquoted operator word.
+func (p *parser) keyword(word string) bool {
+	if p.pos >= len(p.tokens) {
+		return false
+	}
+	t := p.tokens[p.pos]
+	return !t.Quoted && t.Value == word
+}
+
+func (p *parser) or() (Node, error) {
+	var operands Or
+	for {
+		n, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, n)
+		if !p.keyword("OR") {
+			break
+		}
+		p.pos++
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return operands, nil
+}
+
+func (p *parser) and() (Node, error) {
+	var operands And
+	for {
+		n, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, n)
+
+		if p.keyword("AND") {
+			p.pos++
+			continue
+		}
+		if p.pos >= len(p.tokens) || p.keyword("OR") || p.keyword(")") {
+			break
+		}
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return operands, nil
+}
+
+func (p *parser) not() (Node, error) {
+	if p.keyword("NOT") {
+		p.pos++
+		n, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return Not{n}, nil
+	}
+	if p.pos < len(p.tokens) && !p.tokens[p.pos].Quoted && len(p.tokens[p.pos].Value) > 1 && strings.HasPrefix(p.tokens[p.pos].Value, "-") {
+		p.tokens[p.pos].Value = p.tokens[p.pos].Value[1:]
+		n, err := p.primary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{n}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (Node, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, p.errorf("expected a term")
+	}
+	t := p.tokens[p.pos]
+
+	if !t.Quoted {
+		switch t.Value {
+		case "(":
+			p.pos++
+			n, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			if !p.keyword(")") {
+				return nil, p.errorf("expected )")
+			}
+			p.pos++
+			return n, nil
+		case ")", "AND", "OR":
+			return nil, p.errorf("expected a term, got %q", t.Value)
+		}
+	}
+	p.pos++
+
+	if t.Quoted {
+		return Text{t.Value}, nil
+	}
+	field, value, ok := strings.Cut(t.Value, ":")
+	if !ok || field == "" {
+		return Text{t.Value}, nil
+	}
+
+	term := Term{Field: ResolveField(field), Op: ":", Value: value}
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if rest, ok := strings.CutPrefix(value, op); ok {
+			term.Op, term.Value = op, rest
+			break
+		}
+	}
+	if term.Value == "" {
+		p.pos--
+		return nil, p.errorf("%s has no value", t.Value)
+	}
+	return term, nil
+}
+
+// ResolveField returns the alert field an alias stands for, or field itself.
+func ResolveField(field string) string {
+	if resolved, ok := Aliases[field]; ok {
+		return resolved
+	}
+	return field
+}
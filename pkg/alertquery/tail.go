@@ -0,0 +1,122 @@
+package alertquery
+
+import (
+	"slices"
+	"time"
+
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+)
+
+// DefaultPageSize is how many alerts a tail fetches per request.
+const DefaultPageSize = 500
+
+// Searcher runs searches against the indexer.
+type Searcher interface {
+	Search(index string, query any) (*opensearch.SearchResponse, error)
+}
+
+// DefaultLag is how far behind the current time a tail reads, so alerts
+// indexed shortly after their @timestamp are not passed over.
+const DefaultLag = 10 * time.Second
+
+// Tail follows the alerts matching a query as they are indexed. Alerts are
+// ordered by @timestamp with the Wazuh alert id breaking ties, and each
+// request continues with search_after from the last alert returned, so no
+// alert is returned twice or skipped between requests. Alerts reach the
+// indexer some time after their @timestamp, so only alerts at least Lag old
+// are read; an alert indexed later than that would sort before alerts
+// already returned and be missed.
+type Tail struct {
+	searcher Searcher
+	index    string
+	query    map[string]any
+	start    time.Time
+	after    []any
+	now      func() time.Time
+
+	PageSize int
+	Lag      time.Duration
+}
+
+// NewTail returns a tail of the alerts in index matching query. Until an
+// alert has been returned, only alerts from start on are followed.
+func NewTail(searcher Searcher, index string, query map[string]any, start time.Time) *Tail {
+	return &Tail{
+		searcher: searcher,
+		index:    index,
+		query:    query,
+		start:    start,
+		now:      time.Now,
+		PageSize: DefaultPageSize,
+		Lag:      DefaultLag,
+	}
+}
+
+// tailSort orders alerts by @timestamp, then by the id Wazuh gives every
+// alert. The document id is not used as sorting on _id needs fielddata,
+// which is deprecated.
+func tailSort(order string) []any {
+	return []any{
+		map[string]any{"@timestamp": order},
+		map[string]any{"id": order},
+	}
+}
+
+// filter restricts the query to alerts from since (when set) that are at
+// least Lag old.
+func (t *Tail) filter(since time.Time) map[string]any {
+	return map[string]any{"bool": map[string]any{"filter": []any{
+		t.query,
+		timeRangeOf("@timestamp", since, t.now().Add(-t.Lag)),
+	}}}
+}
+
+// Last returns up to n of the newest matching alerts, oldest first. Next
+// continues after the newest of them.
+func (t *Tail) Last(n int) ([]opensearch.SearchHit, error) {
+	if n < 1 {
+		return nil, nil
+	}
+
+	resp, err := t.searcher.Search(t.index, map[string]any{
+		"size":  n,
+		"sort":  tailSort("desc"),
+		"query": t.filter(time.Time{}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hits := resp.Hits.Hits
+	slices.Reverse(hits)
+	if len(hits) > 0 {
+		t.after = hits[len(hits)-1].Sort
+	}
+	return hits, nil
+}
+
+// Next returns the next page of alerts after the last one returned, oldest
+// first. more is set when the page was full and another may follow at once.
+func (t *Tail) Next() (hits []opensearch.SearchHit, more bool, err error) {
+	body := map[string]any{
+		"size": t.PageSize,
+		"sort": tailSort("asc"),
+	}
+	if t.after != nil {
+		body["query"] = t.filter(time.Time{})
+		body["search_after"] = t.after
+	} else {
+		body["query"] = t.filter(t.start)
+	}
+
+	resp, err := t.searcher.Search(t.index, body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	hits = resp.Hits.Hits
+	if len(hits) > 0 {
+		t.after = hits[len(hits)-1].Sort
+	}
+	return hits, len(hits) == t.PageSize, nil
+}
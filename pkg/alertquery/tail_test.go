@@ -0,0 +1,172 @@
+package alertquery
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+)
+
+// fakeIndex answers tail and export searches over docs, honouring size, sort
+// order, search_after and the @timestamp range of tail requests.
+type fakeIndex struct {
+	docs     []opensearch.SearchHit
+	requests int
+}
+
+func doc(ts float64, id string) opensearch.SearchHit {
+	return opensearch.SearchHit{Id: id, Sort: []any{ts, id}}
+}
+
+func compareSort(a, b []any) int {
+	if c := cmp.Compare(a[0].(float64), b[0].(float64)); c != 0 {
+		return c
+	}
+	return cmp.Compare(a[1].(string), b[1].(string))
+}
+
+func (f *fakeIndex) Search(index string, query any) (*opensearch.SearchResponse, error) {
+	f.requests++
+	body := query.(map[string]any)
+	desc := body["sort"].([]any)[0].(map[string]any)["@timestamp"] == "desc"
+
+	docs := slices.Clone(f.docs)
+	slices.SortFunc(docs, func(a, b opensearch.SearchHit) int { return compareSort(a.Sort, b.Sort) })
+	if desc {
+		slices.Reverse(docs)
+	}
+
+	var hits []opensearch.SearchHit
+	for _, d := range docs {
+		if after, ok := body["search_after"].([]any); ok && compareSort(d.Sort, after) <= 0 {
+			continue
+		}
+		if !inRange(body, d.Sort[0].(float64)) {
+			continue
+		}
+		if len(hits) < body["size"].(int) {
+			hits = append(hits, d)
+		}
+	}
+
+	resp := &opensearch.SearchResponse{}
+	resp.Hits.Hits = hits
+	return resp, nil
+}
+
+// inRange reports whether an alert at ts is within the @timestamp range a
+// tail adds to its query.
+func inRange(body map[string]any, ts float64) bool {
+	b, ok := body["query"].(map[string]any)["bool"].(map[string]any)
+	if !ok {
+		return true
+	}
+	filters := b["filter"].([]any)
+	bounds := filters[1].(map[string]any)["range"].(map[string]any)["@timestamp"].(map[string]any)
+	if gte, ok := bounds["gte"].(int64); ok && ts < float64(gte) {
+		return false
+	}
+	if lt, ok := bounds["lt"].(int64); ok && ts >= float64(lt) {
+		return false
+	}
+	return true
+}
+
+// clock returns a tail clock reading ms milliseconds since the epoch.
+func clock(ms *int64) func() time.Time {
+	return func() time.Time { return time.UnixMilli(*ms) }
+}
+
+func ids(hits []opensearch.SearchHit) []string {
+	var out []string
+	for _, h := range hits {
+		out = append(out, h.Id)
+	}
+	return out
+}
+
+func TestTail(t *testing.T) {
+	index := &fakeIndex{docs: []opensearch.SearchHit{doc(1000, "a"), doc(2000, "b"), doc(2000, "c")}}
+	tail := NewTail(index, "wazuh-alerts-*", map[string]any{"match_all": map[string]any{}}, time.UnixMilli(0))
+	tail.PageSize = 2
+	now := int64(20000)
+	tail.now = clock(&now)
+
+	last, err := tail.Last(2)
+	if err != nil {
+		t.Fatalf("Last() error = %v", err)
+	}
+	if got := ids(last); !slices.Equal(got, []string{"b", "c"}) {
+		t.Errorf("Last(2) = %v, want [b c]", got)
+	}
+
+	// Alerts indexed since, including one sharing the timestamp of the last
+	// alert returned, are returned once each
+	index.docs = append(index.docs, doc(2000, "d"), doc(3000, "e"), doc(3000, "f"))
+	var followed []string
+	for {
+		hits, more, err := tail.Next()
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		followed = append(followed, ids(hits)...)
+		if !more {
+			break
+		}
+	}
+	if want := []string{"d", "e", "f"}; !slices.Equal(followed, want) {
+		t.Errorf("Next() returned %v, want %v", followed, want)
+	}
+
+	if hits, more, _ := tail.Next(); len(hits) != 0 || more {
+		t.Errorf("Next() with nothing new = %v, %v", ids(hits), more)
+	}
+}
+
+func TestTailFromStart(t *testing.T) {
+	index := &fakeIndex{docs: []opensearch.SearchHit{doc(1000, "old"), doc(5000, "new")}}
+	tail := NewTail(index, "wazuh-alerts-*", map[string]any{"match_all": map[string]any{}}, time.UnixMilli(4000))
+	now := int64(20000)
+	tail.now = clock(&now)
+
+	if last, _ := tail.Last(0); last != nil {
+		t.Errorf("Last(0) = %v", ids(last))
+	}
+	hits, _, err := tail.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if got := ids(hits); !slices.Equal(got, []string{"new"}) {
+		t.Errorf("Next() = %v, want alerts from the start time only", got)
+	}
+}
+
+func TestTailLateAlert(t *testing.T) {
+	index := &fakeIndex{docs: []opensearch.SearchHit{doc(5000, "a"), doc(7000, "b"), doc(9000, "c")}}
+	tail := NewTail(index, "wazuh-alerts-*", map[string]any{"match_all": map[string]any{}}, time.UnixMilli(0))
+	tail.Lag = 2 * time.Second
+	now := int64(10000)
+	tail.now = clock(&now)
+
+	hits, _, err := tail.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if got := ids(hits); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Next() = %v, want alerts older than the lag only", got)
+	}
+
+	// an alert stamped before c reaches the indexer after c; it is still
+	// returned, in order, as neither was read yet
+	index.docs = append(index.docs, doc(8500, "late"))
+	now = 12000
+	hits, _, err = tail.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if got := ids(hits); !slices.Equal(got, []string{"late", "c"}) {
+		t.Errorf("Next() = %v, want [late c]", got)
+	}
+}
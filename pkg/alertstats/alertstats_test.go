@@ -0,0 +1,139 @@
+package alertstats
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAutoInterval(t *testing.T) {
+	tests := []struct {
+		span time.Duration
+		want time.Duration
+	}{
+		{30 * time.Minute, time.Minute},
+		{time.Hour, time.Minute},
+		{2 * time.Hour, 5 * time.Minute},
+		{24 * time.Hour, 30 * time.Minute},
+		{7 * 24 * time.Hour, 3 * time.Hour},
+		{30 * 24 * time.Hour, 12 * time.Hour},
+		{31 * 24 * time.Hour, 24 * time.Hour},
+		{10 * 365 * 24 * time.Hour, 30 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := AutoInterval(tt.span); got != tt.want {
+			t.Errorf("AutoInterval(%s) = %s, want %s", tt.span, got, tt.want)
+		}
+	}
+}
+
+func TestFixedInterval(t *testing.T) {
+	tests := map[time.Duration]string{
+		7 * 24 * time.Hour:      "7d",
+		3 * time.Hour:           "3h",
+		90 * time.Minute:        "90m",
+		45 * time.Second:        "45s",
+		1500 * time.Millisecond: "1500ms",
+	}
+	for d, want := range tests {
+		if got := FixedInterval(d); got != want {
+			t.Errorf("FixedInterval(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		counts []int
+		want   string
+	}{
+		{nil, ""},
+		{[]int{0, 0}, "▁▁"},
+		{[]int{0, 1, 1000}, "▁▂█"},
+		{[]int{1, 2, 3, 4, 5, 6}, "▃▄▅▆▇█"},
+	}
+	for _, tt := range tests {
+		if got := Sparkline(tt.counts); got != tt.want {
+			t.Errorf("Sparkline(%v) = %q, want %q", tt.counts, got, tt.want)
+		}
+	}
+}
+
+func TestAggregationBounds(t *testing.T) {
+	since := time.UnixMilli(1748736000000)
+	aggs := Aggregation(5, Window{Since: since, Until: since.Add(time.Hour), Interval: 5 * time.Minute})
+
+	for _, h := range []any{
+		aggs["timeline"],
+		aggs["rules"].(map[string]any)["aggs"].(map[string]any)["trend"],
+		aggs["agents"].(map[string]any)["aggs"].(map[string]any)["trend"],
+	} {
+		hist := h.(map[string]any)["date_histogram"].(map[string]any)
+		bounds := hist["extended_bounds"].(map[string]any)
+		if hist["fixed_interval"] != "5m" || bounds["min"] != int64(1748736000000) || bounds["max"] != int64(1748739599999) {
+			t.Errorf("histogram = %v, want 5m buckets covering the window", hist)
+		}
+	}
+
+	open := Aggregation(5, Window{Interval: time.Hour})["timeline"].(map[string]any)["date_histogram"].(map[string]any)
+	if _, ok := open["extended_bounds"]; ok {
+		t.Errorf("open window histogram has bounds: %v", open)
+	}
+}
+
+func TestParse(t *testing.T) {
+	raw := `{
+		"timeline": {"buckets": [
+			{"key": 1748736000000, "doc_count": 3},
+			{"key": 1748739600000, "doc_count": 0},
+			{"key": 1748743200000, "doc_count": 7}
+		]},
+		"rules": {"buckets": [{
+			"key": "5710", "doc_count": 8,
+			"trend": {"buckets": [{"key": 1748736000000, "doc_count": 2}, {"key": 1748739600000, "doc_count": 0}, {"key": 1748743200000, "doc_count": 6}]},
+			"rule": {"hits": {"hits": [{"_id": "a", "_source": {"rule": {"level": 5, "description": "sshd: invalid user"}}}]}}
+		}]},
+		"agents": {"buckets": [{
+			"key": "web-1", "doc_count": 10,
+			"trend": {"buckets": [{"key": 1748736000000, "doc_count": 3}, {"key": 1748739600000, "doc_count": 0}, {"key": 1748743200000, "doc_count": 7}]}
+		}]},
+		"levels": {"buckets": [{"key": 3, "doc_count": 2}, {"key": 12, "doc_count": 1}, {"key": 5, "doc_count": 7}]},
+		"mitre": {"buckets": [{
+			"key": "T1110", "doc_count": 8,
+			"technique": {"hits": {"hits": [{"_id": "a", "_source": {"rule": {"mitre": {"id": ["T1021", "T1110"], "technique": ["Remote Services", "Brute Force"]}}}}]}}
+		}]}
+	}`
+
+	stats, err := Parse(json.RawMessage(raw), 10, time.Hour)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if stats.Total != 10 || stats.Interval != "1h" || len(stats.Timeline) != 3 {
+		t.Fatalf("Parse() = %+v", stats)
+	}
+	if got := stats.Timeline[2]; got.Count != 7 || !got.Time.Equal(time.UnixMilli(1748743200000)) {
+		t.Errorf("Timeline[2] = %+v", got)
+	}
+
+	rule := stats.Rules[0]
+	if rule.Value != "5710" || rule.Level != 5 || rule.Name != "sshd: invalid user" || rule.Share != 0.8 || !slices.Equal(rule.Trend, []int{2, 0, 6}) {
+		t.Errorf("Rules[0] = %+v", rule)
+	}
+	if agent := stats.Agents[0]; agent.Value != "web-1" || !slices.Equal(agent.Trend, []int{3, 0, 7}) {
+		t.Errorf("Agents[0] = %+v", agent)
+	}
+
+	var levels []int
+	for _, l := range stats.Levels {
+		levels = append(levels, l.Level)
+	}
+	if !slices.Equal(levels, []int{12, 5, 3}) {
+		t.Errorf("Levels = %v, want highest first", levels)
+	}
+
+	if m := stats.Mitre[0]; m.Value != "T1110" || m.Name != "Brute Force" {
+		t.Errorf("Mitre[0] = %+v, want the technique name matching the id", m)
+	}
+}
@@ -0,0 +1,25 @@
+package alertstats
+
+import "strings"
+
+var ticks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws counts as a line of block characters scaled to the largest
+// count. Only empty intervals are drawn at the lowest height, so a single
+// alert is never hidden among many.
+func Sparkline(counts []int) string {
+	peak := 0
+	for _, c := range counts {
+		peak = max(peak, c)
+	}
+
+	var b strings.Builder
+	for _, c := range counts {
+		if c <= 0 {
+			b.WriteRune(ticks[0])
+			continue
+		}
+		b.WriteRune(ticks[1+c*(len(ticks)-2)/peak])
+	}
+	return b.String()
+}
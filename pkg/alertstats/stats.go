@@ -0,0 +1,267 @@
+package alertstats
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+)
+
+// MaxBuckets is the most intervals a window may be split into.
+const MaxBuckets = 200
+
+// intervals are the histogram intervals picked by AutoInterval.
+var intervals = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	3 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+	7 * 24 * time.Hour,
+	30 * 24 * time.Hour,
+}
+
+// Window is the time range the statistics cover and the interval alerts are
+// counted in over time. A zero Since leaves the start of the histogram to
+// the first alert.
+type Window struct {
+	Since    time.Time
+	Until    time.Time
+	Interval time.Duration
+}
+
+// AutoInterval returns the shortest interval splitting span into at most 60
+// buckets.
+func AutoInterval(span time.Duration) time.Duration {
+	for _, d := range intervals {
+		if span/d <= 60 {
+			return d
+		}
+	}
+	return intervals[len(intervals)-1]
+}
+
+// FixedInterval formats d as an OpenSearch fixed_interval in its largest
+// whole unit.
+func FixedInterval(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	case d%time.Second == 0:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+// Count is the number of alerts sharing a value of a field.
+type Count struct {
+	Value string `json:"value"`
+	// Rule description or technique name, when known
+	Name string `json:"name,omitempty"`
+	// Level of a rule
+	Level int     `json:"level,omitempty"`
+	Count int     `json:"count"`
+	Share float64 `json:"share"`
+	// Alerts in each interval of the timeline
+	Trend []int `json:"trend,omitempty"`
+}
+
+// Bucket is the number of alerts raised in the interval starting at Time.
+type Bucket struct {
+	Time  time.Time `json:"time"`
+	Count int       `json:"count"`
+}
+
+// Stats summarises the alerts matching a search.
+type Stats struct {
+	Total    int      `json:"total"`
+	Interval string   `json:"interval"`
+	Timeline []Bucket `json:"timeline"`
+	Rules    []Count  `json:"rules"`
+	Agents   []Count  `json:"agents"`
+	// Every level alerted at, highest first
+	Levels []Count `json:"levels"`
+	Mitre  []Count `json:"mitre"`
+}
+
+// Aggregation returns the aggregations for the top rules, agents and MITRE
+// techniques, the level distribution and the alerts over time, with a trend
+// over the same intervals for each rule and agent.
+func Aggregation(top int, w Window) map[string]any {
+	histogram := func() map[string]any {
+		h := map[string]any{
+			"field":          "timestamp",
+			"fixed_interval": FixedInterval(w.Interval),
+			"min_doc_count":  0,
+		}
+		bounds := map[string]any{}
+		if !w.Since.IsZero() {
+			bounds["min"] = w.Since.UnixMilli()
+		}
+		if !w.Until.IsZero() {
+			bounds["max"] = w.Until.UnixMilli() - 1
+		}
+		if len(bounds) > 0 {
+			h["extended_bounds"] = bounds
+		}
+		return map[string]any{"date_histogram": h}
+	}
+
+	rules := terms("rule.id", top)
+	rules["aggs"] = map[string]any{
+		"trend": histogram(),
+		"rule": map[string]any{"top_hits": map[string]any{
+			"size":    1,
+			"sort":    []any{map[string]any{"timestamp": "desc"}},
+			"_source": []string{"rule.description", "rule.level"},
+		}},
+	}
+	agents := terms("agent.name", top)
+	agents["aggs"] = map[string]any{"trend": histogram()}
+	mitre := terms("rule.mitre.id", top)
+	mitre["aggs"] = map[string]any{
+		"technique": map[string]any{"top_hits": map[string]any{
+			"size":    1,
+			"_source": []string{"rule.mitre"},
+		}},
+	}
+
+	return map[string]any{
+		"timeline": histogram(),
+		"rules":    rules,
+		"agents":   agents,
+		"levels":   terms("rule.level", 16),
+		"mitre":    mitre,
+	}
+}
+
+func terms(field string, size int) map[string]any {
+	return map[string]any{"terms": map[string]any{"field": field, "size": size}}
+}
+
+type histogramAgg struct {
+	Buckets []struct {
+		Key      int64 `json:"key"`
+		DocCount int   `json:"doc_count"`
+	} `json:"buckets"`
+}
+
+func (h histogramAgg) counts() []int {
+	var out []int
+	for _, b := range h.Buckets {
+		out = append(out, b.DocCount)
+	}
+	return out
+}
+
+type topHitsAgg struct {
+	Hits struct {
+		Hits []opensearch.SearchHit `json:"hits"`
+	} `json:"hits"`
+}
+
+// alert decodes the single hit of the aggregation, or returns nil.
+func (t topHitsAgg) alert() (*opensearch.Alert, error) {
+	if len(t.Hits.Hits) == 0 {
+		return nil, nil
+	}
+	return t.Hits.Hits[0].Alert()
+}
+
+// termBucket is a terms bucket with the sub-aggregations of Aggregation.
+type termBucket struct {
+	opensearch.TermBucket
+	Trend     histogramAgg `json:"trend"`
+	Rule      topHitsAgg   `json:"rule"`
+	Technique topHitsAgg   `json:"technique"`
+}
+
+// Parse decodes the aggregations of a search built with Aggregation. total
+// is the number of alerts the search matched.
+func Parse(aggregations json.RawMessage, total int, interval time.Duration) (*Stats, error) {
+	var aggs struct {
+		Timeline histogramAgg `json:"timeline"`
+		Rules    struct {
+			Buckets []termBucket `json:"buckets"`
+		} `json:"rules"`
+		Agents struct {
+			Buckets []termBucket `json:"buckets"`
+		} `json:"agents"`
+		Levels struct {
+			Buckets []termBucket `json:"buckets"`
+		} `json:"levels"`
+		Mitre struct {
+			Buckets []termBucket `json:"buckets"`
+		} `json:"mitre"`
+	}
+	if err := json.Unmarshal(aggregations, &aggs); err != nil {
+		return nil, fmt.Errorf("decoding alert statistics: %w", err)
+	}
+
+	stats := &Stats{Total: total, Interval: FixedInterval(interval)}
+	for _, b := range aggs.Timeline.Buckets {
+		stats.Timeline = append(stats.Timeline, Bucket{Time: time.UnixMilli(b.Key).UTC(), Count: b.DocCount})
+	}
+
+	count := func(b termBucket) Count {
+		c := Count{Value: b.String(), Count: b.DocCount}
+		if total > 0 {
+			c.Share = float64(b.DocCount) / float64(total)
+		}
+		return c
+	}
+
+	for _, b := range aggs.Rules.Buckets {
+		c := count(b)
+		c.Trend = b.Trend.counts()
+		alert, err := b.Rule.alert()
+		if err != nil {
+			return nil, err
+		}
+		if alert != nil {
+			c.Name, c.Level = alert.Rule.Description, alert.Rule.Level
+		}
+		stats.Rules = append(stats.Rules, c)
+	}
+
+	for _, b := range aggs.Agents.Buckets {
+		c := count(b)
+		c.Trend = b.Trend.counts()
+		stats.Agents = append(stats.Agents, c)
+	}
+
+	for _, b := range aggs.Levels.Buckets {
+		c := count(b)
+		c.Level, _ = strconv.Atoi(c.Value)
+		stats.Levels = append(stats.Levels, c)
+	}
+	slices.SortFunc(stats.Levels, func(a, b Count) int { return b.Level - a.Level })
+
+	for _, b := range aggs.Mitre.Buckets {
+		c := count(b)
+		alert, err := b.Technique.alert()
+		if err != nil {
+			return nil, err
+		}
+		if alert != nil {
+			// technique names are listed in the same order as the ids
+			if i := slices.Index(alert.Rule.Mitre.Id, c.Value); i >= 0 && i < len(alert.Rule.Mitre.Technique) {
+				c.Name = alert.Rule.Mitre.Technique[i]
+			}
+		}
+		stats.Mitre = append(stats.Mitre, c)
+	}
+
+	return stats, nil
+}
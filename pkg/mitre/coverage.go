@@ -0,0 +1,148 @@
+package mitre
+
+import (
+	"sort"
+	"strings"
+)
+
+// Tactic is an ATT&CK tactic as known by the manager.
+type Tactic struct {
+	Id         string `json:"id"`
+	ExternalId string `json:"external_id"`
+	Name       string `json:"name"`
+	ShortName  string `json:"short_name"`
+}
+
+// Technique is an ATT&CK technique as known by the manager.
+type Technique struct {
+	Id         string   `json:"id"`
+	ExternalId string   `json:"external_id"`
+	Name       string   `json:"name"`
+	Tactics    []string `json:"tactics"`
+	Deprecated int      `json:"deprecated"`
+}
+
+// tacticOrder is the column order of the enterprise ATT&CK matrix.
+var tacticOrder = []string{
+	"reconnaissance",
+	"resource-development",
+	"initial-access",
+	"execution",
+	"persistence",
+	"privilege-escalation",
+	"defense-evasion",
+	"credential-access",
+	"discovery",
+	"lateral-movement",
+	"collection",
+	"command-and-control",
+	"exfiltration",
+	"impact",
+}
+
+// TechniqueCoverage is the detection state of a single technique.
+type TechniqueCoverage struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	// Active rules referencing the technique
+	Rules []string `json:"rules"`
+	// Alerts raised for the technique, when weighting by alert counts
+	Alerts int `json:"alerts"`
+}
+
+// TacticCoverage groups technique coverage under a tactic.
+type TacticCoverage struct {
+	Id         string              `json:"id"`
+	Name       string              `json:"name"`
+	ShortName  string              `json:"short_name"`
+	Covered    int                 `json:"covered"`
+	Techniques []TechniqueCoverage `json:"techniques"`
+}
+
+// Report is the coverage of the ATT&CK matrix by active rules.
+type Report struct {
+	Tactics []TacticCoverage `json:"tactics"`
+	// Technique ids referenced by rules but unknown to the manager
+	Unknown []string `json:"unknown"`
+	// Distinct techniques in the matrix and how many are covered
+	Techniques int `json:"techniques"`
+	Covered    int `json:"covered"`
+}
+
+// Coverage pairs the manager's techniques with the technique ids referenced
+// by rules. ruleRefs maps technique ids to rule ids and alertCounts, which
+// may be nil, maps technique ids to alert counts.
+func Coverage(tactics []Tactic, techniques []Technique, ruleRefs map[string][]string, alertCounts map[string]int) Report {
+	byRef := make(map[string]*Tactic)
+	for i := range tactics {
+		t := &tactics[i]
+		byRef[t.Id] = t
+		byRef[t.ExternalId] = t
+		byRef[t.ShortName] = t
+	}
+
+	grouped := make(map[string]*TacticCoverage)
+	known := make(map[string]bool)
+	covered := make(map[string]bool)
+	for _, tech := range techniques {
+		if tech.Deprecated != 0 || tech.ExternalId == "" {
+			continue
+		}
+		known[tech.ExternalId] = true
+
+		tc := TechniqueCoverage{
+			Id:     tech.ExternalId,
+			Name:   tech.Name,
+			Rules:  ruleRefs[tech.ExternalId],
+			Alerts: alertCounts[tech.ExternalId],
+		}
+		if len(tc.Rules) > 0 {
+			covered[tech.ExternalId] = true
+		}
+
+		for _, ref := range tech.Tactics {
+			tactic, ok := byRef[ref]
+			if !ok {
+				continue
+			}
+			group, ok := grouped[tactic.ShortName]
+			if !ok {
+				group = &TacticCoverage{Id: tactic.ExternalId, Name: tactic.Name, ShortName: tactic.ShortName}
+				grouped[tactic.ShortName] = group
+			}
+			group.Techniques = append(group.Techniques, tc)
+			if len(tc.Rules) > 0 {
+				group.Covered++
+			}
+		}
+	}
+
+	report := Report{Unknown: []string{}, Techniques: len(known), Covered: len(covered)}
+	for _, group := range grouped {
+		sort.Slice(group.Techniques, func(i, j int) bool {
+			return group.Techniques[i].Id < group.Techniques[j].Id
+		})
+		report.Tactics = append(report.Tactics, *group)
+	}
+	sort.Slice(report.Tactics, func(i, j int) bool {
+		return tacticRank(report.Tactics[i].ShortName) < tacticRank(report.Tactics[j].ShortName)
+	})
+
+	for id := range ruleRefs {
+		if !known[id] {
+			report.Unknown = append(report.Unknown, id)
+		}
+	}
+	sort.Strings(report.Unknown)
+
+	return report
+}
+
+func tacticRank(shortName string) int {
+	for i, name := range tacticOrder {
+		if strings.EqualFold(name, shortName) {
+			return i
+		}
+	}
+	return len(tacticOrder)
+}
@@ -0,0 +1,92 @@
+package mitre
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layer is an ATT&CK Navigator layer document.
+type Layer struct {
+	Name        string           `json:"name"`
+	Versions    LayerVersions    `json:"versions"`
+	Domain      string           `json:"domain"`
+	Description string           `json:"description"`
+	Techniques  []LayerTechnique `json:"techniques"`
+	Gradient    LayerGradient    `json:"gradient"`
+	Legend      []LayerLegend    `json:"legendItems"`
+}
+
+type LayerVersions struct {
+	Attack    string `json:"attack"`
+	Navigator string `json:"navigator"`
+	Layer     string `json:"layer"`
+}
+
+type LayerTechnique struct {
+	TechniqueId string `json:"techniqueID"`
+	Tactic      string `json:"tactic,omitempty"`
+	Score       int    `json:"score"`
+	Comment     string `json:"comment,omitempty"`
+	Enabled     bool   `json:"enabled"`
+}
+
+type LayerGradient struct {
+	Colors   []string `json:"colors"`
+	MinValue int      `json:"minValue"`
+	MaxValue int      `json:"maxValue"`
+}
+
+type LayerLegend struct {
+	Label string `json:"label"`
+	Color string `json:"color"`
+}
+
+// NewLayer builds a Navigator layer from report. Covered techniques are
+// scored by the number of rules referencing them or, when weighted is set,
+// by their alert counts.
+func NewLayer(name string, report Report, weighted bool) Layer {
+	layer := Layer{
+		Name:        name,
+		Versions:    LayerVersions{Attack: "15", Navigator: "5.0.0", Layer: "4.5"},
+		Domain:      "enterprise-attack",
+		Description: "Technique coverage of active Wazuh rules, generated by wazctl",
+		Gradient: LayerGradient{
+			Colors: []string{"#ffe766", "#8ec843"},
+		},
+		Legend: []LayerLegend{
+			{Label: "not covered", Color: "#ffffff"},
+			{Label: "covered", Color: "#8ec843"},
+		},
+	}
+
+	for _, tactic := range report.Tactics {
+		for _, tech := range tactic.Techniques {
+			if len(tech.Rules) == 0 {
+				continue
+			}
+
+			score := len(tech.Rules)
+			comment := fmt.Sprintf("rules: %s", strings.Join(tech.Rules, ", "))
+			if weighted {
+				score = tech.Alerts
+				comment = fmt.Sprintf("%s; alerts: %d", comment, tech.Alerts)
+			}
+			if score > layer.Gradient.MaxValue {
+				layer.Gradient.MaxValue = score
+			}
+
+			layer.Techniques = append(layer.Techniques, LayerTechnique{
+				TechniqueId: tech.Id,
+				Tactic:      tactic.ShortName,
+				Score:       score,
+				Comment:     comment,
+				Enabled:     true,
+			})
+		}
+	}
+	if layer.Gradient.MaxValue == 0 {
+		layer.Gradient.MaxValue = 1
+	}
+
+	return layer
+}
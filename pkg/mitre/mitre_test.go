@@ -0,0 +1,62 @@
+package mitre
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	tactics := []Tactic{
+		{Id: "x-mitre-tactic--1", ExternalId: "TA0006", Name: "Credential Access", ShortName: "credential-access"},
+		{Id: "x-mitre-tactic--2", ExternalId: "TA0001", Name: "Initial Access", ShortName: "initial-access"},
+	}
+	techniques := []Technique{
+		{ExternalId: "T1110", Name: "Brute Force", Tactics: []string{"TA0006"}},
+		{ExternalId: "T1078", Name: "Valid Accounts", Tactics: []string{"TA0001", "x-mitre-tactic--1"}},
+		{ExternalId: "T1003", Name: "OS Credential Dumping", Tactics: []string{"TA0006"}},
+		{ExternalId: "T9999", Name: "Old", Tactics: []string{"TA0006"}, Deprecated: 1},
+	}
+	refs := map[string][]string{
+		"T1110": {"5712", "100010"},
+		"T1078": {"100020"},
+		"T0000": {"100030"},
+	}
+	counts := map[string]int{"T1110": 42}
+
+	report := Coverage(tactics, techniques, refs, counts)
+
+	if report.Techniques != 3 || report.Covered != 2 {
+		t.Errorf("Coverage() techniques/covered = %d/%d, want 3/2", report.Techniques, report.Covered)
+	}
+	if want := []string{"T0000"}; !reflect.DeepEqual(report.Unknown, want) {
+		t.Errorf("Coverage() unknown = %v, want %v", report.Unknown, want)
+	}
+
+	var order []string
+	for _, tactic := range report.Tactics {
+		order = append(order, tactic.ShortName)
+	}
+	if want := []string{"initial-access", "credential-access"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Coverage() tactic order = %v, want %v", order, want)
+	}
+	if got := report.Tactics[1]; got.Covered != 2 || len(got.Techniques) != 3 {
+		t.Errorf("credential-access coverage = %d of %d, want 2 of 3", got.Covered, len(got.Techniques))
+	}
+
+	layer := NewLayer("test", report, true)
+	scores := make(map[string]int)
+	for _, tech := range layer.Techniques {
+		scores[tech.Tactic+"/"+tech.TechniqueId] = tech.Score
+	}
+	wantScores := map[string]int{
+		"initial-access/T1078":    0,
+		"credential-access/T1078": 0,
+		"credential-access/T1110": 42,
+	}
+	if !reflect.DeepEqual(scores, wantScores) {
+		t.Errorf("NewLayer() scores = %v, want %v", scores, wantScores)
+	}
+	if layer.Gradient.MaxValue != 42 {
+		t.Errorf("NewLayer() gradient max = %d, want 42", layer.Gradient.MaxValue)
+	}
+}
@@ -0,0 +1,132 @@
+// Package mutate produces variations of log lines that an attacker could
+// trivially cause, to find detections that depend on exact formatting.
+package mutate
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Mutator rewrites a log line. Apply reports false when the mutation does
+// not apply to the line, for example when it has no IPv4 address.
+type Mutator struct {
+	Name        string
+	Description string
+	Apply       func(string) (string, bool)
+}
+
+// Mutators is every available mutator in report order.
+var Mutators = []Mutator{
+	{Name: "uppercase", Description: "upper-case the whole line", Apply: changed(strings.ToUpper)},
+	{Name: "lowercase", Description: "lower-case the whole line", Apply: changed(strings.ToLower)},
+	{Name: "whitespace", Description: "double every space", Apply: changed(func(s string) string {
+		return strings.ReplaceAll(s, " ", "  ")
+	})},
+	{Name: "reorder-kv", Description: "reverse the order of key=value pairs", Apply: reorderKeyValues},
+	{Name: "ipv6", Description: "replace IPv4 addresses with IPv6 addresses", Apply: ipv4ToIpv6},
+	{Name: "homoglyph", Description: "swap Latin letters for Cyrillic lookalikes", Apply: changed(homoglyphs)},
+	{Name: "truncate", Description: "cut the line in half", Apply: truncate},
+}
+
+// Find returns the mutators with the given names, or every mutator when
+// names is empty.
+func Find(names []string) ([]Mutator, error) {
+	if len(names) == 0 {
+		return Mutators, nil
+	}
+	var found []Mutator
+	for _, name := range names {
+		ok := false
+		for _, m := range Mutators {
+			if m.Name == name {
+				found = append(found, m)
+				ok = true
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown mutator %q. Must be one of %v", name, Names())
+		}
+	}
+	return found, nil
+}
+
+// Names returns the names of every mutator.
+func Names() []string {
+	names := make([]string, len(Mutators))
+	for i, m := range Mutators {
+		names[i] = m.Name
+	}
+	return names
+}
+
+// changed wraps a rewrite that applies whenever it changes the line.
+func changed(fn func(string) string) func(string) (string, bool) {
+	return func(s string) (string, bool) {
+		out := fn(s)
+		return out, out != s
+	}
+}
+
+var keyValue = regexp.MustCompile(`[\w.-]+=("[^"]*"|[^\s,;]+)`)
+
+// reorderKeyValues keeps the positions of key=value pairs but reverses
+// which pair sits where.
+func reorderKeyValues(s string) (string, bool) {
+	locs := keyValue.FindAllStringIndex(s, -1)
+	if len(locs) < 2 {
+		return s, false
+	}
+
+	var b strings.Builder
+	last := 0
+	for i, loc := range locs {
+		src := locs[len(locs)-1-i]
+		b.WriteString(s[last:loc[0]])
+		b.WriteString(s[src[0]:src[1]])
+		last = loc[1]
+	}
+	b.WriteString(s[last:])
+
+	out := b.String()
+	return out, out != s
+}
+
+var ipv4 = regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}\b`)
+
+// ipv4ToIpv6 maps every IPv4 address onto a documentation-range IPv6
+// address, so the same source keeps the same replacement.
+func ipv4ToIpv6(s string) (string, bool) {
+	found := false
+	out := ipv4.ReplaceAllStringFunc(s, func(addr string) string {
+		ip := net.ParseIP(addr).To4()
+		if ip == nil {
+			return addr
+		}
+		found = true
+		return fmt.Sprintf("2001:db8::%x:%x", uint16(ip[0])<<8|uint16(ip[1]), uint16(ip[2])<<8|uint16(ip[3]))
+	})
+	return out, found
+}
+
+// homoglyphMap pairs Latin letters with Cyrillic letters that render the same.
+var homoglyphMap = strings.NewReplacer(
+	"a", "а", "c", "с", "e", "е", "o", "о", "p", "р", "x", "х", "y", "у",
+	"A", "А", "B", "В", "C", "С", "E", "Е", "H", "Н", "K", "К", "M", "М",
+	"O", "О", "P", "Р", "T", "Т", "X", "Х",
+)
+
+func homoglyphs(s string) string {
+	return homoglyphMap.Replace(s)
+}
+
+// truncate keeps the first half of the line, never splitting a character.
+func truncate(s string) (string, bool) {
+	n := utf8.RuneCountInString(s)
+	if n < 2 {
+		return s, false
+	}
+	return string([]rune(s)[:n/2]), true
+}
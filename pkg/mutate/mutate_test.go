@@ -0,0 +1,52 @@
+package mutate
+
+import (
+	"testing"
+)
+
+func TestMutators(t *testing.T) {
+	line := `Oct 19 10:00:00 fw kernel: action=deny src=10.0.0.5 dst=192.168.1.1 proto=tcp`
+
+	tests := []struct {
+		mutator   string
+		input     string
+		want      string
+		wantApply bool
+	}{
+		{mutator: "uppercase", input: "Failed password", want: "FAILED PASSWORD", wantApply: true},
+		{mutator: "lowercase", input: "already lower", want: "already lower", wantApply: false},
+		{mutator: "whitespace", input: "a b", want: "a  b", wantApply: true},
+		{
+			mutator:   "reorder-kv",
+			input:     line,
+			want:      `Oct 19 10:00:00 fw kernel: proto=tcp dst=192.168.1.1 src=10.0.0.5 action=deny`,
+			wantApply: true,
+		},
+		{mutator: "reorder-kv", input: "user=root", want: "user=root", wantApply: false},
+		{mutator: "ipv6", input: "from 10.0.0.5 port 22", want: "from 2001:db8::a00:5 port 22", wantApply: true},
+		{mutator: "ipv6", input: "no address", want: "no address", wantApply: false},
+		{mutator: "homoglyph", input: "root", want: "r\u043e\u043et", wantApply: true},
+		{mutator: "truncate", input: "héllo!", want: "hél", wantApply: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mutator, func(t *testing.T) {
+			m, err := Find([]string{tt.mutator})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, applied := m[0].Apply(tt.input)
+			if got != tt.want || applied != tt.wantApply {
+				t.Errorf("%s(%q) = %q, %v, want %q, %v", tt.mutator, tt.input, got, applied, tt.want, tt.wantApply)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	if all, _ := Find(nil); len(all) != len(Mutators) {
+		t.Errorf("Find(nil) returned %d mutators, want all %d", len(all), len(Mutators))
+	}
+	if _, err := Find([]string{"rot13"}); err == nil {
+		t.Error("Find() accepted an unknown mutator")
+	}
+}
@@ -0,0 +1,195 @@
+package noise
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+)
+
+// DefaultFields are the alert fields whose most common values are reported
+// for every noisy rule.
+var DefaultFields = []string{
+	"location",
+	"data.srcip",
+	"data.srcuser",
+	"data.dstuser",
+	"data.url",
+	"data.win.eventdata.image",
+	"data.win.eventdata.targetUserName",
+	"syscheck.path",
+}
+
+// valuesPerField is how many values of each field are kept per rule.
+const valuesPerField = 3
+
+// Value is a common value of a field among the alerts of a rule.
+type Value struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+	Count int    `json:"count"`
+	// Fraction of the rule's alerts carrying the value
+	Share float64 `json:"share"`
+}
+
+// Rule is a rule ranked by the number of alerts it raised.
+type Rule struct {
+	Id          string `json:"id"`
+	Level       int    `json:"level"`
+	Description string `json:"description"`
+	Count       int    `json:"count"`
+	// Fraction of all alerts in the window raised by the rule
+	Share  float64 `json:"share"`
+	Agents []Value `json:"agents"`
+	// Common values of the requested fields, highest share first
+	Values []Value `json:"values"`
+}
+
+// TopValue returns the field value shared by the most alerts of the rule.
+func (r Rule) TopValue() (Value, bool) {
+	if len(r.Values) == 0 {
+		return Value{}, false
+	}
+	return r.Values[0], true
+}
+
+// TopDecodedValue returns the decoded (data.*) field value shared by the
+// most alerts of the rule, the value a suppression can match on. Fields such
+// as location usually carry a single value for every alert of a rule and
+// would otherwise always be picked.
+func (r Rule) TopDecodedValue() (Value, bool) {
+	for _, v := range r.Values {
+		if strings.HasPrefix(v.Field, "data.") {
+			return v, true
+		}
+	}
+	return Value{}, false
+}
+
+// Lookup returns the most common value of field, or the value equal to
+// value when it is set.
+func (r Rule) Lookup(field, value string) (Value, bool) {
+	for _, v := range r.Values {
+		if v.Field == field && (value == "" || v.Value == value) {
+			return v, true
+		}
+	}
+	return Value{}, false
+}
+
+// Aggregation returns the aggregations ranking the top rules by alert count
+// together with the most common agents and values of fields for each.
+func Aggregation(fields []string, top int) map[string]any {
+	sub := map[string]any{
+		"agents": terms("agent.name", valuesPerField),
+		"rule": map[string]any{"top_hits": map[string]any{
+			"size":    1,
+			"sort":    []any{map[string]any{"timestamp": "desc"}},
+			"_source": []string{"rule.description", "rule.level"},
+		}},
+	}
+	for i, field := range fields {
+		sub[fieldAgg(i)] = terms(field, valuesPerField)
+	}
+
+	rules := terms("rule.id", top)
+	rules["aggs"] = sub
+	return map[string]any{"rules": rules}
+}
+
+func terms(field string, size int) map[string]any {
+	return map[string]any{"terms": map[string]any{"field": field, "size": size}}
+}
+
+// fieldAgg names the aggregation of the i-th field; field names are not used
+// as they may contain characters aggregation names cannot.
+func fieldAgg(i int) string {
+	return fmt.Sprintf("field_%d", i)
+}
+
+// Parse decodes the aggregations of a search built with Aggregation. total
+// is the number of alerts the search matched.
+func Parse(aggregations json.RawMessage, fields []string, total int) ([]Rule, error) {
+	var aggs struct {
+		Rules struct {
+			Buckets []json.RawMessage `json:"buckets"`
+		} `json:"rules"`
+	}
+	if err := json.Unmarshal(aggregations, &aggs); err != nil {
+		return nil, fmt.Errorf("decoding rules aggregation: %w", err)
+	}
+
+	var rules []Rule
+	for _, b := range aggs.Rules.Buckets {
+		var bucket opensearch.TermBucket
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(b, &bucket); err != nil {
+			return nil, fmt.Errorf("decoding rules bucket: %w", err)
+		}
+		if err := json.Unmarshal(b, &raw); err != nil {
+			return nil, fmt.Errorf("decoding rules bucket: %w", err)
+		}
+
+		rule := Rule{Id: bucket.String(), Count: bucket.DocCount}
+		if total > 0 {
+			rule.Share = float64(bucket.DocCount) / float64(total)
+		}
+
+		var latest struct {
+			Hits struct {
+				Hits []opensearch.SearchHit `json:"hits"`
+			} `json:"hits"`
+		}
+		if err := json.Unmarshal(raw["rule"], &latest); err != nil {
+			return nil, fmt.Errorf("decoding rule %s: %w", rule.Id, err)
+		}
+		if len(latest.Hits.Hits) > 0 {
+			alert, err := latest.Hits.Hits[0].Alert()
+			if err != nil {
+				return nil, err
+			}
+			rule.Level, rule.Description = alert.Rule.Level, alert.Rule.Description
+		}
+
+		agents, err := values(raw["agents"], "agent.name", bucket.DocCount)
+		if err != nil {
+			return nil, err
+		}
+		rule.Agents = agents
+		for i, field := range fields {
+			found, err := values(raw[fieldAgg(i)], field, bucket.DocCount)
+			if err != nil {
+				return nil, err
+			}
+			rule.Values = append(rule.Values, found...)
+		}
+		sort.SliceStable(rule.Values, func(i, j int) bool { return rule.Values[i].Count > rule.Values[j].Count })
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// values decodes a terms aggregation of field within a rule of count alerts.
+func values(raw json.RawMessage, field string, count int) ([]Value, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	var agg opensearch.TermsAggregation
+	if err := json.Unmarshal(raw, &agg); err != nil {
+		return nil, fmt.Errorf("decoding %s aggregation: %w", field, err)
+	}
+
+	var out []Value
+	for _, b := range agg.Buckets {
+		v := Value{Field: field, Value: b.String(), Count: b.DocCount}
+		if count > 0 {
+			v.Share = float64(b.DocCount) / float64(count)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
@@ -0,0 +1,144 @@
+package noise
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/EpykLab/wazctl/internal/rulexml"
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+)
+
+const aggregations = `{
+  "rules": {
+    "buckets": [
+      {
+        "key": "5710",
+        "doc_count": 800,
+        "rule": {"hits": {"hits": [{"_id": "a1", "_source": {"rule": {"description": "sshd: Attempt to login using a non-existent user", "level": 5}}}]}},
+        "agents": {"buckets": [{"key": "bastion", "doc_count": 760}, {"key": "web-1", "doc_count": 40}]},
+        "field_0": {"buckets": [{"key": "10.0.0.5", "doc_count": 700}]},
+        "field_1": {"buckets": [{"key": "admin", "doc_count": 790}, {"key": "oracle", "doc_count": 10}]}
+      },
+      {
+        "key": 31101,
+        "doc_count": 200,
+        "rule": {"hits": {"hits": []}},
+        "agents": {"buckets": []},
+        "field_0": {"buckets": []},
+        "field_1": {"buckets": []}
+      }
+    ]
+  }
+}`
+
+func TestParse(t *testing.T) {
+	fields := []string{"data.srcip", "data.srcuser"}
+	rules, err := Parse(json.RawMessage(aggregations), fields, 1000)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("Parse() returned %d rules, want 2", len(rules))
+	}
+
+	noisy := rules[0]
+	if noisy.Id != "5710" || noisy.Level != 5 || noisy.Share != 0.8 || noisy.Agents[0].Value != "bastion" {
+		t.Errorf("rules[0] = %+v", noisy)
+	}
+	if top, ok := noisy.TopValue(); !ok || top.Field != "data.srcuser" || top.Value != "admin" || top.Count != 790 {
+		t.Errorf("TopValue() = %+v, %v", top, ok)
+	}
+	if v, ok := noisy.Lookup("data.srcip", ""); !ok || v.Value != "10.0.0.5" || v.Share != 0.875 {
+		t.Errorf("Lookup(data.srcip) = %+v, %v", v, ok)
+	}
+	if _, ok := noisy.Lookup("data.srcuser", "root"); ok {
+		t.Error("Lookup() found a value that is not among the common values")
+	}
+
+	if rules[1].Id != "31101" || rules[1].Description != "" {
+		t.Errorf("rules[1] = %+v", rules[1])
+	}
+}
+
+func TestTopDecodedValue(t *testing.T) {
+	const located = `{"rules": {"buckets": [{
+	  "key": "5710",
+	  "doc_count": 800,
+	  "rule": {"hits": {"hits": []}},
+	  "agents": {"buckets": []},
+	  "field_0": {"buckets": [{"key": "journald", "doc_count": 800}]},
+	  "field_1": {"buckets": [{"key": "10.0.0.5", "doc_count": 700}]},
+	  "field_2": {"buckets": [{"key": "admin", "doc_count": 790}]}
+	}]}}`
+	fields := []string{"location", "data.srcip", "data.srcuser"}
+	rules, err := Parse(json.RawMessage(located), fields, 800)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if top, ok := rules[0].TopValue(); !ok || top.Field != "location" {
+		t.Errorf("TopValue() = %+v, %v, want location", top, ok)
+	}
+	top, ok := rules[0].TopDecodedValue()
+	if !ok || top.Field != "data.srcuser" || top.Value != "admin" {
+		t.Errorf("TopDecodedValue() = %+v, %v, want data.srcuser admin", top, ok)
+	}
+	if _, err := (Suppression{Field: top.Field}).RuleField(); err != nil {
+		t.Errorf("RuleField() of the top decoded value: %v", err)
+	}
+
+	if _, ok := (Rule{Values: []Value{{Field: "location", Value: "journald"}}}).TopDecodedValue(); ok {
+		t.Error("TopDecodedValue() found a value without any data.* field")
+	}
+}
+
+func TestSuppression(t *testing.T) {
+	s := Suppression{
+		Id:                "100200",
+		ParentId:          "5710",
+		ParentDescription: "sshd: Attempt to login using a non-existent user",
+		Field:             "data.srcip",
+		Value:             "10.0.0.5",
+		Count:             700,
+		Since:             "2026-10-12T00:00:00Z",
+	}
+
+	xml, err := s.XML()
+	if err != nil {
+		t.Fatalf("XML() error = %v", err)
+	}
+	if !strings.Contains(xml, `<field name="srcip" type="pcre2">^10\.0\.0\.5$</field>`) {
+		t.Errorf("XML() is missing the field match:\n%s", xml)
+	}
+	if formatted, err := rulexml.Format([]byte(xml)); err != nil || string(formatted) != xml {
+		t.Errorf("XML() is not canonically formatted (%v):\n%s", err, formatted)
+	}
+	rules, err := rulexml.Parse([]byte(xml))
+	if err != nil || len(rules) != 1 || rules[0].Level != "0" || rules[0].IfSid[0] != "5710" {
+		t.Errorf("generated XML parses to %+v, %v", rules, err)
+	}
+
+	sample := v1.SchemaJsonEdgesElem{
+		Title:           "Alert a1",
+		Log:             &v1.SchemaJsonEdgesElemLog{Event: "sshd[1]: Invalid user admin from 10.0.0.5"},
+		RuleId:          "5710",
+		Decoder:         &v1.SchemaJsonEdgesElemDecoder{Name: "sshd"},
+		ExpectedOutcome: "Rule 5710 fires",
+	}
+	test := s.Test(xml, sample, "Jane Roe")
+	if len(test.Edges) != 2 {
+		t.Fatalf("Test() has %d edges, want 2", len(test.Edges))
+	}
+	hit, negative := test.Edges[0], test.Edges[1]
+	if hit.RuleId != "" || hit.Level == nil || *hit.Level != 0 {
+		t.Errorf("suppressed edge = %+v", hit)
+	}
+	if negative.RuleId != "5710" || negative.Expect != v1.SchemaJsonEdgesElemExpectNotFired || *negative.MaxLevel != 0 || negative.Decoder != nil {
+		t.Errorf("negative edge = %+v", negative)
+	}
+
+	if _, err := (Suppression{Field: "agent.name"}).XML(); err == nil {
+		t.Error("XML() accepted a field that is not decoded")
+	}
+}
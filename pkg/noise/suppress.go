@@ -0,0 +1,95 @@
+package noise
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+)
+
+var escape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace
+
+// Suppression is a level 0 child rule silencing a noisy rule for a single
+// value of a decoded field.
+type Suppression struct {
+	// Id of the suppression rule
+	Id string
+	// Noisy rule and the description it alerts with
+	ParentId          string
+	ParentDescription string
+	// Alert field, such as data.srcip, and the value to silence
+	Field string
+	Value string
+	// Alerts the value raised in the window the report covered
+	Count int
+	Since string
+}
+
+// RuleField returns the name a rule <field> option uses for the alert field.
+// Only decoded fields, stored under data in alerts, can be matched this way.
+func (s Suppression) RuleField() (string, error) {
+	name, ok := strings.CutPrefix(s.Field, "data.")
+	if !ok || name == "" {
+		return "", fmt.Errorf("%s is not a decoded field; suppressions match data.* fields", s.Field)
+	}
+	return name, nil
+}
+
+// XML returns the suppression rule in its own group.
+func (s Suppression) XML() (string, error) {
+	name, err := s.RuleField()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!-- Suppresses rule %s for %s %s: %d alerts since %s -->\n", s.ParentId, s.Field, escapeComment(s.Value), s.Count, s.Since)
+	b.WriteString("<group name=\"local,suppression,\">\n")
+	fmt.Fprintf(&b, "  <rule id=\"%s\" level=\"0\">\n", s.Id)
+	fmt.Fprintf(&b, "    <if_sid>%s</if_sid>\n", s.ParentId)
+	fmt.Fprintf(&b, "    <field name=\"%s\" type=\"pcre2\">^%s$</field>\n", escape(name), escape(regexp.QuoteMeta(s.Value)))
+	fmt.Fprintf(&b, "    <description>Suppressed: %s (%s %s)</description>\n", escape(s.ParentDescription), escape(name), escape(s.Value))
+	b.WriteString("  </rule>\n")
+	b.WriteString("</group>\n")
+	return b.String(), nil
+}
+
+// escapeComment keeps a value from closing the XML comment it is shown in.
+func escapeComment(value string) string {
+	return strings.ReplaceAll(value, "--", "- -")
+}
+
+// Test returns a rule test for the suppression rule. sample is an edge
+// replaying an alert of the noisy rule carrying the suppressed value; it is
+// expected to hit the suppression rule, and the noisy rule must no longer
+// alert for it.
+func (s Suppression) Test(xml string, sample v1.SchemaJsonEdgesElem, author string) v1.SchemaJson {
+	zero := 0
+	name, _ := s.RuleField()
+
+	suppressed := sample
+	suppressed.Title = fmt.Sprintf("%s %s is suppressed", name, s.Value)
+	suppressed.RuleId = ""
+	suppressed.Level = &zero
+	suppressed.ExpectedOutcome = fmt.Sprintf("Rule %s matches at level 0 instead of rule %s alerting", s.Id, s.ParentId)
+
+	silenced := sample
+	silenced.Title = fmt.Sprintf("Rule %s no longer alerts for %s %s", s.ParentId, name, s.Value)
+	silenced.Description = fmt.Sprintf("Negative case: the event that made rule %s noisy raises no alert", s.ParentId)
+	silenced.RuleId = s.ParentId
+	silenced.Expect = v1.SchemaJsonEdgesElemExpectNotFired
+	silenced.Level = nil
+	silenced.Decoder = nil
+	silenced.MaxLevel = &zero
+	silenced.ExpectedOutcome = fmt.Sprintf("Rule %s does not fire and nothing above level 0 does", s.ParentId)
+
+	return v1.SchemaJson{
+		RuleId:      s.Id,
+		RuleName:    fmt.Sprintf("Suppressed: %s (%s %s)", s.ParentDescription, name, s.Value),
+		RuleAuthor:  author,
+		RuleContent: strings.TrimSpace(xml),
+		Description: fmt.Sprintf("Suppression of rule %s for %s %s, which raised %d alerts since %s", s.ParentId, s.Field, s.Value, s.Count, s.Since),
+		Edges:       []v1.SchemaJsonEdgesElem{suppressed, silenced},
+	}
+}
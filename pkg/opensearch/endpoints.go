@@ -6,3 +6,18 @@ const (
 	// URI for creating new users: CreateNewIdexerUserURI:<new user>
 	CreateNewIdexerUserURI endpoints = "_plugins/_security/api/internalusers"
 )
+
+const (
+	// Index pattern holding alerts written by the wazuh manager
+	AlertsIndexPattern endpoints = "wazuh-alerts-*"
+
+	// Index pattern holding every event when archiving is enabled
+	ArchivesIndexPattern endpoints = "wazuh-archives-*"
+
+	// URI suffix for running a search against an index: <index>/SearchURI
+	SearchURI endpoints = "_search"
+
+	// URI suffix for creating a point in time on an index, and for deleting
+	// one: <index>/PointInTimeURI
+	PointInTimeURI endpoints = "_search/point_in_time"
+)
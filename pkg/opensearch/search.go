@@ -0,0 +1,113 @@
+package opensearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SearchResponse is the subset of an OpenSearch _search response used by wazctl.
+type SearchResponse struct {
+	Took int `json:"took"`
+	Hits struct {
+		Total struct {
+			Value    int    `json:"value"`
+			Relation string `json:"relation"`
+		} `json:"total"`
+		Hits []SearchHit `json:"hits"`
+	} `json:"hits"`
+	Aggregations json.RawMessage `json:"aggregations,omitempty"`
+	// Point in time id to use for the next page of a point in time search
+	PitId string `json:"pit_id,omitempty"`
+}
+
+// SearchHit is a single document returned by a search.
+type SearchHit struct {
+	Index  string          `json:"_index"`
+	Id     string          `json:"_id"`
+	Source json.RawMessage `json:"_source"`
+	Sort   []any           `json:"sort,omitempty"`
+}
+
+// Alert is the subset of a wazuh-alerts document used by wazctl.
+type Alert struct {
+	Timestamp string `json:"timestamp"`
+	Id        string `json:"id"`
+	FullLog   string `json:"full_log"`
+	Location  string `json:"location"`
+	Rule      struct {
+		Id          string   `json:"id"`
+		Level       int      `json:"level"`
+		Description string   `json:"description"`
+		Groups      []string `json:"groups"`
+		Mitre       struct {
+			Id        []string `json:"id"`
+			Tactic    []string `json:"tactic"`
+			Technique []string `json:"technique"`
+		} `json:"mitre"`
+	} `json:"rule"`
+	Agent struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+		Ip   string `json:"ip"`
+	} `json:"agent"`
+	Decoder struct {
+		Name   string `json:"name"`
+		Parent string `json:"parent"`
+	} `json:"decoder"`
+	Data map[string]any `json:"data,omitempty"`
+}
+
+// alertTimeLayouts are the formats the manager uses for the timestamp field.
+var alertTimeLayouts = []string{
+	"2006-01-02T15:04:05.000-0700",
+	"2006-01-02T15:04:05.000Z0700",
+	time.RFC3339Nano,
+}
+
+// Time parses the alert timestamp.
+func (a *Alert) Time() (time.Time, error) {
+	for _, layout := range alertTimeLayouts {
+		if t, err := time.Parse(layout, a.Timestamp); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised alert timestamp %q", a.Timestamp)
+}
+
+// Alert decodes the hit source as a wazuh alert.
+func (h *SearchHit) Alert() (*Alert, error) {
+	var alert Alert
+	if err := json.Unmarshal(h.Source, &alert); err != nil {
+		return nil, fmt.Errorf("decoding alert %s: %w", h.Id, err)
+	}
+	return &alert, nil
+}
+
+// TermsAggregation is the result of a terms aggregation.
+type TermsAggregation struct {
+	Buckets []TermBucket `json:"buckets"`
+}
+
+// TermBucket is a single value of a terms aggregation.
+type TermBucket struct {
+	Key         any    `json:"key"`
+	KeyAsString string `json:"key_as_string,omitempty"`
+	DocCount    int    `json:"doc_count"`
+}
+
+// String returns the bucket key as text, whatever its JSON type.
+func (b TermBucket) String() string {
+	if b.KeyAsString != "" {
+		return b.KeyAsString
+	}
+	switch k := b.Key.(type) {
+	case string:
+		return k
+	case float64:
+		return strconv.FormatFloat(k, 'f', -1, 64)
+	default:
+		return fmt.Sprint(k)
+	}
+}
@@ -0,0 +1,188 @@
+// Package replay runs historical events through logtest on a manager and
+// compares the result with how they were originally classified.
+package replay
+
+import (
+	"context"
+	"sort"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+	"github.com/EpykLab/wazctl/pkg/ruletest"
+)
+
+// Kind classifies how a replayed event differs from the original alert.
+type Kind string
+
+const (
+	KindUnchanged Kind = "unchanged"
+	// Same rule, different level
+	KindRelevelled Kind = "relevelled"
+	// A different rule matched
+	KindReclassified Kind = "reclassified"
+	// A rule matched before, none matches now
+	KindLost Kind = "lost"
+	// No rule matched before, one matches now
+	KindGained Kind = "gained"
+	// The event could not be replayed
+	KindError Kind = "error"
+)
+
+// Match is the rule an event was classified as.
+type Match struct {
+	RuleId      string `json:"rule_id,omitempty"`
+	Level       int    `json:"level"`
+	Description string `json:"description,omitempty"`
+}
+
+// Sample is an indexed event to replay.
+type Sample struct {
+	DocId string
+	Alert opensearch.Alert
+}
+
+// Outcome compares the original and replayed classification of a sample.
+type Outcome struct {
+	DocId  string `json:"doc_id"`
+	Before Match  `json:"before"`
+	After  Match  `json:"after"`
+	Kind   Kind   `json:"kind"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Classify names the difference between two classifications of an event.
+func Classify(before, after Match) Kind {
+	switch {
+	case before.RuleId == after.RuleId && before.Level == after.Level:
+		return KindUnchanged
+	case before.RuleId == after.RuleId:
+		return KindRelevelled
+	case after.RuleId == "":
+		return KindLost
+	case before.RuleId == "":
+		return KindGained
+	default:
+		return KindReclassified
+	}
+}
+
+// Run replays samples through logtest with a pool of parallel workers, each
+// with its own session, and returns the outcomes in the order of samples.
+// Samples not replayed before ctx is cancelled are left out.
+func Run(ctx context.Context, tester ruletest.Logtester, samples []Sample, parallel int) []Outcome {
+	opts := ruletest.RunOptions{Parallel: parallel}
+	return ruletest.RunPool(ctx, tester, samples, opts, func(_ context.Context, sample Sample, session *ruletest.Session) []Outcome {
+		return []Outcome{replay(session, sample)}
+	}, nil)
+}
+
+func replay(session *ruletest.Session, sample Sample) Outcome {
+	alert := sample.Alert
+	outcome := Outcome{
+		DocId: sample.DocId,
+		Before: Match{
+			RuleId:      alert.Rule.Id,
+			Level:       alert.Rule.Level,
+			Description: alert.Rule.Description,
+		},
+	}
+
+	res, err := session.Send(v1.SchemaJsonEdgesElemLog{
+		Event:     alert.FullLog,
+		Location:  alert.Location,
+		LogFormat: ruletest.GuessLogFormat(alert.FullLog),
+	})
+	session.Reset()
+	if err != nil {
+		outcome.Kind = KindError
+		outcome.Error = err.Error()
+		return outcome
+	}
+
+	outcome.After = Match{
+		RuleId:      res.RuleId(),
+		Level:       res.RuleLevel(),
+		Description: res.RuleDescription(),
+	}
+	outcome.Kind = Classify(outcome.Before, outcome.After)
+	return outcome
+}
+
+// RuleSummary counts how the samples of one rule changed.
+type RuleSummary struct {
+	RuleId string `json:"rule_id"`
+	// Samples classified as the rule originally
+	Before int `json:"before"`
+	// Samples classified as the rule after replay
+	After int `json:"after"`
+	// Samples that moved to the rule
+	Gained int `json:"gained"`
+	// Samples that moved away from the rule
+	Lost int `json:"lost"`
+	// Samples that kept the rule with a different level
+	Relevelled int `json:"relevelled"`
+}
+
+// Changed reports whether any sample of the rule changed.
+func (s RuleSummary) Changed() bool {
+	return s.Gained > 0 || s.Lost > 0 || s.Relevelled > 0
+}
+
+// Summarize totals outcomes by rule id. Rules with the largest net change
+// come first; failed replays are not counted.
+func Summarize(outcomes []Outcome) []RuleSummary {
+	byRule := make(map[string]*RuleSummary)
+	get := func(id string) *RuleSummary {
+		if byRule[id] == nil {
+			byRule[id] = &RuleSummary{RuleId: id}
+		}
+		return byRule[id]
+	}
+
+	for _, o := range outcomes {
+		if o.Kind == KindError {
+			continue
+		}
+		if o.Before.RuleId != "" {
+			get(o.Before.RuleId).Before++
+		}
+		if o.After.RuleId != "" {
+			get(o.After.RuleId).After++
+		}
+		switch o.Kind {
+		case KindRelevelled:
+			get(o.Before.RuleId).Relevelled++
+		case KindLost, KindGained, KindReclassified:
+			if o.Before.RuleId != "" {
+				get(o.Before.RuleId).Lost++
+			}
+			if o.After.RuleId != "" {
+				get(o.After.RuleId).Gained++
+			}
+		}
+	}
+
+	summary := make([]RuleSummary, 0, len(byRule))
+	for _, s := range byRule {
+		summary = append(summary, *s)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		di, dj := abs(summary[i].After-summary[i].Before), abs(summary[j].After-summary[j].Before)
+		if di != dj {
+			return di > dj
+		}
+		ci, cj := summary[i].Gained+summary[i].Lost+summary[i].Relevelled, summary[j].Gained+summary[j].Lost+summary[j].Relevelled
+		if ci != cj {
+			return ci > cj
+		}
+		return summary[i].RuleId < summary[j].RuleId
+	})
+	return summary
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
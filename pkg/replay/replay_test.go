@@ -0,0 +1,104 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/EpykLab/wazctl/pkg/actions"
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		before Match
+		after  Match
+		want   Kind
+	}{
+		{name: "same", before: Match{RuleId: "5715", Level: 3}, after: Match{RuleId: "5715", Level: 3}, want: KindUnchanged},
+		{name: "level", before: Match{RuleId: "5715", Level: 3}, after: Match{RuleId: "5715", Level: 5}, want: KindRelevelled},
+		{name: "other rule", before: Match{RuleId: "5715", Level: 3}, after: Match{RuleId: "100001", Level: 3}, want: KindReclassified},
+		{name: "no rule now", before: Match{RuleId: "5715", Level: 3}, after: Match{}, want: KindLost},
+		{name: "archived event now alerts", before: Match{}, after: Match{RuleId: "100001", Level: 7}, want: KindGained},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.before, tt.after); got != tt.want {
+				t.Errorf("Classify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeLogtester classifies events by a fixed table.
+type fakeLogtester struct {
+	mu     sync.Mutex
+	rules  map[string]Match
+	tokens int
+}
+
+func (f *fakeLogtester) RunLogtest(req actions.LogtestRequest) (*actions.LogtestResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokens++
+
+	m, ok := f.rules[req.Event]
+	if !ok {
+		return nil, fmt.Errorf("unknown event %q", req.Event)
+	}
+	out := map[string]any{}
+	if m.RuleId != "" {
+		out["rule"] = map[string]any{"id": m.RuleId, "level": float64(m.Level)}
+	}
+	return &actions.LogtestResult{Token: fmt.Sprint(f.tokens), Output: out}, nil
+}
+
+func (f *fakeLogtester) EndLogtestSession(token string) error { return nil }
+
+func sample(id, event, ruleId string, level int) Sample {
+	s := Sample{DocId: id, Alert: opensearch.Alert{FullLog: event}}
+	s.Alert.Rule.Id = ruleId
+	s.Alert.Rule.Level = level
+	return s
+}
+
+func TestRunAndSummarize(t *testing.T) {
+	tester := &fakeLogtester{rules: map[string]Match{
+		"login":   {RuleId: "5715", Level: 3},
+		"fail":    {RuleId: "100001", Level: 10},
+		"noise":   {},
+		"louder":  {RuleId: "5716", Level: 7},
+		"unknown": {RuleId: "5715", Level: 3},
+	}}
+	samples := []Sample{
+		sample("a", "login", "5715", 3),
+		sample("b", "fail", "5716", 5),
+		sample("c", "noise", "5716", 5),
+		sample("d", "louder", "5716", 5),
+		sample("e", "broken", "5715", 3),
+	}
+
+	outcomes := Run(context.Background(), tester, samples, 3)
+
+	var kinds []Kind
+	for _, o := range outcomes {
+		kinds = append(kinds, o.Kind)
+	}
+	want := []Kind{KindUnchanged, KindReclassified, KindLost, KindRelevelled, KindError}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("Run() kinds = %v, want %v", kinds, want)
+	}
+
+	got := Summarize(outcomes)
+	wantSummary := []RuleSummary{
+		{RuleId: "5716", Before: 3, After: 1, Lost: 2, Relevelled: 1},
+		{RuleId: "100001", Before: 0, After: 1, Gained: 1},
+		{RuleId: "5715", Before: 1, After: 1},
+	}
+	if !reflect.DeepEqual(got, wantSummary) {
+		t.Errorf("Summarize() = %+v, want %+v", got, wantSummary)
+	}
+}
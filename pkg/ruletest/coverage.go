@@ -0,0 +1,100 @@
+package ruletest
+
+import (
+	"github.com/EpykLab/wazctl/internal/rulexml"
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+)
+
+// AssertedRuleIds returns the rule ids a test file expects to fire, taken
+// from every edge expecting its rule to fire. Rules only asserted not to
+// fire do not count as covered.
+func AssertedRuleIds(test *v1.SchemaJson) []string {
+	var ids []string
+	for _, edge := range test.Edges {
+		if EdgeExpect(edge) == v1.SchemaJsonEdgesElemExpectFired {
+			ids = append(ids, EdgeRuleId(test, edge))
+		}
+	}
+	return uniqueIds(ids)
+}
+
+// ReferencedRuleIds returns every rule id a test file mentions, whether it
+// expects the rule to fire or not.
+func ReferencedRuleIds(test *v1.SchemaJson) []string {
+	ids := []string{test.RuleId}
+	for _, edge := range test.Edges {
+		ids = append(ids, EdgeRuleId(test, edge))
+	}
+	return uniqueIds(ids)
+}
+
+// uniqueIds drops empty and repeated ids, keeping the first occurrence.
+func uniqueIds(ids []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// CoverageReport compares the custom rules with the rules asserted by tests.
+type CoverageReport struct {
+	// Number of distinct custom rule ids
+	CustomRules int `json:"custom_rules"`
+	// Number of custom rules asserted by at least one test
+	CoveredRules int `json:"covered_rules"`
+	// Custom rules no test asserts
+	Untested []string `json:"untested"`
+	// Rules asserted by tests that are not among the custom rules
+	TestedButMissing []string `json:"tested_but_missing"`
+	// Share of custom rules covered, from 0 to 100
+	Percent float64 `json:"percent"`
+	// Test files that failed to load and so assert nothing
+	LoadErrors []string `json:"load_errors,omitempty"`
+}
+
+// Coverage builds a CoverageReport from the custom and tested rule ids.
+func Coverage(custom, tested []string) CoverageReport {
+	customSet := toSet(custom)
+	testedSet := toSet(tested)
+
+	report := CoverageReport{
+		CustomRules:      len(customSet),
+		Untested:         []string{},
+		TestedButMissing: []string{},
+	}
+	for id := range customSet {
+		if testedSet[id] {
+			report.CoveredRules++
+		} else {
+			report.Untested = append(report.Untested, id)
+		}
+	}
+	for id := range testedSet {
+		if !customSet[id] {
+			report.TestedButMissing = append(report.TestedButMissing, id)
+		}
+	}
+	rulexml.SortIds(report.Untested)
+	rulexml.SortIds(report.TestedButMissing)
+
+	if report.CustomRules > 0 {
+		report.Percent = float64(report.CoveredRules) * 100 / float64(report.CustomRules)
+	}
+
+	return report
+}
+
+func toSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id != "" {
+			set[id] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,66 @@
+package ruletest
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+)
+
+func TestCoverage(t *testing.T) {
+	tests := []struct {
+		name   string
+		custom []string
+		tested []string
+		want   CoverageReport
+	}{
+		{
+			name:   "partial coverage",
+			custom: []string{"100002", "100001", "100010", "100001"},
+			tested: []string{"100001", "100010", "999"},
+			want: CoverageReport{
+				CustomRules:      3,
+				CoveredRules:     2,
+				Untested:         []string{"100002"},
+				TestedButMissing: []string{"999"},
+				Percent:          200.0 / 3,
+			},
+		},
+		{
+			name:   "no custom rules",
+			custom: nil,
+			tested: []string{"100001"},
+			want: CoverageReport{
+				Untested:         []string{},
+				TestedButMissing: []string{"100001"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Coverage(tt.custom, tt.tested); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Coverage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssertedRuleIds(t *testing.T) {
+	maxLevel := 3
+	test := &v1.SchemaJson{
+		RuleId: "100001",
+		Edges: []v1.SchemaJsonEdgesElem{
+			{Title: "fires"},
+			{Title: "sibling fires", RuleId: "100002", Expect: v1.SchemaJsonEdgesElemExpectFired},
+			{Title: "benign", RuleId: "5716", Expect: v1.SchemaJsonEdgesElemExpectNotFired},
+			{Title: "quiet", MaxLevel: &maxLevel},
+		},
+	}
+
+	if got, want := AssertedRuleIds(test), []string{"100001", "100002"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("AssertedRuleIds() = %v, want %v", got, want)
+	}
+	if got, want := ReferencedRuleIds(test), []string{"100001", "100002", "5716"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReferencedRuleIds() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,150 @@
+package ruletest
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+)
+
+// AlertFinder looks up alerts written to the indexer.
+type AlertFinder interface {
+	FindAlertForRule(ruleId, agent string, since time.Time) (*opensearch.Alert, string, error)
+}
+
+// E2EOptions controls where edge commands run and how long to wait for alerts.
+type E2EOptions struct {
+	// Docker container to run commands in, empty to run them locally
+	Container string
+	// Only accept alerts raised by this agent name
+	Agent string
+	// How long to wait for an alert after a command finishes
+	Timeout time.Duration
+	// Delay between indexer queries
+	PollInterval time.Duration
+}
+
+// E2EResult is the outcome of running an edge command and waiting for its alert.
+type E2EResult struct {
+	Result
+	// When the edge command was started
+	Started time.Time `json:"started"`
+	// Time between starting the command and the alert timestamp
+	Latency time.Duration `json:"latency,omitempty"`
+	// Document id (_id) of the matching alert in the indexer
+	AlertId string `json:"alert_id,omitempty"`
+}
+
+// RunE2E executes every bash edge command in test and waits for an alert for
+// the edge's rule in the indexer. Edges expecting their rule not to fire
+// watch the indexer for the whole timeout and fail on any alert for it; edges
+// with max_level fail on an alert above it. Edges without a bash command, and
+// edges only setting max_level, which would need every rule's alerts, are
+// skipped.
+func RunE2E(ctx context.Context, path string, test *v1.SchemaJson, finder AlertFinder, opts E2EOptions) []E2EResult {
+	var results []E2EResult
+
+	for _, edge := range test.Edges {
+		ruleId := EdgeRuleId(test, edge)
+		expect := EdgeExpect(edge)
+		res := E2EResult{Result: Result{File: path, RuleId: ruleId, Edge: edge.Title}}
+
+		if ruleId == "" {
+			res.Status = StatusSkip
+			res.Message = "decoder tests have no alert to wait for"
+			results = append(results, res)
+			continue
+		}
+		if expect == "" {
+			res.Status = StatusSkip
+			res.Message = "max_level without expect is not checked end to end"
+			results = append(results, res)
+			continue
+		}
+		if strings.TrimSpace(edge.Command.Value) == "" {
+			res.Status = StatusSkip
+			res.Message = "edge has no command"
+			results = append(results, res)
+			continue
+		}
+		if edge.Command.Type != v1.SchemaJsonEdgesElemCommandTypeBash {
+			res.Status = StatusSkip
+			res.Message = fmt.Sprintf("command type %q is not executed end to end", edge.Command.Type)
+			results = append(results, res)
+			continue
+		}
+
+		res.Started = time.Now()
+		if out, err := commandFor(ctx, edge.Command.Value, opts.Container).CombinedOutput(); err != nil {
+			res.Status = StatusFail
+			res.Message = fmt.Sprintf("command failed: %v: %s", err, strings.TrimSpace(string(out)))
+			results = append(results, res)
+			continue
+		}
+
+		alert, docId, err := waitForAlert(ctx, finder, ruleId, res.Started, opts)
+		if alert != nil {
+			res.AlertId = docId
+			if at, err := alert.Time(); err == nil {
+				res.Latency = at.Sub(res.Started)
+			}
+		}
+		switch {
+		case err != nil:
+			res.Status = StatusFail
+			res.Message = err.Error()
+		case expect == v1.SchemaJsonEdgesElemExpectNotFired && alert != nil:
+			res.Status = StatusFail
+			res.Message = fmt.Sprintf("rule %s fired, expected it not to", ruleId)
+		case expect == v1.SchemaJsonEdgesElemExpectNotFired:
+			res.Status = StatusPass
+			res.Message = fmt.Sprintf("no alert for rule %s within %s", ruleId, opts.Timeout)
+		case alert == nil:
+			res.Status = StatusFail
+			res.Message = fmt.Sprintf("no alert for rule %s within %s", ruleId, opts.Timeout)
+		case edge.MaxLevel != nil && alert.Rule.Level > *edge.MaxLevel:
+			res.Status = StatusFail
+			res.Message = fmt.Sprintf("rule %s fired at level %d, above max level %d", ruleId, alert.Rule.Level, *edge.MaxLevel)
+		default:
+			res.Status = StatusPass
+		}
+		results = append(results, res)
+	}
+
+	return results
+}
+
+// commandFor builds the process for a bash edge command, wrapping it in
+// docker exec when a container is given.
+func commandFor(ctx context.Context, command, container string) *exec.Cmd {
+	if container != "" {
+		return exec.CommandContext(ctx, "docker", "exec", container, "bash", "-c", command)
+	}
+	return exec.CommandContext(ctx, "bash", "-c", command)
+}
+
+// waitForAlert polls finder until an alert appears or the timeout expires,
+// returning the alert and its document id.
+func waitForAlert(ctx context.Context, finder AlertFinder, ruleId string, since time.Time, opts E2EOptions) (*opensearch.Alert, string, error) {
+	deadline := time.Now().Add(opts.Timeout)
+
+	for {
+		alert, docId, err := finder.FindAlertForRule(ruleId, opts.Agent, since)
+		if err != nil || alert != nil {
+			return alert, docId, err
+		}
+		if time.Now().Add(opts.PollInterval).After(deadline) {
+			return nil, "", nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		case <-time.After(opts.PollInterval):
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package ruletest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+)
+
+type fakeFinder struct {
+	alerts map[string]*opensearch.Alert
+	calls  int
+}
+
+func (f *fakeFinder) FindAlertForRule(ruleId, agent string, since time.Time) (*opensearch.Alert, string, error) {
+	f.calls++
+	if alert, ok := f.alerts[ruleId]; ok {
+		return alert, "doc-" + ruleId, nil
+	}
+	return nil, "", nil
+}
+
+func TestRunE2E(t *testing.T) {
+	alert := &opensearch.Alert{Id: "1700000000.1", Timestamp: time.Now().Add(time.Second).Format("2006-01-02T15:04:05.000-0700")}
+	alert.Rule.Level = 5
+	level := func(n int) *int { return &n }
+
+	tests := []struct {
+		name       string
+		ruleId     string
+		command    v1.SchemaJsonEdgesElemCommand
+		edge       v1.SchemaJsonEdgesElem
+		wantStatus Status
+	}{
+		{
+			name:       "alert found",
+			ruleId:     "100001",
+			command:    v1.SchemaJsonEdgesElemCommand{Type: "bash", Value: "true"},
+			wantStatus: StatusPass,
+		},
+		{
+			name:       "no alert",
+			ruleId:     "100002",
+			command:    v1.SchemaJsonEdgesElemCommand{Type: "bash", Value: "true"},
+			wantStatus: StatusFail,
+		},
+		{
+			name:       "command fails",
+			ruleId:     "100001",
+			command:    v1.SchemaJsonEdgesElemCommand{Type: "bash", Value: "exit 3"},
+			wantStatus: StatusFail,
+		},
+		{
+			name:       "edge rule id",
+			ruleId:     "100002",
+			command:    v1.SchemaJsonEdgesElemCommand{Type: "bash", Value: "true"},
+			edge:       v1.SchemaJsonEdgesElem{RuleId: "100001"},
+			wantStatus: StatusPass,
+		},
+		{
+			name:       "not fired",
+			ruleId:     "100002",
+			command:    v1.SchemaJsonEdgesElemCommand{Type: "bash", Value: "true"},
+			edge:       v1.SchemaJsonEdgesElem{Expect: v1.SchemaJsonEdgesElemExpectNotFired},
+			wantStatus: StatusPass,
+		},
+		{
+			name:       "fired when not expected",
+			ruleId:     "100001",
+			command:    v1.SchemaJsonEdgesElemCommand{Type: "bash", Value: "true"},
+			edge:       v1.SchemaJsonEdgesElem{Expect: v1.SchemaJsonEdgesElemExpectNotFired},
+			wantStatus: StatusFail,
+		},
+		{
+			name:       "above max level",
+			ruleId:     "100001",
+			command:    v1.SchemaJsonEdgesElemCommand{Type: "bash", Value: "true"},
+			edge:       v1.SchemaJsonEdgesElem{Expect: v1.SchemaJsonEdgesElemExpectFired, MaxLevel: level(3)},
+			wantStatus: StatusFail,
+		},
+		{
+			name:       "max level only skipped",
+			ruleId:     "100001",
+			command:    v1.SchemaJsonEdgesElemCommand{Type: "bash", Value: "true"},
+			edge:       v1.SchemaJsonEdgesElem{MaxLevel: level(3)},
+			wantStatus: StatusSkip,
+		},
+		{
+			name:       "powershell skipped",
+			ruleId:     "100001",
+			command:    v1.SchemaJsonEdgesElemCommand{Type: "powershell", Value: "Get-Process"},
+			wantStatus: StatusSkip,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finder := &fakeFinder{alerts: map[string]*opensearch.Alert{"100001": alert}}
+			edge := tt.edge
+			edge.Title, edge.Command = tt.name, tt.command
+			test := &v1.SchemaJson{RuleId: tt.ruleId, Edges: []v1.SchemaJsonEdgesElem{edge}}
+
+			results := RunE2E(context.Background(), "test.yaml", test, finder, E2EOptions{
+				Timeout:      20 * time.Millisecond,
+				PollInterval: 5 * time.Millisecond,
+			})
+			if len(results) != 1 {
+				t.Fatalf("RunE2E() returned %d results, want 1", len(results))
+			}
+			if got := results[0]; got.Status != tt.wantStatus {
+				t.Errorf("RunE2E() status = %v (%s), want %v", got.Status, got.Message, tt.wantStatus)
+			}
+			ruleId := EdgeRuleId(test, edge)
+			if results[0].RuleId != ruleId {
+				t.Errorf("RunE2E() rule = %s, want %s", results[0].RuleId, ruleId)
+			}
+			if tt.wantStatus == StatusPass && EdgeExpect(edge) == v1.SchemaJsonEdgesElemExpectFired {
+				if results[0].Latency <= 0 {
+					t.Errorf("RunE2E() latency = %v, want > 0", results[0].Latency)
+				}
+				if results[0].AlertId != "doc-"+ruleId {
+					t.Errorf("RunE2E() alert id = %q, want the document id", results[0].AlertId)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,392 @@
+package ruletest
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/netip"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+)
+
+// usernames and hostnames are drawn on by the username and hostname
+// generators.
+var (
+	usernames = []string{"root", "admin", "alice", "bob", "carol", "deploy", "jenkins", "oracle", "postgres", "svc_backup", "test", "ubuntu", "www-data"}
+	hostnames = []string{"web", "db", "app", "mail", "vpn", "dc", "build", "proxy"}
+)
+
+// BaseTime is the first time generated by syslogTime and isoTime.
+var BaseTime = time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// Generators returns the template functions available in rule test
+// templates. Values are drawn from a generator seeded with seed so a test
+// file expands to the same logs on every run.
+func Generators(seed uint64) template.FuncMap {
+	rng := rand.New(rand.NewPCG(seed, seed>>1|1))
+
+	// every generated time is a second after the previous one, so events
+	// of a sequence stay in order
+	clock := BaseTime.Add(-time.Second)
+	tick := func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	return template.FuncMap{
+		// ipv4 returns a routable-looking address outside the private,
+		// loopback and multicast ranges.
+		"ipv4": func() string {
+			for {
+				a := 1 + rng.IntN(223)
+				if a == 10 || a == 127 || a == 169 || a == 172 || a == 192 {
+					continue
+				}
+				return fmt.Sprintf("%d.%d.%d.%d", a, rng.IntN(256), rng.IntN(256), 1+rng.IntN(254))
+			}
+		},
+		"privateIpv4": func() string {
+			if rng.IntN(2) == 0 {
+				return fmt.Sprintf("10.%d.%d.%d", rng.IntN(256), rng.IntN(256), 1+rng.IntN(254))
+			}
+			return fmt.Sprintf("192.168.%d.%d", rng.IntN(256), 1+rng.IntN(254))
+		},
+		// ipv6 returns an address in the 2001:db8::/32 documentation prefix.
+		"ipv6": func() string {
+			var b [16]byte
+			b[0], b[1], b[2], b[3] = 0x20, 0x01, 0x0d, 0xb8
+			for i := 4; i < 16; i++ {
+				b[i] = byte(rng.IntN(256))
+			}
+			return netip.AddrFrom16(b).String()
+		},
+		"username": func() string {
+			return usernames[rng.IntN(len(usernames))]
+		},
+		"hostname": func() string {
+			return fmt.Sprintf("%s-%02d", hostnames[rng.IntN(len(hostnames))], 1+rng.IntN(99))
+		},
+		"int": func(min, max int) (int, error) {
+			if max < min {
+				return 0, fmt.Errorf("int: max %d is below min %d", max, min)
+			}
+			return min + rng.IntN(max-min+1), nil
+		},
+		"pick": func(values ...string) (string, error) {
+			if len(values) == 0 {
+				return "", fmt.Errorf("pick: no values")
+			}
+			return values[rng.IntN(len(values))], nil
+		},
+		// syslogTime and isoTime count up from BaseTime rather than using
+		// the current time, which would change the logs, and so snapshots,
+		// on every run.
+		"syslogTime": func() string {
+			return tick().Format(time.Stamp)
+		},
+		"isoTime": func() string {
+			return tick().Format("2006-01-02T15:04:05.000Z07:00")
+		},
+	}
+}
+
+// SeedFor returns the generator seed for a test file with content. Seeding
+// from the content rather than the path keeps the values the same whatever
+// directory the tests are run from.
+func SeedFor(content []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(content)
+	return h.Sum64()
+}
+
+// Expand replaces every edge of test that has a matrix with one edge per
+// combination of matrix values, and renders the templates in the titles,
+// outcomes, commands and logs of edges that have vars or a matrix, or of
+// all edges when the test sets templates. Other edges are kept verbatim so
+// a literal {{ in a log needs no escaping. Vars and matrix values are
+// available to templates as {{.name}}; vars may themselves use generators,
+// matrix values and other vars, and are rendered once per case so every
+// event of a sequence sees the same value.
+func Expand(test *v1.SchemaJson, seed uint64) error {
+	funcs := Generators(seed)
+
+	var edges []v1.SchemaJsonEdgesElem
+	for _, edge := range test.Edges {
+		matrix := mergeMatrix(test.Matrix, edge.Matrix)
+		for k, values := range matrix {
+			if len(values) == 0 {
+				return fmt.Errorf("edge %q: matrix %q has no values", edge.Title, k)
+			}
+		}
+		for _, combo := range combinations(matrix) {
+			expanded, err := expandEdge(test, edge, combo, funcs)
+			if err != nil {
+				return fmt.Errorf("edge %q: %w", edge.Title, err)
+			}
+			edges = append(edges, expanded)
+		}
+	}
+
+	test.Edges = edges
+	test.Matrix = nil
+	test.Vars = nil
+	return nil
+}
+
+// expandEdge renders one case of edge for the matrix values in combo.
+func expandEdge(test *v1.SchemaJson, edge v1.SchemaJsonEdgesElem, combo []matrixValue, funcs template.FuncMap) (v1.SchemaJsonEdgesElem, error) {
+	out := edge
+	out.Matrix = nil
+	out.Vars = nil
+
+	templated := test.Templates || len(combo) > 0 || len(test.Vars) > 0 || len(edge.Vars) > 0
+	if !templated {
+		return out, nil
+	}
+
+	data := make(map[string]string)
+	for _, v := range combo {
+		data[v.Key] = v.Value
+	}
+
+	vars := make(map[string]string)
+	for k, v := range test.Vars {
+		vars[k] = v
+	}
+	for k, v := range edge.Vars {
+		vars[k] = v
+	}
+	for k := range vars {
+		if _, ok := data[k]; ok {
+			return edge, fmt.Errorf("%q is defined in both vars and matrix", k)
+		}
+	}
+	if err := renderVars(vars, data, funcs); err != nil {
+		return edge, err
+	}
+
+	fields := []templateField{
+		{"title", &out.Title},
+		{"description", &out.Description},
+		{"expected_outcome", &out.ExpectedOutcome},
+		{"rule_id", &out.RuleId},
+	}
+	fields = append(fields, templateField{"command.value", &out.Command.Value})
+	if edge.Log != nil {
+		log := *edge.Log
+		out.Log = &log
+		fields = append(fields,
+			templateField{"log.event", &log.Event},
+			templateField{"log.location", &log.Location},
+			templateField{"log.log_format", &log.LogFormat})
+	}
+	if len(edge.Events) > 0 {
+		out.Events = append([]v1.SchemaJsonEdgesElemEventsElem{}, edge.Events...)
+		for i := range out.Events {
+			e := &out.Events[i]
+			fields = append(fields,
+				templateField{fmt.Sprintf("events[%d].event", i+1), &e.Event},
+				templateField{fmt.Sprintf("events[%d].location", i+1), &e.Location},
+				templateField{fmt.Sprintf("events[%d].log_format", i+1), &e.LogFormat})
+		}
+	}
+
+	for _, f := range fields {
+		var err error
+		if *f.value, err = render(f.name, *f.value, data, funcs); err != nil {
+			return edge, err
+		}
+	}
+
+	// A title without placeholders would be repeated for every case, so
+	// name the case after its matrix values instead.
+	if len(combo) > 0 && out.Title == edge.Title {
+		out.Title = fmt.Sprintf("%s [%s]", edge.Title, comboName(combo))
+	}
+
+	return out, nil
+}
+
+// render executes text as a template, leaving text without placeholders
+// untouched.
+func render(name, text string, data map[string]string, funcs template.FuncMap) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderVars renders vars into data. A var is rendered after the vars it
+// refers to, so vars can be built from each other whatever their names.
+func renderVars(vars, data map[string]string, funcs template.FuncMap) error {
+	const (
+		rendering = iota + 1
+		rendered
+	)
+	state := make(map[string]int, len(vars))
+
+	var resolve func(name string) error
+	resolve = func(name string) error {
+		switch state[name] {
+		case rendering:
+			return fmt.Errorf("vars.%s refers back to itself", name)
+		case rendered:
+			return nil
+		}
+		state[name] = rendering
+		for _, ref := range fieldRefs(vars[name], funcs) {
+			if _, ok := vars[ref]; ok {
+				if err := resolve(ref); err != nil {
+					return err
+				}
+			}
+		}
+
+		value, err := render("vars."+name, vars[name], data, funcs)
+		if err != nil {
+			return err
+		}
+		data[name] = value
+		state[name] = rendered
+		return nil
+	}
+
+	for _, name := range sortedNames(vars) {
+		if err := resolve(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldRefs returns the names text refers to as {{.name}}. Text that does
+// not parse refers to nothing; render reports the error.
+func fieldRefs(text string, funcs template.FuncMap) []string {
+	if !strings.Contains(text, "{{") {
+		return nil
+	}
+	tmpl, err := template.New("").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil
+	}
+
+	var refs []string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Cmds {
+				walk(c)
+			}
+		case *parse.CommandNode:
+			for _, a := range n.Args {
+				walk(a)
+			}
+		case *parse.ChainNode:
+			walk(n.Node)
+		case *parse.FieldNode:
+			refs = append(refs, n.Ident[0])
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		}
+	}
+	walk(tmpl.Tree.Root)
+	return refs
+}
+
+// templateField is a string field of an edge rendered as a template.
+type templateField struct {
+	name  string
+	value *string
+}
+
+type matrixValue struct {
+	Key   string
+	Value string
+}
+
+// mergeMatrix returns the test's matrix with the edge's entries added,
+// replacing entries of the same name.
+func mergeMatrix(test, edge map[string][]string) map[string][]string {
+	merged := make(map[string][]string, len(test)+len(edge))
+	for k, v := range test {
+		merged[k] = v
+	}
+	for k, v := range edge {
+		merged[k] = v
+	}
+	return merged
+}
+
+// combinations returns the cartesian product of matrix, varying the last
+// key (in name order) fastest. An empty matrix has a single, empty
+// combination.
+func combinations(matrix map[string][]string) [][]matrixValue {
+	combos := [][]matrixValue{nil}
+	for _, k := range sortedNames(matrix) {
+		var next [][]matrixValue
+		for _, combo := range combos {
+			for _, v := range matrix[k] {
+				c := append(append([]matrixValue{}, combo...), matrixValue{k, v})
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+func comboName(combo []matrixValue) string {
+	parts := make([]string, len(combo))
+	for i, v := range combo {
+		parts[i] = v.Key + "=" + v.Value
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
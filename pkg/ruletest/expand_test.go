@@ -0,0 +1,187 @@
+package ruletest
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const matrixTest = `ruleId: "100200"
+ruleName: SSH login
+description: Successful SSH logins
+vars:
+  src: "{{ipv4}}"
+matrix:
+  user: [root, admin]
+edges:
+  - title: Accepted password
+    description: Password login
+    expected_outcome: Rule fires
+    log:
+      event: "{{syslogTime}} host sshd[1]: Accepted password for {{.user}} from {{.src}} port 22 ssh2"
+  - title: "Login on {{.host}}"
+    description: Login from each host
+    expected_outcome: Rule fires
+    matrix:
+      host: [web-01, db-01]
+      user: [deploy]
+    events:
+      - event: "sshd[1]: Failed password for {{.user}} from {{.src}} on {{.host}}"
+      - event: "sshd[1]: Accepted password for {{.user}} from {{.src}} on {{.host}}"
+  - title: Plain edge
+    description: No placeholders
+    expected_outcome: Rule fires
+    log:
+      event: "host sshd[1]: Accepted publickey for root"
+`
+
+func TestLoadExpandsMatrix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ssh.yaml")
+	if err := os.WriteFile(path, []byte(matrixTest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	test, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var titles []string
+	for _, edge := range test.Edges {
+		titles = append(titles, edge.Title)
+	}
+	want := []string{
+		"Accepted password [user=root]",
+		"Accepted password [user=admin]",
+		"Login on web-01",
+		"Login on db-01",
+		"Plain edge [user=root]",
+		"Plain edge [user=admin]",
+	}
+	if strings.Join(titles, "|") != strings.Join(want, "|") {
+		t.Fatalf("titles = %q, want %q", titles, want)
+	}
+
+	event := test.Edges[1].Log.Event
+	if !strings.Contains(event, "Accepted password for admin from ") || strings.Contains(event, "{{") {
+		t.Errorf("rendered event = %q", event)
+	}
+	src := strings.Fields(event[strings.Index(event, " from ")+6:])[0]
+	if _, err := netip.ParseAddr(src); err != nil {
+		t.Errorf("ipv4 generated %q: %v", src, err)
+	}
+
+	seq := test.Edges[2].Events
+	if seq[0].Event != strings.Replace(seq[1].Event, "Accepted", "Failed", 1) {
+		t.Errorf("events of one case saw different vars: %q, %q", seq[0].Event, seq[1].Event)
+	}
+	if !strings.Contains(seq[0].Event, "for deploy ") {
+		t.Errorf("edge matrix did not override the test matrix: %q", seq[0].Event)
+	}
+
+	for i, want := range []string{"Jan  1 12:00:00 ", "Jan  1 12:00:01 "} {
+		if got := test.Edges[i].Log.Event; !strings.HasPrefix(got, want) {
+			t.Errorf("syslogTime in edge %d = %q, want it to count up from BaseTime", i+1, got)
+		}
+	}
+
+	// the same file in another directory expands to the same logs
+	moved := filepath.Join(t.TempDir(), "nested", "ssh.yaml")
+	if err := os.MkdirAll(filepath.Dir(moved), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(moved, []byte(matrixTest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Load(moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range test.Edges {
+		if a, b := EdgeEvents(again.Edges[i]), EdgeEvents(test.Edges[i]); !reflect.DeepEqual(a, b) {
+			t.Errorf("expansion is not reproducible: %q, %q", a, b)
+		}
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		extra string
+		event string
+		want  string
+	}{
+		{name: "unknown var", extra: "templates: true\n", event: "{{.missing}}", want: "missing"},
+		{name: "unknown function", extra: "templates: true\n", event: "{{nope}}", want: "nope"},
+		{name: "vars refer to each other", extra: "vars:\n  a: \"{{.b}}\"\n  b: \"{{.a}}\"\n", event: "{{.a}}", want: "refers back to itself"},
+		{name: "var shadows matrix", extra: "vars:\n  user: x\nmatrix:\n  user: [a]\n", event: "{{.user}}", want: "both vars and matrix"},
+		{name: "empty matrix", extra: "matrix:\n  user: []\n", event: "x", want: "has no values"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "ruleId: \"1\"\n" + tt.extra + "edges:\n  - title: t\n    log:\n      event: \"" + tt.event + "\"\n"
+			path := filepath.Join(t.TempDir(), "t.yaml")
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandTemplatesOptIn(t *testing.T) {
+	tests := []struct {
+		name  string
+		extra string
+		event string
+		want  string
+	}{
+		{name: "no vars or matrix", event: "GET /?q={{7*7}} HTTP/1.1", want: "GET /?q={{7*7}} HTTP/1.1"},
+		{name: "templates flag", extra: "templates: true\n", event: "{{int 3 3}}", want: "3"},
+		{name: "var refers to a later var", extra: "vars:\n  a: \"{{.z}}-a\"\n  z: \"{{.user}}-z\"\nmatrix:\n  user: [root]\n", event: "{{.a}}", want: "root-z-a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "ruleId: \"1\"\n" + tt.extra + "edges:\n  - title: t\n    log:\n      event: \"" + tt.event + "\"\n"
+			path := filepath.Join(t.TempDir(), "t.yaml")
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			test, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got := test.Edges[0].Log.Event; got != tt.want {
+				t.Errorf("event = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	funcs := Generators(1)
+
+	ip, _ := netip.ParseAddr(funcs["ipv6"].(func() string)())
+	if !netip.MustParsePrefix("2001:db8::/32").Contains(ip) {
+		t.Errorf("ipv6 = %v, want an address in 2001:db8::/32", ip)
+	}
+	for range 50 {
+		ip, err := netip.ParseAddr(funcs["ipv4"].(func() string)())
+		if err != nil || !ip.Is4() || ip.IsPrivate() || ip.IsLoopback() {
+			t.Fatalf("ipv4 = %v, %v", ip, err)
+		}
+	}
+	if n, err := funcs["int"].(func(int, int) (int, error))(3, 3); err != nil || n != 3 {
+		t.Errorf("int(3, 3) = %d, %v", n, err)
+	}
+}
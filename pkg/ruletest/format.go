@@ -0,0 +1,107 @@
+package ruletest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/EpykLab/wazctl/internal/rulexml"
+	"gopkg.in/yaml.v3"
+)
+
+// contentKeys are the test file keys holding ruleset XML.
+var contentKeys = []string{"ruleContent", "decoderContent"}
+
+// HasRuleContent reports whether content is a rule test file with a
+// ruleContent or decoderContent block to format.
+func HasRuleContent(content []byte) bool {
+	var doc map[string]any
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return false
+	}
+	for _, name := range contentKeys {
+		if s, ok := doc[name].(string); ok && strings.TrimSpace(s) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatRuleContent formats the rule and decoder XML in the ruleContent and
+// decoderContent blocks of a rule test file, leaving the rest of the file
+// byte for byte as it was. Blocks that are not literal blocks (|- or |) are
+// left unchanged.
+func FormatRuleContent(content []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return content, nil
+	}
+
+	type block struct {
+		key, value *yaml.Node
+	}
+	var blocks []block
+	root := doc.Content[0]
+	for _, name := range contentKeys {
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == name && root.Content[i+1].Style&yaml.LiteralStyle != 0 {
+				blocks = append(blocks, block{root.Content[i], root.Content[i+1]})
+			}
+		}
+	}
+	// replace from the bottom up so earlier line numbers stay valid
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].key.Line > blocks[j].key.Line })
+
+	lines := strings.Split(string(content), "\n")
+	changed := false
+	for _, b := range blocks {
+		formatted, err := rulexml.Format([]byte(b.value.Value))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", b.key.Value, err)
+		}
+		if strings.TrimRight(string(formatted), "\n") == strings.TrimRight(b.value.Value, "\n") {
+			continue
+		}
+		lines = replaceBlock(lines, b.key, string(formatted))
+		changed = true
+	}
+
+	if !changed {
+		return content, nil
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// replaceBlock replaces the literal block of key with formatted. The block
+// runs from the line after the key to the last non-blank line indented
+// deeper than the key.
+func replaceBlock(lines []string, key *yaml.Node, formatted string) []string {
+	start, end, indent := key.Line, key.Line, ""
+	for i := start; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		if trimmed == "" {
+			continue
+		}
+		width := len(lines[i]) - len(trimmed)
+		if width < key.Column {
+			break
+		}
+		if indent == "" {
+			indent = lines[i][:width]
+		}
+		end = i + 1
+	}
+
+	var block []string
+	for _, line := range strings.Split(strings.TrimRight(formatted, "\n"), "\n") {
+		if line != "" {
+			line = indent + line
+		}
+		block = append(block, line)
+	}
+
+	return append(append(append([]string{}, lines[:start]...), block...), lines[end:]...)
+}
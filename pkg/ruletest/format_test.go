@@ -0,0 +1,89 @@
+package ruletest
+
+import (
+	"testing"
+)
+
+func TestFormatRuleContent(t *testing.T) {
+	content := `# sshd tests
+ruleId: "100010"
+ruleName: sshd
+ruleContent: |-
+  <rule level="5" id="100010">
+      <if_sid>5716</if_sid>
+
+      <description>SSH failure</description></rule>
+decoderContent: |-
+  <decoder name="sshd-x"><parent>sshd</parent>
+        <regex>user (\S+)</regex></decoder>
+description: Root login failures   # kept as is
+edges:
+  - title:   Fails
+    log:
+      event: "sshd[1]: Failed password for root"
+`
+	want := `# sshd tests
+ruleId: "100010"
+ruleName: sshd
+ruleContent: |-
+  <rule id="100010" level="5">
+    <if_sid>5716</if_sid>
+
+    <description>SSH failure</description>
+  </rule>
+decoderContent: |-
+  <decoder name="sshd-x">
+    <parent>sshd</parent>
+    <regex>user (\S+)</regex>
+  </decoder>
+description: Root login failures   # kept as is
+edges:
+  - title:   Fails
+    log:
+      event: "sshd[1]: Failed password for root"
+`
+
+	got, err := FormatRuleContent([]byte(content))
+	if err != nil {
+		t.Fatalf("FormatRuleContent() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("FormatRuleContent() =\n%s\nwant\n%s", got, want)
+	}
+
+	again, _ := FormatRuleContent(got)
+	if string(again) != string(got) {
+		t.Errorf("FormatRuleContent() is not idempotent:\n%s", again)
+	}
+
+	plain := []byte("ruleId: \"1\"\nruleContent: '<rule id=\"1\"/>'\n")
+	if got, _ := FormatRuleContent(plain); string(got) != string(plain) {
+		t.Errorf("FormatRuleContent() rewrote a quoted ruleContent: %s", got)
+	}
+
+	if _, err := FormatRuleContent([]byte("ruleContent: |-\n  <rule>\n")); err == nil {
+		t.Error("FormatRuleContent() accepted malformed rule XML")
+	}
+}
+
+func TestHasRuleContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{name: "rule content", content: "ruleId: \"1\"\nruleContent: |-\n  <rule id=\"1\" level=\"3\"/>\n", want: true},
+		{name: "decoder content", content: "decoder: x\ndecoderContent: <decoder name=\"x\"/>\n", want: true},
+		{name: "no content", content: "ruleId: \"1\"\nedges: []\n", want: false},
+		{name: "other yaml", content: "name: ci\non: [push]\n", want: false},
+		{name: "invalid", content: "a: [", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasRuleContent([]byte(tt.content)); got != tt.want {
+				t.Errorf("HasRuleContent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package ruletest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+	"gopkg.in/yaml.v3"
+)
+
+// EdgeFromAlert freezes an indexed alert as an edge that replays its
+// full_log through logtest and expects the same rule, level and decoder.
+func EdgeFromAlert(docId string, alert *opensearch.Alert) (v1.SchemaJsonEdgesElem, error) {
+	if strings.TrimSpace(alert.FullLog) == "" {
+		return v1.SchemaJsonEdgesElem{}, fmt.Errorf("alert %s has no full_log to replay (events such as eventchannel are not stored as raw logs)", docId)
+	}
+
+	level := alert.Rule.Level
+	edge := v1.SchemaJsonEdgesElem{
+		Title: fmt.Sprintf("Alert %s", docId),
+		Description: strings.TrimSpace(fmt.Sprintf("Frozen from alert %s raised by agent %s at %s: %s",
+			docId, alert.Agent.Name, alert.Timestamp, alert.Rule.Description)),
+		Log: &v1.SchemaJsonEdgesElemLog{
+			Event:     alert.FullLog,
+			Location:  alert.Location,
+			LogFormat: GuessLogFormat(alert.FullLog),
+		},
+		RuleId:          alert.Rule.Id,
+		Level:           &level,
+		ExpectedOutcome: fmt.Sprintf("Rule %s fires with level %d", alert.Rule.Id, level),
+	}
+	if alert.Decoder.Name != "" {
+		edge.Decoder = &v1.SchemaJsonEdgesElemDecoder{Name: alert.Decoder.Name}
+	}
+
+	return edge, nil
+}
+
+// GuessLogFormat picks the logtest log format for a raw event taken from an
+// alert: json for JSON objects, syslog otherwise.
+func GuessLogFormat(event string) string {
+	if strings.HasPrefix(strings.TrimSpace(event), "{") {
+		return "json"
+	}
+	return DefaultLogFormat
+}
+
+// AppendEdge adds edge to the edges of a rule test file, editing the YAML
+// node tree so comments and the order of existing keys are preserved. It
+// refuses to add an edge whose log event is already tested.
+func AppendEdge(content []byte, edge v1.SchemaJsonEdgesElem) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("rule test file is not a YAML mapping")
+	}
+	root := doc.Content[0]
+
+	var edges *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "edges" {
+			edges = root.Content[i+1]
+		}
+	}
+	if edges == nil {
+		edges = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "edges"}, edges)
+	}
+	if edges.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("edges is not a list")
+	}
+
+	var existing []v1.SchemaJsonEdgesElem
+	if err := edges.Decode(&existing); err != nil {
+		return nil, err
+	}
+	if edge.Log != nil {
+		for _, e := range existing {
+			if e.Log != nil && e.Log.Event == edge.Log.Event {
+				return nil, fmt.Errorf("edge %q already replays this log", e.Title)
+			}
+		}
+	}
+
+	var node yaml.Node
+	if err := node.Encode(edge); err != nil {
+		return nil, err
+	}
+	edges.Content = append(edges.Content, &node)
+	// A flow style empty list ("edges: []") cannot hold block mappings
+	edges.Style = 0
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
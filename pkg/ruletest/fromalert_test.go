@@ -0,0 +1,82 @@
+package ruletest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EpykLab/wazctl/pkg/opensearch"
+)
+
+func TestEdgeFromAlert(t *testing.T) {
+	alert := &opensearch.Alert{
+		Timestamp: "2026-10-19T10:00:00.000+0000",
+		FullLog:   `{"event":"login"}`,
+		Location:  "/var/log/app.json",
+	}
+	alert.Rule.Id = "100020"
+	alert.Rule.Level = 7
+	alert.Decoder.Name = "json"
+
+	edge, err := EdgeFromAlert("abc", alert)
+	if err != nil {
+		t.Fatalf("EdgeFromAlert() error = %v", err)
+	}
+	if edge.Log.LogFormat != "json" || edge.Log.Location != "/var/log/app.json" {
+		t.Errorf("log = %+v", edge.Log)
+	}
+	if edge.RuleId != "100020" || *edge.Level != 7 || edge.Decoder.Name != "json" {
+		t.Errorf("edge = %+v", edge)
+	}
+
+	alert.FullLog = ""
+	if _, err := EdgeFromAlert("abc", alert); err == nil {
+		t.Error("EdgeFromAlert() accepted an alert without full_log")
+	}
+}
+
+func TestAppendEdge(t *testing.T) {
+	content := `# regression tests for sshd
+ruleId: "5715"
+ruleName: sshd login
+ruleAuthor: Jane Roe
+description: sshd logins
+edges:
+  - title: Existing
+    description: Existing edge
+    log:
+      event: "old log" # keep me
+    expected_outcome: Rule 5715 fires
+`
+	alert := &opensearch.Alert{FullLog: "new log", Location: "/var/log/auth.log"}
+	alert.Rule.Id = "5715"
+	alert.Rule.Level = 3
+	edge, _ := EdgeFromAlert("abc", alert)
+
+	out, err := AppendEdge([]byte(content), edge)
+	if err != nil {
+		t.Fatalf("AppendEdge() error = %v", err)
+	}
+	for _, want := range []string{"# regression tests for sshd", "# keep me", "title: Alert abc"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("AppendEdge() output lost %q:\n%s", want, out)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "sshd.yaml")
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		t.Fatal(err)
+	}
+	test, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v\n%s", err, out)
+	}
+	if len(test.Edges) != 2 || test.Edges[1].Log.Event != "new log" {
+		t.Errorf("edges = %+v", test.Edges)
+	}
+
+	if _, err := AppendEdge(out, edge); err == nil {
+		t.Error("AppendEdge() added the same log twice")
+	}
+}
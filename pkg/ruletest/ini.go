@@ -0,0 +1,182 @@
+package ruletest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+)
+
+// IniSection is a test case from the Wazuh ruleset's .ini test files, as run
+// by runtests.py.
+type IniSection struct {
+	Name string
+	// Line the section header was read from
+	Line int
+	// Keys in file order with their values
+	Keys   []string
+	Values map[string]string
+}
+
+// ParseIni reads a ruleset .ini test file. Keys are lower-cased; lines
+// starting with '#' or ';' are comments.
+func ParseIni(r io.Reader) ([]IniSection, error) {
+	var sections []IniSection
+	var current *IniSection
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			sections = append(sections, IniSection{
+				Name:   strings.TrimSpace(line[1 : len(line)-1]),
+				Line:   lineNo,
+				Values: make(map[string]string),
+			})
+			current = &sections[len(sections)-1]
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: key outside of a section", lineNo)
+		}
+		key = strings.ToLower(strings.Join(strings.Fields(key), " "))
+		if _, ok := current.Values[key]; !ok {
+			current.Keys = append(current.Keys, key)
+		}
+		current.Values[key] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return sections, nil
+}
+
+// iniLogKey matches "log 1 pass" and "log 2 fail" keys.
+var iniLogKey = regexp.MustCompile(`^log (\d+) (pass|fail)$`)
+
+// iniKnownKeys are the section keys understood besides the log keys.
+var iniKnownKeys = map[string]bool{
+	"rule":       true,
+	"alert":      true,
+	"decoder":    true,
+	"location":   true,
+	"log_format": true,
+}
+
+// FromIni converts the sections of a ruleset .ini file into a rule test.
+// Every log line becomes an edge on the section's rule: "pass" logs expect
+// the rule to fire with the section's alert level, "fail" logs expect it
+// not to fire. Sections or keys that cannot be converted are returned as
+// warnings.
+func FromIni(path string, sections []IniSection, author string) (*v1.SchemaJson, []string) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	test := &v1.SchemaJson{
+		RuleName:    name,
+		RuleAuthor:  author,
+		Description: fmt.Sprintf("Imported from the Wazuh ruleset test %s", filepath.Base(path)),
+	}
+	var warnings []string
+
+	for _, section := range sections {
+		ruleId := section.Values["rule"]
+		if ruleId == "" {
+			warnings = append(warnings, fmt.Sprintf("line %d: [%s] has no rule, skipped", section.Line, section.Name))
+			continue
+		}
+		if test.RuleId == "" {
+			test.RuleId = ruleId
+		}
+
+		var level *int
+		if alert, ok := section.Values["alert"]; ok {
+			n, err := strconv.Atoi(alert)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("line %d: [%s] alert %q is not a level, ignored", section.Line, section.Name, alert))
+			} else {
+				level = &n
+			}
+		}
+		var decoder *v1.SchemaJsonEdgesElemDecoder
+		if d := section.Values["decoder"]; d != "" {
+			decoder = &v1.SchemaJsonEdgesElemDecoder{Name: d}
+		}
+
+		logs := iniLogs(section)
+		for _, key := range section.Keys {
+			if !iniKnownKeys[key] && !iniLogKey.MatchString(key) {
+				warnings = append(warnings, fmt.Sprintf("line %d: [%s] unsupported key %q ignored", section.Line, section.Name, key))
+			}
+		}
+		if len(logs) == 0 {
+			warnings = append(warnings, fmt.Sprintf("line %d: [%s] has no log lines, skipped", section.Line, section.Name))
+			continue
+		}
+
+		for _, l := range logs {
+			edge := v1.SchemaJsonEdgesElem{
+				Title:       section.Name,
+				Description: section.Name,
+				RuleId:      ruleId,
+				Decoder:     decoder,
+				Log: &v1.SchemaJsonEdgesElemLog{
+					Event:     l.event,
+					Location:  section.Values["location"],
+					LogFormat: section.Values["log_format"],
+				},
+			}
+			if len(logs) > 1 {
+				edge.Title = fmt.Sprintf("%s (log %d)", section.Name, l.n)
+			}
+			if l.pass {
+				edge.Level = level
+				edge.ExpectedOutcome = fmt.Sprintf("Rule %s fires", ruleId)
+			} else {
+				edge.Expect = v1.SchemaJsonEdgesElemExpectNotFired
+				edge.ExpectedOutcome = fmt.Sprintf("Rule %s does not fire", ruleId)
+			}
+			test.Edges = append(test.Edges, edge)
+		}
+	}
+
+	return test, warnings
+}
+
+type iniLog struct {
+	n     int
+	pass  bool
+	event string
+}
+
+// iniLogs returns the log lines of a section ordered by their number.
+func iniLogs(section IniSection) []iniLog {
+	var logs []iniLog
+	for _, key := range section.Keys {
+		m := iniLogKey.FindStringSubmatch(key)
+		if m == nil || section.Values[key] == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		logs = append(logs, iniLog{n: n, pass: m[2] == "pass", event: section.Values[key]})
+	}
+	sort.SliceStable(logs, func(i, j int) bool { return logs[i].n < logs[j].n })
+	return logs
+}
@@ -0,0 +1,70 @@
+package ruletest
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+)
+
+const sshdIni = `; sshd tests
+[Successful sshd login]
+log 1 pass = Sep 23 06:30:20 host sshd[1]: Accepted password for root from 10.0.0.5 port 22 ssh2
+log 2 pass = Sep 23 06:30:21 host sshd[1]: Accepted publickey for root from 10.0.0.5 port 22 ssh2
+rule = 5715
+alert = 3
+decoder = sshd
+
+[Not a login]
+log 1 fail = Sep 23 06:30:22 host sshd[1]: Connection closed by 10.0.0.5
+rule = 5715
+program_name = sshd
+
+[Missing rule]
+log 1 pass = nothing
+`
+
+func TestParseIni(t *testing.T) {
+	sections, err := ParseIni(strings.NewReader(sshdIni))
+	if err != nil {
+		t.Fatalf("ParseIni() error = %v", err)
+	}
+	if len(sections) != 3 {
+		t.Fatalf("ParseIni() returned %d sections, want 3", len(sections))
+	}
+	if got := sections[0].Values["log 2 pass"]; !strings.Contains(got, "Accepted publickey") {
+		t.Errorf("log 2 pass = %q", got)
+	}
+	if sections[1].Line != 9 {
+		t.Errorf("section line = %d, want 9", sections[1].Line)
+	}
+
+	if _, err := ParseIni(strings.NewReader("rule = 1\n")); err == nil {
+		t.Error("ParseIni() accepted a key outside of a section")
+	}
+}
+
+func TestFromIni(t *testing.T) {
+	sections, _ := ParseIni(strings.NewReader(sshdIni))
+	test, warnings := FromIni("tests/sshd.ini", sections, "Wazuh")
+
+	if test.RuleId != "5715" || test.RuleName != "sshd" {
+		t.Errorf("FromIni() header = %q %q", test.RuleId, test.RuleName)
+	}
+	if len(test.Edges) != 3 {
+		t.Fatalf("FromIni() returned %d edges, want 3", len(test.Edges))
+	}
+
+	pass := test.Edges[1]
+	if pass.Title != "Successful sshd login (log 2)" || pass.Level == nil || *pass.Level != 3 || pass.Decoder.Name != "sshd" {
+		t.Errorf("pass edge = %+v", pass)
+	}
+	fail := test.Edges[2]
+	if fail.Expect != v1.SchemaJsonEdgesElemExpectNotFired || fail.Level != nil || fail.Title != "Not a login" {
+		t.Errorf("fail edge = %+v", fail)
+	}
+
+	if len(warnings) != 2 {
+		t.Errorf("FromIni() warnings = %q, want program_name and missing rule", warnings)
+	}
+}
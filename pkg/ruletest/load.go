@@ -0,0 +1,148 @@
+package ruletest
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EpykLab/wazctl/internal/files"
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads a rule test file, expands its matrix and templates into
+// individual edges and validates the result.
+func Load(path string) (*v1.SchemaJson, error) {
+	content, err := files.ReadFileFromSpecifiedPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var test v1.SchemaJson
+	if err := yaml.Unmarshal(content, &test); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	if err := Expand(&test, SeedFor(content)); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err := validate(&test); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &test, nil
+}
+
+// validate applies the required-field rules of the JSON schema, which the
+// YAML decoder does not enforce on its own.
+func validate(test *v1.SchemaJson) error {
+	if test.RuleId == "" && test.Decoder == "" {
+		return fmt.Errorf("ruleId or decoder is required")
+	}
+	if len(test.Edges) == 0 {
+		return fmt.Errorf("at least one edge is required")
+	}
+	for i, edge := range test.Edges {
+		if edge.Title == "" {
+			return fmt.Errorf("edge %d: title is required", i+1)
+		}
+		switch edge.Expect {
+		case "", v1.SchemaJsonEdgesElemExpectFired, v1.SchemaJsonEdgesElemExpectNotFired:
+		default:
+			return fmt.Errorf("edge %q: expect must be one of [fired, not_fired], got %q", edge.Title, edge.Expect)
+		}
+		if edge.Level != nil && *edge.Level < 0 {
+			return fmt.Errorf("edge %q: level must not be negative", edge.Title)
+		}
+		if edge.Decoder != nil && edge.Decoder.Name == "" && test.Decoder == "" {
+			return fmt.Errorf("edge %q: decoder name is required", edge.Title)
+		}
+		if edge.Expect != "" && EdgeRuleId(test, edge) == "" {
+			return fmt.Errorf("edge %q: expect requires a rule_id", edge.Title)
+		}
+		if edge.MaxLevel != nil && *edge.MaxLevel < 0 {
+			return fmt.Errorf("edge %q: max_level must not be negative", edge.Title)
+		}
+		if err := validateEvents(edge); err != nil {
+			return fmt.Errorf("edge %q: %w", edge.Title, err)
+		}
+	}
+	return nil
+}
+
+func validateEvents(edge v1.SchemaJsonEdgesElem) error {
+	if edge.Log != nil && len(edge.Events) > 0 {
+		return fmt.Errorf("log and events cannot be used together")
+	}
+	for i, event := range edge.Events {
+		if event.Event == "" {
+			return fmt.Errorf("event %d: event is required", i+1)
+		}
+		if event.Delay != "" {
+			if _, err := time.ParseDuration(event.Delay); err != nil {
+				return fmt.Errorf("event %d: invalid delay %q", i+1, event.Delay)
+			}
+		}
+	}
+	if edge.TriggerEvent != nil {
+		if len(edge.Events) == 0 {
+			return fmt.Errorf("trigger_event requires events")
+		}
+		if n := *edge.TriggerEvent; n < 1 || n > len(edge.Events) {
+			return fmt.Errorf("trigger_event %d is outside the %d events", n, len(edge.Events))
+		}
+	}
+	return nil
+}
+
+// Discover expands paths into rule test files. Directories are walked
+// recursively for .yaml and .yml files, skipping hidden files such as
+// .wazctl.yaml and hidden directories such as .github; files are returned as
+// given.
+func Discover(paths []string) ([]string, error) {
+	var found []string
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			found = append(found, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if p != path && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.IsDir() && IsTestFile(p) {
+				found = append(found, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(found)
+	return found, nil
+}
+
+// IsTestFile reports whether path looks like a rule test file.
+func IsTestFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
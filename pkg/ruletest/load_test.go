@@ -0,0 +1,47 @@
+package ruletest
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"ssh.yaml",
+		"web/apache.yml",
+		"web/notes.txt",
+		".wazctl.yaml",
+		".github/workflows/ci.yaml",
+		"web/.cache/old.yaml",
+	} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Discover([]string{root})
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	want := []string{filepath.Join(root, "ssh.yaml"), filepath.Join(root, "web/apache.yml")}
+	if !slices.Equal(got, want) {
+		t.Errorf("Discover() = %v, want %v", got, want)
+	}
+
+	// hidden directories are walked when given explicitly
+	hidden := filepath.Join(root, ".github")
+	got, err = Discover([]string{hidden})
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	if want := []string{filepath.Join(hidden, "workflows/ci.yaml")}; !slices.Equal(got, want) {
+		t.Errorf("Discover(%s) = %v, want %v", hidden, got, want)
+	}
+}
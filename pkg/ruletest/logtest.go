@@ -0,0 +1,349 @@
+package ruletest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"github.com/EpykLab/wazctl/pkg/actions"
+)
+
+const (
+	// DefaultLocation is reported for log events that do not set one
+	DefaultLocation = "wazctl"
+	// DefaultLogFormat is used for log events that do not set one
+	DefaultLogFormat = "syslog"
+)
+
+// Logtester sends events through the manager's logtest tool.
+type Logtester interface {
+	RunLogtest(req actions.LogtestRequest) (*actions.LogtestResult, error)
+	EndLogtestSession(token string) error
+}
+
+// Session is a logtest session. Events sent through the same session share
+// decoder and rule state, so frequency and composite rules can fire.
+type Session struct {
+	tester Logtester
+	token  string
+}
+
+// NewSession returns a session that is opened by the first event sent.
+func NewSession(tester Logtester) *Session {
+	return &Session{tester: tester}
+}
+
+// Send runs a single log event through the session.
+func (s *Session) Send(log v1.SchemaJsonEdgesElemLog) (*actions.LogtestResult, error) {
+	req := actions.LogtestRequest{
+		Token:     s.token,
+		LogFormat: log.LogFormat,
+		Location:  log.Location,
+		Event:     log.Event,
+	}
+	if req.LogFormat == "" {
+		req.LogFormat = DefaultLogFormat
+	}
+	if req.Location == "" {
+		req.Location = DefaultLocation
+	}
+
+	res, err := s.tester.RunLogtest(req)
+	if err != nil {
+		return nil, err
+	}
+	s.token = res.Token
+	return res, nil
+}
+
+// Reset ends the session on the manager so the next event starts from a
+// clean state.
+func (s *Session) Reset() error {
+	if s.token == "" {
+		return nil
+	}
+	token := s.token
+	s.token = ""
+	return s.tester.EndLogtestSession(token)
+}
+
+// RunLogtest replays the log event, or the event sequence, of every edge in
+// test through session and checks the edge's expectations: by default that
+// the rule fired on the trigger event. Each edge starts from a fresh session. Edges without log
+// events are skipped, and no further edges run once ctx is cancelled.
+func RunLogtest(ctx context.Context, path string, test *v1.SchemaJson, session *Session) []Result {
+	var results []Result
+
+	for _, edge := range test.Edges {
+		if ctx.Err() != nil {
+			break
+		}
+		res := Result{File: path, RuleId: EdgeRuleId(test, edge), Edge: edge.Title}
+
+		outs, err := ReplayEdge(ctx, edge, session)
+		switch {
+		case err != nil:
+			res.Status = StatusFail
+			res.Message = err.Error()
+		case outs == nil:
+			res.Status = StatusSkip
+			res.Message = "edge has no log event"
+		default:
+			res.Status, res.Message = checkEdge(res.RuleId, EdgeDecoder(test, edge), edge, outs)
+		}
+		results = append(results, res)
+	}
+
+	return results
+}
+
+// EdgeEvents returns the events an edge replays through logtest: its event
+// sequence, or its single log event.
+func EdgeEvents(edge v1.SchemaJsonEdgesElem) []v1.SchemaJsonEdgesElemEventsElem {
+	if len(edge.Events) > 0 {
+		return edge.Events
+	}
+	if edge.Log != nil && edge.Log.Event != "" {
+		return []v1.SchemaJsonEdgesElemEventsElem{{
+			Event:     edge.Log.Event,
+			Location:  edge.Log.Location,
+			LogFormat: edge.Log.LogFormat,
+		}}
+	}
+	return nil
+}
+
+// TriggerIndex returns the zero-based position of the event expected to
+// trigger the rule: trigger_event when set, otherwise the last event.
+func TriggerIndex(edge v1.SchemaJsonEdgesElem) int {
+	if edge.TriggerEvent != nil {
+		return *edge.TriggerEvent - 1
+	}
+	return len(EdgeEvents(edge)) - 1
+}
+
+// ReplayEdge sends every event of edge through one session, waiting for each
+// event's delay first, and returns the output of each event. The session is
+// reset afterwards. It returns nil when the edge has no log events.
+func ReplayEdge(ctx context.Context, edge v1.SchemaJsonEdgesElem, session *Session) ([]*actions.LogtestResult, error) {
+	events := EdgeEvents(edge)
+	if len(events) == 0 {
+		return nil, nil
+	}
+	defer session.Reset()
+
+	outs := make([]*actions.LogtestResult, 0, len(events))
+	for i, event := range events {
+		if event.Delay != "" {
+			delay, err := time.ParseDuration(event.Delay)
+			if err != nil {
+				return nil, fmt.Errorf("event %d: invalid delay %q", i+1, event.Delay)
+			}
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		out, err := session.Send(v1.SchemaJsonEdgesElemLog{
+			Event:     event.Event,
+			Location:  event.Location,
+			LogFormat: event.LogFormat,
+		})
+		if err != nil {
+			if len(events) > 1 {
+				return nil, fmt.Errorf("event %d: %w", i+1, err)
+			}
+			return nil, err
+		}
+		outs = append(outs, out)
+	}
+
+	return outs, nil
+}
+
+// EdgeRuleId returns the rule an edge asserts on.
+func EdgeRuleId(test *v1.SchemaJson, edge v1.SchemaJsonEdgesElem) string {
+	if edge.RuleId != "" {
+		return edge.RuleId
+	}
+	return test.RuleId
+}
+
+// EdgeExpect returns what an edge expects of its rule. Edges that only set
+// max_level make no assertion about the rule itself.
+func EdgeExpect(edge v1.SchemaJsonEdgesElem) v1.SchemaJsonEdgesElemExpect {
+	if edge.Expect == "" && edge.MaxLevel != nil {
+		return ""
+	}
+	if edge.Expect == "" {
+		return v1.SchemaJsonEdgesElemExpectFired
+	}
+	return edge.Expect
+}
+
+// EdgeDecoder returns the decoder expectations of an edge, naming the
+// test's decoder when the edge does not. It returns nil when there are none.
+func EdgeDecoder(test *v1.SchemaJson, edge v1.SchemaJsonEdgesElem) *v1.SchemaJsonEdgesElemDecoder {
+	if edge.Decoder == nil && test.Decoder == "" {
+		return nil
+	}
+
+	decoder := v1.SchemaJsonEdgesElemDecoder{Name: test.Decoder}
+	if edge.Decoder != nil {
+		decoder = *edge.Decoder
+		if decoder.Name == "" {
+			decoder.Name = test.Decoder
+		}
+	}
+	return &decoder
+}
+
+// checkEdge applies the decoder, level bound, fired or not fired and level
+// expectations of edge to the output of its events. Edges of a decoder test
+// without a rule id make no assertion about rules unless they set expect.
+func checkEdge(ruleId string, decoder *v1.SchemaJsonEdgesElemDecoder, edge v1.SchemaJsonEdgesElem, outs []*actions.LogtestResult) (Status, string) {
+	trigger := TriggerIndex(edge)
+
+	if decoder != nil {
+		if message := checkDecoder(decoder, outs[trigger]); message != "" {
+			return StatusFail, message + eventSuffix(outs, trigger)
+		}
+	}
+
+	if edge.MaxLevel != nil {
+		for i, out := range outs {
+			if level := out.RuleLevel(); level > *edge.MaxLevel {
+				return StatusFail, fmt.Sprintf("rule %s fired at level %d%s, above max level %d: %s",
+					out.RuleId(), level, eventSuffix(outs, i), *edge.MaxLevel, out.RuleDescription())
+			}
+		}
+	}
+
+	expect := EdgeExpect(edge)
+	if ruleId == "" {
+		expect = ""
+	}
+
+	switch expect {
+	case v1.SchemaJsonEdgesElemExpectFired:
+		status, message := checkRuleFired(ruleId, outs, trigger)
+		if status == StatusPass && edge.Level != nil {
+			if level := outs[trigger].RuleLevel(); level != *edge.Level {
+				return StatusFail, fmt.Sprintf("rule %s fired with level %d%s, expected level %d",
+					ruleId, level, eventSuffix(outs, trigger), *edge.Level)
+			}
+		}
+		return status, message
+	case v1.SchemaJsonEdgesElemExpectNotFired:
+		return checkRuleNotFired(ruleId, outs)
+	default:
+		return StatusPass, ""
+	}
+}
+
+// checkDecoder compares the decoder name, parent and decoded fields of out
+// with decoder, returning a failure message or "" when they match. Fields
+// are looked up under data first, where logtest reports decoded fields,
+// then as a path from the root of the output.
+func checkDecoder(decoder *v1.SchemaJsonEdgesElemDecoder, out *actions.LogtestResult) string {
+	if name := out.DecoderName(); name != decoder.Name {
+		if name == "" {
+			name = "no decoder"
+		}
+		return fmt.Sprintf("expected decoder %s, got %s", decoder.Name, name)
+	}
+
+	if decoder.Parent != "" {
+		parent, _ := out.Lookup("decoder.parent")
+		if got := decodedValue(parent); got != decoder.Parent {
+			return fmt.Sprintf("expected parent decoder %s, got %q", decoder.Parent, got)
+		}
+	}
+
+	names := make([]string, 0, len(decoder.Fields))
+	for name := range decoder.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v, ok := out.Lookup("data." + name)
+		if !ok {
+			v, ok = out.Lookup(name)
+		}
+		if !ok {
+			return fmt.Sprintf("decoder %s did not extract field %s", decoder.Name, name)
+		}
+		if got, want := decodedValue(v), decoder.Fields[name]; got != want {
+			return fmt.Sprintf("decoded field %s is %q, expected %q", name, got, want)
+		}
+	}
+	return ""
+}
+
+// decodedValue renders a value of the logtest output as the string it was
+// decoded from.
+func decodedValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		out, _ := json.Marshal(v)
+		return string(out)
+	}
+}
+
+// checkRuleNotFired passes when ruleId fires on none of the events.
+func checkRuleNotFired(ruleId string, outs []*actions.LogtestResult) (Status, string) {
+	for i, out := range outs {
+		if out.RuleId() == ruleId {
+			return StatusFail, fmt.Sprintf("expected rule %s not to fire, it fired%s (level %d): %s",
+				ruleId, eventSuffix(outs, i), out.RuleLevel(), out.RuleDescription())
+		}
+	}
+	return StatusPass, ""
+}
+
+// eventSuffix names the event at i when the edge replays a sequence.
+func eventSuffix(outs []*actions.LogtestResult, i int) string {
+	if len(outs) > 1 {
+		return fmt.Sprintf(" on event %d", i+1)
+	}
+	return ""
+}
+
+// checkRuleFired passes when ruleId fires on the trigger event and on no
+// earlier event of the sequence.
+func checkRuleFired(ruleId string, outs []*actions.LogtestResult, trigger int) (Status, string) {
+	for i, out := range outs[:trigger] {
+		if out.RuleId() == ruleId {
+			return StatusFail, fmt.Sprintf("rule %s fired early on event %d, expected event %d", ruleId, i+1, trigger+1)
+		}
+	}
+
+	out := outs[trigger]
+	where := eventSuffix(outs, trigger)
+
+	fired := out.RuleId()
+	switch {
+	case fired == ruleId:
+		return StatusPass, ""
+	case fired == "":
+		return StatusFail, fmt.Sprintf("expected rule %s%s, no rule matched", ruleId, where)
+	default:
+		return StatusFail, fmt.Sprintf("expected rule %s%s, got rule %s (level %d): %s",
+			ruleId, where, fired, out.RuleLevel(), out.RuleDescription())
+	}
+}
@@ -0,0 +1,378 @@
+package ruletest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"github.com/EpykLab/wazctl/pkg/actions"
+)
+
+// fakeLogtester fires the rule mapped to an event and records the requests.
+type fakeLogtester struct {
+	mu       sync.Mutex
+	rules    map[string]string
+	decoders map[string]string
+	// Decoded data reported for an event, under output.data
+	data     map[string]map[string]any
+	requests []actions.LogtestRequest
+	ended    []string
+	// Fire "frequency" once an event has been seen this often in a session
+	frequency map[string]int
+	counts    map[string]int
+	sessions  int
+	// Events the manager fails to process
+	failing map[string]bool
+}
+
+func (f *fakeLogtester) RunLogtest(req actions.LogtestRequest) (*actions.LogtestResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req)
+
+	token := req.Token
+	if token == "" {
+		f.sessions++
+		token = fmt.Sprintf("token-%d", f.sessions)
+	}
+	if f.counts == nil {
+		f.counts = make(map[string]int)
+	}
+	f.counts[token+req.Event]++
+	if f.failing[req.Event] {
+		return nil, fmt.Errorf("logtest failed for %q", req.Event)
+	}
+
+	out := map[string]any{}
+	id, ok := f.rules[req.Event]
+	if threshold, stateful := f.frequency[req.Event]; stateful && f.counts[token+req.Event] >= threshold {
+		id, ok = "frequency", true
+	}
+	if ok {
+		out["rule"] = map[string]any{"id": id, "level": float64(5), "description": "fake"}
+	}
+	if name, ok := f.decoders[req.Event]; ok {
+		out["decoder"] = map[string]any{"name": name, "parent": name}
+	}
+	if data, ok := f.data[req.Event]; ok {
+		out["data"] = data
+	}
+	return &actions.LogtestResult{Token: token, Output: out}, nil
+}
+
+func (f *fakeLogtester) EndLogtestSession(token string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ended = append(f.ended, token)
+	return nil
+}
+
+func TestRunLogtest(t *testing.T) {
+	tester := &fakeLogtester{rules: map[string]string{
+		"match":   "100001",
+		"sibling": "100002",
+	}}
+	test := &v1.SchemaJson{
+		RuleId: "100001",
+		Edges: []v1.SchemaJsonEdgesElem{
+			{Title: "pass", Log: &v1.SchemaJsonEdgesElemLog{Event: "match"}},
+			{Title: "wrong rule", Log: &v1.SchemaJsonEdgesElemLog{Event: "sibling", LogFormat: "json"}},
+			{Title: "no match", Log: &v1.SchemaJsonEdgesElemLog{Event: "nothing"}},
+			{Title: "command only", Command: v1.SchemaJsonEdgesElemCommand{Type: "bash", Value: "true"}},
+		},
+	}
+
+	results := RunLogtest(context.Background(), "t.yaml", test, NewSession(tester))
+
+	var got []Status
+	for _, r := range results {
+		got = append(got, r.Status)
+	}
+	want := []Status{StatusPass, StatusFail, StatusFail, StatusSkip}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("RunLogtest() statuses = %v, want %v", got, want)
+	}
+	if !strings.Contains(results[1].Message, "got rule 100002") {
+		t.Errorf("wrong rule message = %q", results[1].Message)
+	}
+
+	if r := tester.requests[0]; r.Location != DefaultLocation || r.LogFormat != DefaultLogFormat || r.Token != "" {
+		t.Errorf("first request = %+v, want defaults and no token", r)
+	}
+	if r := tester.requests[1]; r.LogFormat != "json" || r.Token != "" {
+		t.Errorf("second request = %+v, want json format in a fresh session", r)
+	}
+	if len(tester.ended) != 3 {
+		t.Errorf("ended %d sessions, want 3", len(tester.ended))
+	}
+}
+
+func TestAffected(t *testing.T) {
+	tests := map[string]*v1.SchemaJson{
+		"a.yaml": {RuleId: "100001"},
+		"b.yaml": {RuleId: "100002"},
+		"c.yaml": {RuleId: "100003"},
+	}
+
+	tcs := []struct {
+		name           string
+		changedTests   []string
+		changedRuleIds []string
+		want           []string
+	}{
+		{name: "test file changed", changedTests: []string{"b.yaml"}, want: []string{"b.yaml"}},
+		{name: "rule changed", changedRuleIds: []string{"100003", "100001"}, want: []string{"a.yaml", "c.yaml"}},
+		{name: "both", changedTests: []string{"a.yaml"}, changedRuleIds: []string{"100001", "100002"}, want: []string{"a.yaml", "b.yaml"}},
+		{name: "unknown", changedTests: []string{"z.yaml"}, changedRuleIds: []string{"999"}, want: []string{}},
+	}
+	for _, tt := range tcs {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Affected(tests, tt.changedTests, tt.changedRuleIds); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Affected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunLogtestFiles(t *testing.T) {
+	tester := &fakeLogtester{rules: map[string]string{"match": "100001"}}
+
+	var files []TestFile
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		files = append(files, TestFile{Path: name + ".yaml", Test: &v1.SchemaJson{
+			RuleId: "100001",
+			Edges: []v1.SchemaJsonEdgesElem{
+				{Title: "one", Log: &v1.SchemaJsonEdgesElemLog{Event: "match"}},
+				{Title: "two", Log: &v1.SchemaJsonEdgesElemLog{Event: "match"}},
+			},
+		}})
+	}
+
+	results := RunLogtestFiles(context.Background(), tester, files, RunOptions{Parallel: 4})
+	if len(results) != 16 {
+		t.Fatalf("RunLogtestFiles() returned %d results, want 16", len(results))
+	}
+	for i, r := range results {
+		if want := files[i/2].Path; r.File != want || r.Status != StatusPass {
+			t.Errorf("result %d = %s %s, want %s PASS", i, r.File, r.Status, want)
+		}
+	}
+}
+
+func TestRunLogtestFilesFailFast(t *testing.T) {
+	tester := &fakeLogtester{rules: map[string]string{"match": "100001"}}
+	failing := TestFile{Path: "fail.yaml", Test: &v1.SchemaJson{
+		RuleId: "100001",
+		Edges: []v1.SchemaJsonEdgesElem{
+			{Title: "miss", Log: &v1.SchemaJsonEdgesElemLog{Event: "nothing"}},
+			{Title: "after miss", Log: &v1.SchemaJsonEdgesElemLog{Event: "match"}},
+		},
+	}}
+	passing := TestFile{Path: "pass.yaml", Test: &v1.SchemaJson{
+		RuleId: "100001",
+		Edges:  []v1.SchemaJsonEdgesElem{{Title: "hit", Log: &v1.SchemaJsonEdgesElemLog{Event: "match"}}},
+	}}
+
+	results := RunLogtestFiles(context.Background(), tester, []TestFile{failing, passing, passing}, RunOptions{Parallel: 1, FailFast: true})
+
+	// The failing file finishes its own edges, nothing after it starts
+	if len(results) != 2 || results[0].Status != StatusFail || results[1].File != "fail.yaml" {
+		t.Errorf("RunLogtestFiles() = %+v, want only the failing file", results)
+	}
+}
+
+func TestRunLogtestSequence(t *testing.T) {
+	fail := v1.SchemaJsonEdgesElemEventsElem{Event: "fail"}
+	three := []v1.SchemaJsonEdgesElemEventsElem{fail, fail, {Event: "fail", Delay: "1ms"}}
+	trigger := func(n int) *int { return &n }
+
+	tests := []struct {
+		name        string
+		edge        v1.SchemaJsonEdgesElem
+		wantStatus  Status
+		wantMessage string
+	}{
+		{
+			name:       "fires on last event",
+			edge:       v1.SchemaJsonEdgesElem{Title: "t", Events: three},
+			wantStatus: StatusPass,
+		},
+		{
+			name:       "fires on trigger event",
+			edge:       v1.SchemaJsonEdgesElem{Title: "t", Events: append(three, fail), TriggerEvent: trigger(3)},
+			wantStatus: StatusPass,
+		},
+		{
+			name:        "fires too early",
+			edge:        v1.SchemaJsonEdgesElem{Title: "t", Events: append(three, fail), TriggerEvent: trigger(4)},
+			wantStatus:  StatusFail,
+			wantMessage: "fired early on event 3",
+		},
+		{
+			name:        "never fires",
+			edge:        v1.SchemaJsonEdgesElem{Title: "t", Events: three[:2]},
+			wantStatus:  StatusFail,
+			wantMessage: "on event 2, got rule 100001",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tester := &fakeLogtester{
+				rules:     map[string]string{"fail": "100001"},
+				frequency: map[string]int{"fail": 3},
+			}
+			test := &v1.SchemaJson{RuleId: "frequency", Edges: []v1.SchemaJsonEdgesElem{tt.edge}}
+
+			results := RunLogtest(context.Background(), "t.yaml", test, NewSession(tester))
+			if results[0].Status != tt.wantStatus || !strings.Contains(results[0].Message, tt.wantMessage) {
+				t.Errorf("RunLogtest() = %s %q, want %s %q", results[0].Status, results[0].Message, tt.wantStatus, tt.wantMessage)
+			}
+			if tester.sessions != 1 {
+				t.Errorf("sequence used %d sessions, want 1", tester.sessions)
+			}
+		})
+	}
+}
+
+func TestRunLogtestNegative(t *testing.T) {
+	level := func(n int) *int { return &n }
+	benign := &v1.SchemaJsonEdgesElemLog{Event: "benign"}
+
+	tests := []struct {
+		name        string
+		edge        v1.SchemaJsonEdgesElem
+		wantStatus  Status
+		wantMessage string
+	}{
+		{
+			name:       "other rule fires",
+			edge:       v1.SchemaJsonEdgesElem{Log: benign, RuleId: "100001", Expect: v1.SchemaJsonEdgesElemExpectNotFired},
+			wantStatus: StatusPass,
+		},
+		{
+			name:        "rule fires anyway",
+			edge:        v1.SchemaJsonEdgesElem{Log: benign, Expect: v1.SchemaJsonEdgesElemExpectNotFired},
+			wantStatus:  StatusFail,
+			wantMessage: "expected rule 5716 not to fire, it fired (level 5)",
+		},
+		{
+			name:       "level and decoder match",
+			edge:       v1.SchemaJsonEdgesElem{Log: benign, Level: level(5), Decoder: &v1.SchemaJsonEdgesElemDecoder{Name: "sshd"}},
+			wantStatus: StatusPass,
+		},
+		{
+			name:        "wrong level",
+			edge:        v1.SchemaJsonEdgesElem{Log: benign, Level: level(3)},
+			wantStatus:  StatusFail,
+			wantMessage: "fired with level 5, expected level 3",
+		},
+		{
+			name:        "wrong decoder",
+			edge:        v1.SchemaJsonEdgesElem{Log: benign, Decoder: &v1.SchemaJsonEdgesElemDecoder{Name: "pam"}},
+			wantStatus:  StatusFail,
+			wantMessage: "expected decoder pam, got sshd",
+		},
+		{
+			name:       "below max level",
+			edge:       v1.SchemaJsonEdgesElem{Log: benign, MaxLevel: level(5)},
+			wantStatus: StatusPass,
+		},
+		{
+			name:        "above max level",
+			edge:        v1.SchemaJsonEdgesElem{Log: benign, MaxLevel: level(3)},
+			wantStatus:  StatusFail,
+			wantMessage: "rule 5716 fired at level 5, above max level 3",
+		},
+		{
+			name: "sequence above max level",
+			edge: v1.SchemaJsonEdgesElem{
+				Events:   []v1.SchemaJsonEdgesElemEventsElem{{Event: "quiet"}, {Event: "benign"}},
+				RuleId:   "5716",
+				Expect:   v1.SchemaJsonEdgesElemExpectNotFired,
+				MaxLevel: level(0),
+			},
+			wantStatus:  StatusFail,
+			wantMessage: "fired at level 5 on event 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tester := &fakeLogtester{
+				rules:    map[string]string{"benign": "5716"},
+				decoders: map[string]string{"benign": "sshd"},
+			}
+			tt.edge.Title = tt.name
+			test := &v1.SchemaJson{RuleId: "5716", Edges: []v1.SchemaJsonEdgesElem{tt.edge}}
+
+			results := RunLogtest(context.Background(), "t.yaml", test, NewSession(tester))
+			if results[0].Status != tt.wantStatus || !strings.Contains(results[0].Message, tt.wantMessage) {
+				t.Errorf("RunLogtest() = %s %q, want %s %q", results[0].Status, results[0].Message, tt.wantStatus, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestRunLogtestDecoder(t *testing.T) {
+	tester := &fakeLogtester{
+		rules:    map[string]string{"login": "5715"},
+		decoders: map[string]string{"login": "sshd"},
+		data: map[string]map[string]any{
+			"login": {"srcip": "10.0.0.5", "srcuser": "root", "srcport": float64(22)},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		decoder     *v1.SchemaJsonEdgesElemDecoder
+		wantStatus  Status
+		wantMessage string
+	}{
+		{name: "test decoder by default", wantStatus: StatusPass},
+		{
+			name:       "fields and parent match",
+			decoder:    &v1.SchemaJsonEdgesElemDecoder{Parent: "sshd", Fields: map[string]string{"srcip": "10.0.0.5", "srcport": "22", "decoder.name": "sshd"}},
+			wantStatus: StatusPass,
+		},
+		{
+			name:        "wrong value",
+			decoder:     &v1.SchemaJsonEdgesElemDecoder{Fields: map[string]string{"srcuser": "admin"}},
+			wantStatus:  StatusFail,
+			wantMessage: `decoded field srcuser is "root", expected "admin"`,
+		},
+		{
+			name:        "missing field",
+			decoder:     &v1.SchemaJsonEdgesElemDecoder{Fields: map[string]string{"dstuser": "root"}},
+			wantStatus:  StatusFail,
+			wantMessage: "decoder sshd did not extract field dstuser",
+		},
+		{
+			name:        "wrong decoder",
+			decoder:     &v1.SchemaJsonEdgesElemDecoder{Name: "pam"},
+			wantStatus:  StatusFail,
+			wantMessage: "expected decoder pam, got sshd",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No ruleId: whichever rule fires is irrelevant to a decoder test
+			test := &v1.SchemaJson{Decoder: "sshd", Edges: []v1.SchemaJsonEdgesElem{{
+				Title:   tt.name,
+				Log:     &v1.SchemaJsonEdgesElemLog{Event: "login"},
+				Decoder: tt.decoder,
+			}}}
+
+			results := RunLogtest(context.Background(), "sshd.yaml", test, NewSession(tester))
+			if len(results) != 1 {
+				t.Fatalf("RunLogtest() returned %d results", len(results))
+			}
+			if results[0].Status != tt.wantStatus || !strings.Contains(results[0].Message, tt.wantMessage) {
+				t.Errorf("result = %s %q, want %s %q", results[0].Status, results[0].Message, tt.wantStatus, tt.wantMessage)
+			}
+		})
+	}
+}
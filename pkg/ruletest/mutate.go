@@ -0,0 +1,112 @@
+package ruletest
+
+import (
+	"context"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"github.com/EpykLab/wazctl/pkg/mutate"
+)
+
+// MutationResult records whether a mutated edge still triggers its rule.
+type MutationResult struct {
+	File     string `json:"file"`
+	RuleId   string `json:"rule_id"`
+	Edge     string `json:"edge"`
+	Mutation string `json:"mutation"`
+	// The mutated trigger event
+	Event string `json:"event"`
+	// Whether the rule no longer fires
+	Evaded bool `json:"evaded"`
+	// Rule that matched the mutated event instead, if any
+	FiredInstead string `json:"fired_instead,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// RunMutations applies every mutator to the events of each edge of test that
+// expects its rule to fire and passes unmutated, and reports which mutations
+// stop the rule from firing. Edges that do not pass as written are skipped.
+func RunMutations(ctx context.Context, path string, test *v1.SchemaJson, session *Session, mutators []mutate.Mutator) []MutationResult {
+	var results []MutationResult
+
+	for _, edge := range test.Edges {
+		if ctx.Err() != nil {
+			break
+		}
+		if EdgeExpect(edge) != v1.SchemaJsonEdgesElemExpectFired {
+			continue
+		}
+		ruleId := EdgeRuleId(test, edge)
+		if ruleId == "" {
+			// decoder tests have no rule to evade
+			continue
+		}
+
+		outs, err := ReplayEdge(ctx, edge, session)
+		if err != nil || outs == nil {
+			continue
+		}
+		if status, _ := checkRuleFired(ruleId, outs, TriggerIndex(edge)); status != StatusPass {
+			continue
+		}
+
+		for _, m := range mutators {
+			mutated, ok := mutateEdge(edge, m)
+			if !ok {
+				continue
+			}
+			res := MutationResult{
+				File:     path,
+				RuleId:   ruleId,
+				Edge:     edge.Title,
+				Mutation: m.Name,
+				Event:    mutated.Events[TriggerIndex(mutated)].Event,
+			}
+
+			outs, err := ReplayEdge(ctx, mutated, session)
+			if err != nil {
+				res.Error = err.Error()
+				results = append(results, res)
+				continue
+			}
+
+			// only the trigger event counts; the rule firing on an earlier
+			// event of a sequence does not catch the mutated trigger
+			fired := outs[TriggerIndex(mutated)].RuleId()
+			res.Evaded = fired != ruleId
+			if res.Evaded {
+				res.FiredInstead = fired
+			}
+			results = append(results, res)
+		}
+	}
+
+	return results
+}
+
+// mutateEdge returns a copy of edge with m applied to every event. It
+// reports false when m changes none of them.
+func mutateEdge(edge v1.SchemaJsonEdgesElem, m mutate.Mutator) (v1.SchemaJsonEdgesElem, bool) {
+	events := EdgeEvents(edge)
+	mutated := make([]v1.SchemaJsonEdgesElemEventsElem, len(events))
+	applied := false
+	for i, event := range events {
+		mutated[i] = event
+		if out, ok := m.Apply(event.Event); ok && out != "" {
+			mutated[i].Event = out
+			applied = true
+		}
+	}
+
+	trigger := TriggerIndex(edge) + 1
+	edge.Log = nil
+	edge.Events = mutated
+	edge.TriggerEvent = &trigger
+	return edge, applied
+}
+
+// RunMutationFiles runs RunMutations on the worker pool described by opts.
+func RunMutationFiles(ctx context.Context, tester Logtester, files []TestFile, opts RunOptions, mutators []mutate.Mutator) []MutationResult {
+	return RunPool(ctx, tester, files, opts, func(ctx context.Context, file TestFile, session *Session) []MutationResult {
+		return RunMutations(ctx, file.Path, file.Test, session, mutators)
+	}, func([]MutationResult) bool { return false })
+}
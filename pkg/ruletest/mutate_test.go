@@ -0,0 +1,78 @@
+package ruletest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"github.com/EpykLab/wazctl/pkg/mutate"
+)
+
+func TestRunMutations(t *testing.T) {
+	tester := &fakeLogtester{rules: map[string]string{
+		"Failed password for root": "5716",
+		"FAILED PASSWORD FOR ROOT": "5716",
+		"failed password for root": "5710",
+	}}
+	test := &v1.SchemaJson{
+		RuleId: "5716",
+		Edges: []v1.SchemaJsonEdgesElem{
+			{Title: "root", Log: &v1.SchemaJsonEdgesElemLog{Event: "Failed password for root"}},
+			{Title: "not passing", Log: &v1.SchemaJsonEdgesElemLog{Event: "unknown"}},
+			{Title: "negative", Log: &v1.SchemaJsonEdgesElemLog{Event: "Failed password for root"}, Expect: v1.SchemaJsonEdgesElemExpectNotFired},
+		},
+	}
+	mutators, _ := mutate.Find([]string{"uppercase", "lowercase", "ipv6", "truncate"})
+
+	results := RunMutations(context.Background(), "t.yaml", test, NewSession(tester), mutators)
+
+	type outcome struct {
+		mutation string
+		evaded   bool
+		instead  string
+	}
+	var got []outcome
+	for _, r := range results {
+		if r.Edge != "root" {
+			t.Errorf("mutated edge %q, want only the passing positive edge", r.Edge)
+		}
+		got = append(got, outcome{r.Mutation, r.Evaded, r.FiredInstead})
+	}
+	want := []outcome{
+		{"uppercase", false, ""},
+		{"lowercase", true, "5710"},
+		{"truncate", true, ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RunMutations() = %v, want %v", got, want)
+	}
+}
+
+func TestRunMutationsSequence(t *testing.T) {
+	// once uppercased the first event fires the rule and the trigger does not
+	tester := &fakeLogtester{rules: map[string]string{
+		"SETUP":                    "5716",
+		"Failed password for root": "5716",
+		"FAILED PASSWORD FOR ROOT": "5710",
+	}}
+	test := &v1.SchemaJson{
+		RuleId: "5716",
+		Edges: []v1.SchemaJsonEdgesElem{{
+			Title: "sequence",
+			Events: []v1.SchemaJsonEdgesElemEventsElem{
+				{Event: "setup"},
+				{Event: "Failed password for root"},
+			},
+		}},
+	}
+	mutators, _ := mutate.Find([]string{"uppercase"})
+
+	results := RunMutations(context.Background(), "t.yaml", test, NewSession(tester), mutators)
+	if len(results) != 1 {
+		t.Fatalf("RunMutations() = %+v, want one result", results)
+	}
+	if r := results[0]; !r.Evaded || r.FiredInstead != "5710" {
+		t.Errorf("RunMutations() = evaded %v instead %q, want the trigger event to evade to 5710", r.Evaded, r.FiredInstead)
+	}
+}
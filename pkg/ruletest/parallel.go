@@ -0,0 +1,81 @@
+package ruletest
+
+import (
+	"context"
+	"sync"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+)
+
+// TestFile is a loaded rule test file.
+type TestFile struct {
+	Path string
+	Test *v1.SchemaJson
+}
+
+// RunOptions controls how a set of test files is run.
+type RunOptions struct {
+	// Number of files run concurrently, each worker with its own session
+	Parallel int
+	// Stop starting new files and edges after the first failure
+	FailFast bool
+}
+
+// RunLogtestFiles runs files through logtest with a bounded pool of workers.
+// Every worker owns a logtest session so stateful rules in one file cannot
+// see events from another. Results are returned in the order of files no
+// matter which worker finished first; files that never ran because of
+// cancellation or --fail-fast contribute no results.
+func RunLogtestFiles(ctx context.Context, tester Logtester, files []TestFile, opts RunOptions) []Result {
+	return RunPool(ctx, tester, files, opts, func(ctx context.Context, file TestFile, session *Session) []Result {
+		return RunLogtest(ctx, file.Path, file.Test, session)
+	}, Failed)
+}
+
+// RunPool runs fn for every item on the worker pool described by opts, each
+// worker with its own logtest session, and returns the results in the order
+// of items. failed decides whether an item's results stop the run under
+// FailFast; it may be nil when FailFast is never set.
+func RunPool[T, R any](ctx context.Context, tester Logtester, items []T, opts RunOptions, fn func(context.Context, T, *Session) []R, failed func([]R) bool) []R {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := max(1, min(opts.Parallel, len(items)))
+	perItem := make([][]R, len(items))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session := NewSession(tester)
+			defer session.Reset()
+
+			for i := range jobs {
+				results := fn(ctx, items[i], session)
+				perItem[i] = results
+				if opts.FailFast && failed(results) {
+					cancel()
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range items {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	var results []R
+	for _, r := range perItem {
+		results = append(results, r...)
+	}
+	return results
+}
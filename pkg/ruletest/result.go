@@ -0,0 +1,32 @@
+package ruletest
+
+// Status is the outcome of a single edge.
+type Status string
+
+const (
+	StatusPass Status = "PASS"
+	StatusFail Status = "FAIL"
+	StatusSkip Status = "SKIP"
+)
+
+// Result records the outcome of running one edge of a rule test file.
+type Result struct {
+	File   string `json:"file"`
+	RuleId string `json:"rule_id"`
+	Edge   string `json:"edge"`
+	Status Status `json:"status"`
+	// Explanation for failed or skipped edges
+	Message string `json:"message,omitempty"`
+	// Line diff against the golden file for failed snapshot edges
+	Diff []string `json:"diff,omitempty"`
+}
+
+// Failed reports whether any result failed.
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,311 @@
+package ruletest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"github.com/EpykLab/wazctl/pkg/actions"
+)
+
+// volatileFields are removed from logtest output before it is compared,
+// because they change on every run or between managers.
+var volatileFields = []string{
+	"timestamp",
+	"id",
+	"predecoder.timestamp",
+	"manager.name",
+	"agent.name",
+}
+
+// Snapshot is the recorded logtest output of a single edge.
+type Snapshot struct {
+	Alert  bool           `json:"alert"`
+	Output map[string]any `json:"output"`
+}
+
+// NewSnapshot normalises a logtest result into a Snapshot.
+func NewSnapshot(res *actions.LogtestResult) Snapshot {
+	return Snapshot{Alert: res.Alert, Output: Normalize(res.Output)}
+}
+
+// Normalize returns a copy of output without the volatile fields.
+func Normalize(output map[string]any) map[string]any {
+	out := deepCopy(output).(map[string]any)
+	for _, path := range volatileFields {
+		deletePath(out, strings.Split(path, "."))
+	}
+	return out
+}
+
+func deepCopy(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(t))
+		for k, v := range t {
+			m[k] = deepCopy(v)
+		}
+		return m
+	case []any:
+		s := make([]any, len(t))
+		for i, v := range t {
+			s[i] = deepCopy(v)
+		}
+		return s
+	case nil:
+		return map[string]any{}
+	default:
+		return t
+	}
+}
+
+func deletePath(m map[string]any, path []string) {
+	if len(path) == 1 {
+		delete(m, path[0])
+		return
+	}
+	if child, ok := m[path[0]].(map[string]any); ok {
+		deletePath(child, path[1:])
+	}
+}
+
+// GoldenPath returns the golden file kept next to a test file, e.g.
+// tests/ssh.yaml is recorded in tests/ssh.golden.json.
+func GoldenPath(testPath string) string {
+	return strings.TrimSuffix(testPath, filepath.Ext(testPath)) + ".golden.json"
+}
+
+// LoadGolden reads the snapshots recorded for a test file, keyed by edge.
+// A missing golden file yields an empty map.
+func LoadGolden(path string) (map[string]Snapshot, error) {
+	golden := make(map[string]Snapshot)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return golden, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &golden); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return golden, nil
+}
+
+// WriteGolden records snapshots keyed by edge, sorted for stable diffs.
+func WriteGolden(path string, snapshots map[string]Snapshot) error {
+	content, err := json.MarshalIndent(snapshots, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// edgeKeys names each edge in a golden file by its title, numbering
+// repeated titles so every edge keeps its own snapshot.
+func edgeKeys(edges []v1.SchemaJsonEdgesElem) []string {
+	keys := make([]string, len(edges))
+	seen := make(map[string]int)
+	for i, edge := range edges {
+		seen[edge.Title]++
+		keys[i] = edge.Title
+		if n := seen[edge.Title]; n > 1 {
+			keys[i] = fmt.Sprintf("%s #%d", edge.Title, n)
+		}
+	}
+	return keys
+}
+
+// RunSnapshot replays every edge of test and compares the normalised output
+// with the file's golden snapshots. Edges without a recorded snapshot are
+// recorded; with update every snapshot is rewritten and snapshots of edges
+// that no longer exist are dropped, while edges that fail to replay keep
+// their previous snapshot.
+func RunSnapshot(ctx context.Context, path string, test *v1.SchemaJson, session *Session, update bool) []Result {
+	goldenPath := GoldenPath(path)
+	golden, err := LoadGolden(goldenPath)
+	if err != nil {
+		return []Result{{File: path, RuleId: test.RuleId, Status: StatusFail, Message: err.Error()}}
+	}
+
+	recorded := make(map[string]Snapshot)
+	if !update {
+		for k, v := range golden {
+			recorded[k] = v
+		}
+	}
+
+	var results []Result
+	changed := update
+	keys := edgeKeys(test.Edges)
+	for i, edge := range test.Edges {
+		if ctx.Err() != nil {
+			break
+		}
+		res := Result{File: path, RuleId: test.RuleId, Edge: edge.Title}
+
+		outs, err := ReplayEdge(ctx, edge, session)
+		if err != nil {
+			res.Status = StatusFail
+			res.Message = err.Error()
+			if update && keepSnapshots(recorded, golden, keys[i]) {
+				res.Message += " (previous snapshot kept)"
+			}
+			results = append(results, res)
+			continue
+		}
+		if outs == nil {
+			res.Status = StatusSkip
+			res.Message = "edge has no log event"
+			results = append(results, res)
+			continue
+		}
+
+		res.Status = StatusPass
+		written := false
+		for n, out := range outs {
+			key := keys[i]
+			if len(outs) > 1 {
+				// Every event of a sequence keeps its own snapshot
+				key = fmt.Sprintf("%s [event %d]", keys[i], n+1)
+			}
+
+			got := NewSnapshot(out)
+			want, ok := golden[key]
+			switch {
+			case update:
+				recorded[key] = got
+			case !ok:
+				recorded[key] = got
+				changed, written = true, true
+			default:
+				if diff := DiffLines(snapshotLines(want), snapshotLines(got)); diff != nil {
+					res.Status = StatusFail
+					res.Message = "output differs from snapshot"
+					if len(outs) > 1 {
+						diff = append([]string{fmt.Sprintf("@ event %d", n+1)}, diff...)
+					}
+					res.Diff = append(res.Diff, diff...)
+				}
+			}
+		}
+		switch {
+		case update:
+			res.Message = "snapshot updated"
+		case written && res.Status == StatusPass:
+			res.Message = "snapshot written"
+		}
+		results = append(results, res)
+	}
+
+	if changed && ctx.Err() == nil {
+		if err := WriteGolden(goldenPath, recorded); err != nil {
+			results = append(results, Result{File: path, RuleId: test.RuleId, Status: StatusFail, Message: err.Error()})
+		}
+	}
+
+	return results
+}
+
+// keepSnapshots copies the golden snapshots of the edge named key, including
+// those of each event of a sequence, into recorded. It reports whether any
+// were found.
+func keepSnapshots(recorded, golden map[string]Snapshot, key string) bool {
+	kept := false
+	for k, v := range golden {
+		if k == key || strings.HasPrefix(k, key+" [event ") {
+			recorded[k] = v
+			kept = true
+		}
+	}
+	return kept
+}
+
+// RunSnapshotFiles runs RunSnapshot on the worker pool described by opts.
+func RunSnapshotFiles(ctx context.Context, tester Logtester, files []TestFile, opts RunOptions, update bool) []Result {
+	return RunPool(ctx, tester, files, opts, func(ctx context.Context, file TestFile, session *Session) []Result {
+		return RunSnapshot(ctx, file.Path, file.Test, session, update)
+	}, Failed)
+}
+
+func snapshotLines(s Snapshot) []string {
+	content, _ := json.MarshalIndent(s, "", "  ")
+	return strings.Split(string(content), "\n")
+}
+
+// diffContext is the number of unchanged lines kept around each change.
+const diffContext = 2
+
+// DiffLines returns a line diff turning a into b, with removed lines
+// prefixed "- ", added lines "+ " and unchanged lines "  ". Unchanged lines
+// far from any change are elided as "  ...". It returns nil when both are
+// equal.
+func DiffLines(a, b []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []string
+	changed := false
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			diff = append(diff, "  "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff = append(diff, "- "+a[i])
+			changed = true
+			i++
+		default:
+			diff = append(diff, "+ "+b[j])
+			changed = true
+			j++
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return trimContext(diff)
+}
+
+func trimContext(diff []string) []string {
+	keep := make([]bool, len(diff))
+	for i, line := range diff {
+		if strings.HasPrefix(line, "  ") {
+			continue
+		}
+		for j := max(0, i-diffContext); j <= min(len(diff)-1, i+diffContext); j++ {
+			keep[j] = true
+		}
+	}
+
+	var out []string
+	for i, line := range diff {
+		switch {
+		case keep[i]:
+			out = append(out, line)
+		case len(out) == 0 || out[len(out)-1] != "  ...":
+			out = append(out, "  ...")
+		}
+	}
+	return out
+}
@@ -0,0 +1,113 @@
+package ruletest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+	"github.com/EpykLab/wazctl/pkg/actions"
+)
+
+func TestNormalize(t *testing.T) {
+	output := map[string]any{
+		"timestamp":  "2026-10-19T10:00:00.000+0000",
+		"id":         "1760000000.1234",
+		"manager":    map[string]any{"name": "wazuh-dev"},
+		"predecoder": map[string]any{"timestamp": "Oct 19 10:00:00", "program_name": "sshd"},
+		"decoder":    map[string]any{"name": "sshd"},
+	}
+	want := map[string]any{
+		"manager":    map[string]any{},
+		"predecoder": map[string]any{"program_name": "sshd"},
+		"decoder":    map[string]any{"name": "sshd"},
+	}
+	if got := Normalize(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("Normalize() = %v, want %v", got, want)
+	}
+	if _, ok := output["timestamp"]; !ok {
+		t.Error("Normalize() modified its input")
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"{", "a", "b", "c", "d", "e", "f", "g", "}"}
+	b := []string{"{", "a", "b", "c", "d", "e", "F", "g", "}"}
+
+	want := []string{"  ...", "  d", "  e", "- f", "+ F", "  g", "  }"}
+	if got := DiffLines(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffLines() = %q, want %q", got, want)
+	}
+	if got := DiffLines(a, a); got != nil {
+		t.Errorf("DiffLines(equal) = %q, want nil", got)
+	}
+}
+
+func TestRunSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ssh.yaml")
+	test := &v1.SchemaJson{
+		RuleId: "100001",
+		Edges: []v1.SchemaJsonEdgesElem{
+			{Title: "edge", Log: &v1.SchemaJsonEdgesElemLog{Event: "match"}},
+			{Title: "edge", Log: &v1.SchemaJsonEdgesElemLog{Event: "other"}},
+		},
+	}
+	run := func(tester *fakeLogtester, update bool) []Result {
+		return RunSnapshot(context.Background(), path, test, NewSession(tester), update)
+	}
+	statuses := func(results []Result) []Status {
+		var s []Status
+		for _, r := range results {
+			s = append(s, r.Status)
+		}
+		return s
+	}
+
+	tester := &fakeLogtester{rules: map[string]string{"match": "100001", "other": "100002"}}
+	if got := statuses(run(tester, false)); !reflect.DeepEqual(got, []Status{StatusPass, StatusPass}) {
+		t.Fatalf("first run = %v, want snapshots written", got)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "ssh.golden.json")); err != nil {
+		t.Fatalf("golden file not written: %v", err)
+	}
+
+	changed := &fakeLogtester{rules: map[string]string{"match": "100001", "other": "100003"}}
+	results := run(changed, false)
+	if got := statuses(results); !reflect.DeepEqual(got, []Status{StatusPass, StatusFail}) {
+		t.Fatalf("changed run = %v, want second edge to fail", got)
+	}
+	if len(results[1].Diff) == 0 {
+		t.Error("failed snapshot has no diff")
+	}
+
+	run(changed, true)
+	if got := statuses(run(changed, false)); !reflect.DeepEqual(got, []Status{StatusPass, StatusPass}) {
+		t.Errorf("run after --update = %v, want all to pass", got)
+	}
+
+	broken := &fakeLogtester{rules: changed.rules, failing: map[string]bool{"other": true}}
+	results = run(broken, true)
+	if got := statuses(results); !reflect.DeepEqual(got, []Status{StatusPass, StatusFail}) {
+		t.Fatalf("--update with a failing edge = %v, want second edge to fail", got)
+	}
+	if !strings.Contains(results[1].Message, "previous snapshot kept") {
+		t.Errorf("failing edge message = %q", results[1].Message)
+	}
+	golden, err := LoadGolden(GoldenPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := golden["edge #2"]; !ok {
+		t.Errorf("snapshot of the failing edge was dropped: %v", golden)
+	}
+}
+
+func TestNewSnapshotKeepsAlert(t *testing.T) {
+	got := NewSnapshot(&actions.LogtestResult{Alert: true, Output: map[string]any{"id": "1"}})
+	if !got.Alert || len(got.Output) != 0 {
+		t.Errorf("NewSnapshot() = %+v", got)
+	}
+}
@@ -0,0 +1,42 @@
+package ruletest
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	v1 "github.com/EpykLab/wazctl/models/schemas/rules/v1"
+)
+
+// IsRuleFile reports whether path looks like a rule XML file.
+func IsRuleFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".xml")
+}
+
+// Affected returns the tests that must run again after a change: the test
+// files that changed themselves and every test referring to one of the
+// changed rule ids. The result is sorted.
+func Affected(tests map[string]*v1.SchemaJson, changedTests, changedRuleIds []string) []string {
+	ruleIds := toSet(changedRuleIds)
+	selected := make(map[string]bool)
+
+	for _, path := range changedTests {
+		if _, ok := tests[path]; ok {
+			selected[path] = true
+		}
+	}
+	for path, test := range tests {
+		for _, id := range ReferencedRuleIds(test) {
+			if ruleIds[id] {
+				selected[path] = true
+			}
+		}
+	}
+
+	out := make([]string, 0, len(selected))
+	for path := range selected {
+		out = append(out, path)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,223 @@
+package ruletree
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EpykLab/wazctl/pkg/actions"
+)
+
+// parentKeys are the rule options placing a rule below other rules, in the
+// order they are shown.
+var parentKeys = []string{"if_sid", "if_matched_sid", "if_group", "if_matched_group"}
+
+// Ref is a rule shown in an ancestry or child listing.
+type Ref struct {
+	Id          int    `json:"id"`
+	Level       int    `json:"level"`
+	Description string `json:"description"`
+	File        string `json:"file"`
+}
+
+// Node is a parent of a rule reached through one of its if_ options.
+type Node struct {
+	// Option linking the child to this parent, such as if_sid or if_group
+	Via string `json:"via"`
+	// Rule id or group named by the option
+	Value string `json:"value"`
+	// Parent rule for sid options, nil when the manager has no such rule
+	Rule *Ref `json:"rule,omitempty"`
+	// Rules in the group for group options; the first MaxWalkedMembers are
+	// also walked as "member" nodes in Parents
+	Members []Ref `json:"members,omitempty"`
+	// Set when the rule already appears below this node
+	Cycle bool `json:"cycle,omitempty"`
+	// Set when the rule's parents are already shown elsewhere in the tree
+	Seen    bool   `json:"seen,omitempty"`
+	Parents []Node `json:"parents,omitempty"`
+}
+
+// MaxWalkedMembers is how many rules of a group parent have their ancestry
+// walked; groups such as authentication_failed have hundreds of members.
+const MaxWalkedMembers = 5
+
+// Child is a rule evaluated below another rule.
+type Child struct {
+	Ref
+	Via   string `json:"via"`
+	Value string `json:"value"`
+}
+
+// Tree indexes a rule catalogue by id and group.
+type Tree struct {
+	rules   map[int]actions.WazuhRule
+	byGroup map[string][]int
+}
+
+// New indexes rules, usually the manager's full catalogue.
+func New(rules []actions.WazuhRule) *Tree {
+	t := &Tree{
+		rules:   make(map[int]actions.WazuhRule, len(rules)),
+		byGroup: make(map[string][]int),
+	}
+	for _, r := range rules {
+		t.rules[r.Id] = r
+		for _, g := range r.Groups {
+			t.byGroup[g] = append(t.byGroup[g], r.Id)
+		}
+	}
+	for _, ids := range t.byGroup {
+		sort.Ints(ids)
+	}
+	return t
+}
+
+// Rule returns the rule with id.
+func (t *Tree) Rule(id int) (actions.WazuhRule, bool) {
+	r, ok := t.rules[id]
+	return r, ok
+}
+
+// Ancestry returns the parents of rule id, each with its own parents up to
+// the rules that have none. Groups are resolved to their member rules, the
+// first MaxWalkedMembers of which are walked in turn. Every rule's parents
+// are shown once: a rule already on the path from id is marked as a cycle
+// and a rule already walked elsewhere as seen.
+func (t *Tree) Ancestry(id int) []Node {
+	w := &walker{tree: t, path: map[int]bool{id: true}, seen: map[int]bool{id: true}}
+	return w.parents(id)
+}
+
+// walker walks the ancestry of one rule.
+type walker struct {
+	tree *Tree
+	// Rules between the walked rule and the current node
+	path map[int]bool
+	// Rules whose parents have been walked
+	seen map[int]bool
+}
+
+func (w *walker) parents(id int) []Node {
+	rule, ok := w.tree.rules[id]
+	if !ok {
+		return nil
+	}
+
+	var nodes []Node
+	for _, key := range parentKeys {
+		for _, value := range Option(rule, key) {
+			node := Node{Via: key, Value: value}
+
+			if strings.HasSuffix(key, "_group") {
+				for i, member := range w.tree.byGroup[value] {
+					node.Members = append(node.Members, w.tree.ref(member))
+					if i < MaxWalkedMembers {
+						node.Parents = append(node.Parents, w.walk(Node{Via: "member", Value: strconv.Itoa(member)}, member))
+					}
+				}
+				nodes = append(nodes, node)
+				continue
+			}
+
+			parent, err := strconv.Atoi(value)
+			if _, known := w.tree.rules[parent]; err != nil || !known {
+				nodes = append(nodes, node)
+				continue
+			}
+			nodes = append(nodes, w.walk(node, parent))
+		}
+	}
+	return nodes
+}
+
+// walk completes node with the parent rule it leads to and, the first time
+// the rule is reached, that rule's own parents.
+func (w *walker) walk(node Node, parent int) Node {
+	ref := w.tree.ref(parent)
+	node.Rule = &ref
+	switch {
+	case w.path[parent]:
+		node.Cycle = true
+		return node
+	case w.seen[parent]:
+		node.Seen = true
+		return node
+	}
+	w.path[parent], w.seen[parent] = true, true
+	node.Parents = w.parents(parent)
+	delete(w.path, parent)
+	return node
+}
+
+// Children returns the rules naming rule id in an if_sid or if_matched_sid
+// option, or one of its groups in an if_group or if_matched_group option,
+// ordered by id.
+func (t *Tree) Children(id int) []Child {
+	rule, ok := t.rules[id]
+	if !ok {
+		return nil
+	}
+	groups := make(map[string]bool, len(rule.Groups))
+	for _, g := range rule.Groups {
+		groups[g] = true
+	}
+	self := strconv.Itoa(id)
+
+	var children []Child
+	for _, r := range t.rules {
+		if r.Id == id {
+			continue
+		}
+	options:
+		for _, key := range parentKeys {
+			for _, value := range Option(r, key) {
+				isGroup := strings.HasSuffix(key, "_group")
+				if isGroup && groups[value] || !isGroup && value == self {
+					children = append(children, Child{Ref: t.ref(r.Id), Via: key, Value: value})
+					break options
+				}
+			}
+		}
+	}
+
+	sort.Slice(children, func(i, j int) bool { return children[i].Id < children[j].Id })
+	return children
+}
+
+func (t *Tree) ref(id int) Ref {
+	r := t.rules[id]
+	return Ref{
+		Id:          r.Id,
+		Level:       r.Level,
+		Description: r.Description,
+		File:        strings.TrimPrefix(r.RelativeDirname+"/"+r.Filename, "/"),
+	}
+}
+
+// Option returns the comma or space separated values of a rule option from
+// the details the manager reports for it.
+func Option(rule actions.WazuhRule, key string) []string {
+	var raw []string
+	switch v := rule.Details[key].(type) {
+	case nil:
+		return nil
+	case string:
+		raw = []string{v}
+	case []any:
+		for _, item := range v {
+			raw = append(raw, fmt.Sprint(item))
+		}
+	default:
+		raw = []string{fmt.Sprint(v)}
+	}
+
+	var values []string
+	for _, v := range raw {
+		values = append(values, strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n'
+		})...)
+	}
+	return values
+}
@@ -0,0 +1,119 @@
+package ruletree
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/EpykLab/wazctl/pkg/actions"
+)
+
+func catalogue() []actions.WazuhRule {
+	return []actions.WazuhRule{
+		{Id: 5700, Level: 0, Description: "SSHD messages grouped.", Groups: []string{"syslog", "sshd"}},
+		{Id: 5710, Level: 5, Groups: []string{"syslog", "sshd", "authentication_failed"}, Details: map[string]any{"if_sid": "5700"}},
+		{Id: 5711, Level: 0, Groups: []string{"syslog", "sshd"}, Details: map[string]any{"if_sid": "5700"}},
+		{Id: 5712, Level: 10, Groups: []string{"syslog", "sshd", "authentication_failures"}, Details: map[string]any{"if_matched_sid": "5710"}},
+		{Id: 100010, Level: 12, Groups: []string{"local"}, Details: map[string]any{"if_sid": "5712, 5711"}},
+		{Id: 100011, Level: 3, Groups: []string{"local"}, Details: map[string]any{"if_group": "authentication_failures"}},
+		{Id: 100012, Level: 3, Groups: []string{"local"}, Details: map[string]any{"if_sid": []any{"100012", "999999"}}},
+		{Id: 100013, Level: 3, Groups: []string{"loop"}, Details: map[string]any{"if_group": "loop"}},
+	}
+}
+
+func TestAncestry(t *testing.T) {
+	tree := New(catalogue())
+
+	nodes := tree.Ancestry(100010)
+	if len(nodes) != 2 {
+		t.Fatalf("Ancestry(100010) = %d parents, want 2", len(nodes))
+	}
+	first := nodes[0]
+	if first.Via != "if_sid" || first.Rule == nil || first.Rule.Id != 5712 {
+		t.Fatalf("first parent = %+v", first)
+	}
+	if len(first.Parents) != 1 || first.Parents[0].Via != "if_matched_sid" || first.Parents[0].Rule.Id != 5710 {
+		t.Fatalf("parents of 5712 = %+v", first.Parents)
+	}
+	if root := first.Parents[0].Parents; len(root) != 1 || root[0].Rule.Id != 5700 || root[0].Parents != nil {
+		t.Errorf("parents of 5710 = %+v", root)
+	}
+
+	group := tree.Ancestry(100011)
+	if len(group) != 1 || group[0].Value != "authentication_failures" || len(group[0].Members) != 1 || group[0].Members[0].Id != 5712 {
+		t.Fatalf("Ancestry(100011) = %+v", group)
+	}
+	// the group's members are walked like if_sid parents
+	var chain []int
+	for nodes := group[0].Parents; len(nodes) == 1 && nodes[0].Rule != nil; nodes = nodes[0].Parents {
+		chain = append(chain, nodes[0].Rule.Id)
+	}
+	if want := []int{5712, 5710, 5700}; !reflect.DeepEqual(chain, want) {
+		t.Errorf("ancestry through the group = %v, want %v", chain, want)
+	}
+
+	loop := tree.Ancestry(100013)
+	if len(loop) != 1 || len(loop[0].Parents) != 1 || !loop[0].Parents[0].Cycle {
+		t.Errorf("Ancestry(100013) = %+v, want its own group membership marked as a cycle", loop)
+	}
+
+	odd := tree.Ancestry(100012)
+	if len(odd) != 2 || !odd[0].Cycle || odd[1].Rule != nil || odd[1].Value != "999999" {
+		t.Errorf("Ancestry(100012) = %+v", odd)
+	}
+}
+
+func TestChildren(t *testing.T) {
+	tree := New(catalogue())
+
+	var got []int
+	for _, c := range tree.Children(5712) {
+		got = append(got, c.Id)
+	}
+	if want := []int{100010, 100011}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Children(5712) = %v, want %v", got, want)
+	}
+
+	got = nil
+	for _, c := range tree.Children(5700) {
+		got = append(got, c.Id)
+	}
+	if want := []int{5710, 5711}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Children(5700) = %v, want %v", got, want)
+	}
+}
+
+func TestAncestryLargeGroup(t *testing.T) {
+	rules := []actions.WazuhRule{
+		{Id: 5700, Groups: []string{"syslog"}},
+		{Id: 100100, Details: map[string]any{"if_group": "big"}},
+	}
+	for id := 100001; id <= 100008; id++ {
+		rules = append(rules, actions.WazuhRule{Id: id, Groups: []string{"big"}, Details: map[string]any{"if_sid": "5700"}})
+	}
+	tree := New(rules)
+
+	nodes := tree.Ancestry(100100)
+	if len(nodes) != 1 || len(nodes[0].Members) != 8 {
+		t.Fatalf("Ancestry(100100) = %+v, want one group of 8 members", nodes)
+	}
+	members := nodes[0].Parents
+	if len(members) != MaxWalkedMembers {
+		t.Fatalf("walked %d members, want %d", len(members), MaxWalkedMembers)
+	}
+
+	// the shared parent is walked below the first member only
+	var walked, seen int
+	for _, m := range members {
+		if len(m.Parents) != 1 || m.Parents[0].Rule == nil || m.Parents[0].Rule.Id != 5700 {
+			t.Fatalf("member %s parents = %+v", m.Value, m.Parents)
+		}
+		if m.Parents[0].Seen {
+			seen++
+		} else {
+			walked++
+		}
+	}
+	if walked != 1 || seen != MaxWalkedMembers-1 {
+		t.Errorf("5700 walked %d times and marked seen %d times, want 1 and %d", walked, seen, MaxWalkedMembers-1)
+	}
+}
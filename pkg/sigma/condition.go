@@ -0,0 +1,241 @@
+package sigma
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// expr is a node of a detection condition: andExpr, orExpr, notExpr or a
+// Literal.
+type expr any
+
+type (
+	andExpr []expr
+	orExpr  []expr
+	notExpr struct{ expr }
+)
+
+// MaxBranches bounds the number of Wazuh rules one Sigma rule expands to.
+const MaxBranches = 32
+
+var conditionToken = regexp.MustCompile(`\(|\)|[^\s()]+`)
+
+// conditionParser is a recursive descent parser for Sigma conditions:
+//
+//	or      = and { "or" and }
+//	and     = not { "and" not }
+//	not     = "not" not | primary
+//	primary = "(" or ")" | ("1" | "any" | "all") "of" pattern | identifier
+type conditionParser struct {
+	tokens   []string
+	pos      int
+	searches map[string]expr
+	names    []string
+}
+
+// parseCondition parses condition against the search expressions of a rule.
+func parseCondition(condition string, searches map[string]expr, names []string) (expr, error) {
+	if strings.Contains(condition, "|") {
+		return nil, fmt.Errorf("aggregations (%s) are not supported", strings.TrimSpace(condition[strings.Index(condition, "|")+1:]))
+	}
+
+	p := &conditionParser{tokens: conditionToken.FindAllString(condition, -1), searches: searches, names: names}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in condition", p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+func (p *conditionParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return strings.ToLower(p.tokens[p.pos])
+	}
+	return ""
+}
+
+func (p *conditionParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *conditionParser) or() (expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	terms := orExpr{left}
+	for p.peek() == "or" {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, right)
+	}
+	if len(terms) == 1 {
+		return left, nil
+	}
+	return terms, nil
+}
+
+func (p *conditionParser) and() (expr, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	terms := andExpr{left}
+	for p.peek() == "and" {
+		p.next()
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, right)
+	}
+	if len(terms) == 1 {
+		return left, nil
+	}
+	return terms, nil
+}
+
+func (p *conditionParser) not() (expr, error) {
+	if p.peek() == "not" {
+		p.next()
+		e, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{e}, nil
+	}
+	return p.primary()
+}
+
+func (p *conditionParser) primary() (expr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("condition ends unexpectedly")
+	}
+	raw := p.tokens[p.pos]
+
+	switch tok := p.next(); tok {
+	case "(":
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return e, nil
+	case "1", "any", "all":
+		if p.next() != "of" {
+			return nil, fmt.Errorf("expected \"of\" after %q", tok)
+		}
+		if p.pos >= len(p.tokens) {
+			return nil, fmt.Errorf("expected a pattern after %q of", tok)
+		}
+		pattern := p.tokens[p.pos]
+		p.next()
+		matched := p.matching(pattern)
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("%q matches no search identifier", pattern)
+		}
+		if tok == "all" {
+			return andExpr(matched), nil
+		}
+		return orExpr(matched), nil
+	case ")", "and", "or", "of":
+		return nil, fmt.Errorf("unexpected %q in condition", raw)
+	default:
+		e, ok := p.searches[raw]
+		if !ok {
+			return nil, fmt.Errorf("unknown search identifier %q", raw)
+		}
+		return e, nil
+	}
+}
+
+// matching returns the searches matching an "of" pattern; "them" matches
+// every search not starting with an underscore.
+func (p *conditionParser) matching(pattern string) []expr {
+	var out []expr
+	for _, name := range p.names {
+		if pattern == "them" {
+			if !strings.HasPrefix(name, "_") {
+				out = append(out, p.searches[name])
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			out = append(out, p.searches[name])
+		}
+	}
+	return out
+}
+
+// dnf rewrites e as a disjunction of conjunctions of literals, pushing
+// negations down to the literals. Each conjunction becomes one Wazuh rule.
+func dnf(e expr, negate bool) ([][]Literal, error) {
+	switch e := e.(type) {
+	case Literal:
+		if negate {
+			e.Negate = !e.Negate
+		}
+		return [][]Literal{{e}}, nil
+	case notExpr:
+		return dnf(e.expr, !negate)
+	case andExpr:
+		if negate {
+			return dnfUnion([]expr(e), true)
+		}
+		return dnfProduct([]expr(e), false)
+	case orExpr:
+		if negate {
+			return dnfProduct([]expr(e), true)
+		}
+		return dnfUnion([]expr(e), false)
+	}
+	return nil, fmt.Errorf("unexpected condition node %T", e)
+}
+
+func dnfUnion(terms []expr, negate bool) ([][]Literal, error) {
+	var out [][]Literal
+	for _, t := range terms {
+		branches, err := dnf(t, negate)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, branches...)
+		if len(out) > MaxBranches {
+			return nil, fmt.Errorf("condition expands to more than %d Wazuh rules", MaxBranches)
+		}
+	}
+	return out, nil
+}
+
+func dnfProduct(terms []expr, negate bool) ([][]Literal, error) {
+	out := [][]Literal{nil}
+	for _, t := range terms {
+		branches, err := dnf(t, negate)
+		if err != nil {
+			return nil, err
+		}
+		var next [][]Literal
+		for _, prefix := range out {
+			for _, b := range branches {
+				next = append(next, append(append([]Literal{}, prefix...), b...))
+			}
+		}
+		if len(next) > MaxBranches {
+			return nil, fmt.Errorf("condition expands to more than %d Wazuh rules", MaxBranches)
+		}
+		out = next
+	}
+	return out, nil
+}
@@ -0,0 +1,305 @@
+package sigma
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Issue is a part of a Sigma rule the converter could not translate.
+type Issue struct {
+	Where   string `json:"where"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Where, i.Message)
+}
+
+// Conversion is a Sigma rule translated into Wazuh rule conditions. Each
+// branch of the detection condition becomes one Wazuh rule.
+type Conversion struct {
+	Rule     *Rule
+	Level    int
+	Parent   LogsourceMapping
+	Branches [][]Literal
+	Issues   []Issue
+}
+
+// Convert translates the detection logic of rule through mapping. Parts of
+// the rule that cannot be expressed are reported as issues and the
+// condition branches using them dropped, so a Conversion with no branches
+// means nothing could be converted.
+func Convert(rule *Rule, mapping Mapping) *Conversion {
+	c := &Conversion{Rule: rule}
+
+	sigmaLevel := strings.ToLower(rule.Level)
+	if sigmaLevel == "" {
+		sigmaLevel = "medium"
+	}
+	level, ok := mapping.Levels[sigmaLevel]
+	if !ok {
+		level = mapping.Levels["medium"]
+		c.issue("level", fmt.Sprintf("level %q has no mapping, using %d", rule.Level, level))
+	}
+	c.Level = level
+
+	parent, ok := mapping.logsource(rule.Logsource)
+	if !ok {
+		c.issue("logsource", fmt.Sprintf("no mapping for logsource %q; add one to the mapping file", rule.Logsource))
+		return c
+	}
+	c.Parent = parent
+
+	names := rule.searchNames()
+	searches := make(map[string]expr, len(names))
+	for _, name := range names {
+		node := rule.Searches[name]
+		e, err := searchExpr(name, &node)
+		if err != nil {
+			e = Literal{Problem: err.Error()}
+		}
+		searches[name] = e
+	}
+
+	var branches [][]Literal
+	for _, condition := range rule.Conditions {
+		e, err := parseCondition(condition, searches, names)
+		if err == nil {
+			var b [][]Literal
+			if b, err = dnf(e, false); err == nil {
+				branches = append(branches, b...)
+				continue
+			}
+		}
+		c.issue("condition", fmt.Sprintf("%q: %s", condition, err))
+	}
+
+	dropped := 0
+	for _, branch := range branches {
+		if ok := c.mapBranch(branch, parent, mapping.Fields); ok {
+			c.Branches = append(c.Branches, branch)
+		} else {
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		c.issue("condition", fmt.Sprintf("%d of %d condition branches dropped", dropped, len(branches)))
+	}
+
+	return c
+}
+
+// mapBranch renames the fields of branch in place and reports whether every
+// literal of it can be expressed.
+func (c *Conversion) mapBranch(branch []Literal, parent LogsourceMapping, fields map[string]string) bool {
+	if len(branch) == 0 {
+		c.issue("condition", "a condition branch has no conditions")
+		return false
+	}
+
+	ok := true
+	for i := range branch {
+		lit := &branch[i]
+		where := lit.Field
+		if where == "" {
+			where = "keywords"
+		}
+
+		if lit.Problem == "" && lit.Field != "" {
+			name, mapped := parent.field(lit.Field, fields)
+			if !mapped {
+				lit.Problem = fmt.Sprintf("field has no mapping for logsource %q", c.Rule.Logsource)
+			}
+			lit.Field = name
+		}
+		if lit.Problem != "" {
+			c.issue(where, lit.Problem)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// issue records an issue once.
+func (c *Conversion) issue(where, message string) {
+	for _, i := range c.Issues {
+		if i.Where == where && i.Message == message {
+			return
+		}
+	}
+	c.Issues = append(c.Issues, Issue{Where: where, Message: message})
+}
+
+// XML renders the Wazuh rules of the conversion, one per branch, using ids
+// in order. len(ids) must be at least len(c.Branches).
+func (c *Conversion) XML(ids []int) string {
+	var b strings.Builder
+	r := c.Rule
+
+	fmt.Fprintf(&b, "<!-- Converted from Sigma rule %q", r.Title)
+	if r.Id != "" {
+		fmt.Fprintf(&b, " (%s)", r.Id)
+	}
+	b.WriteString(" by wazctl -->\n<group name=\"sigma,\">\n")
+
+	for i, branch := range c.Branches {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if len(c.Branches) > 1 {
+			fmt.Fprintf(&b, "  <!-- condition branch %d of %d -->\n", i+1, len(c.Branches))
+		}
+		fmt.Fprintf(&b, "  <rule id=\"%d\" level=\"%d\">\n", ids[i], c.Level)
+
+		switch {
+		case c.Parent.IfSid != "":
+			fmt.Fprintf(&b, "    <if_sid>%s</if_sid>\n", escape(c.Parent.IfSid))
+		case c.Parent.IfGroup != "":
+			fmt.Fprintf(&b, "    <if_group>%s</if_group>\n", escape(c.Parent.IfGroup))
+		}
+		if c.Parent.DecodedAs != "" {
+			fmt.Fprintf(&b, "    <decoded_as>%s</decoded_as>\n", escape(c.Parent.DecodedAs))
+		}
+
+		for _, group := range groupByTarget(branch) {
+			regex, negate := combine(group)
+			negated := ""
+			if negate {
+				negated = ` negate="yes"`
+			}
+			if group[0].Field == "" {
+				fmt.Fprintf(&b, "    <regex type=\"pcre2\"%s>%s</regex>\n", negated, escape(regex))
+			} else {
+				fmt.Fprintf(&b, "    <field name=\"%s\" type=\"pcre2\"%s>%s</field>\n", escape(group[0].Field), negated, escape(regex))
+			}
+		}
+
+		fmt.Fprintf(&b, "    <description>%s</description>\n", escape(r.Title))
+		if desc := strings.Join(strings.Fields(r.Description), " "); desc != "" {
+			fmt.Fprintf(&b, "    <info type=\"text\">%s</info>\n", escape(desc))
+		}
+		for _, ref := range r.References {
+			fmt.Fprintf(&b, "    <info type=\"link\">%s</info>\n", escape(ref))
+		}
+		if mitre := r.MitreIds(); len(mitre) > 0 {
+			b.WriteString("    <mitre>\n")
+			for _, id := range mitre {
+				fmt.Fprintf(&b, "      <id>%s</id>\n", escape(id))
+			}
+			b.WriteString("    </mitre>\n")
+		}
+		b.WriteString("  </rule>\n")
+	}
+
+	b.WriteString("</group>\n")
+	return b.String()
+}
+
+// groupByTarget groups the literals of a branch by field, keywords (the
+// empty field) included, in first appearance order.
+func groupByTarget(branch []Literal) [][]Literal {
+	var groups [][]Literal
+	index := make(map[string]int)
+	for _, lit := range branch {
+		i, ok := index[lit.Field]
+		if !ok {
+			i = len(groups)
+			index[lit.Field] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], lit)
+	}
+	return groups
+}
+
+// combine returns one PCRE2 expression for literals on the same target,
+// and whether the element has to be negated. Wazuh takes a single
+// condition per field, so several are joined with lookaheads.
+func combine(literals []Literal) (string, bool) {
+	if len(literals) == 1 {
+		return literalRegex(literals[0]), literals[0].Negate
+	}
+
+	var b strings.Builder
+	b.WriteString("(?s)^")
+	for _, lit := range literals {
+		if lit.Negate {
+			b.WriteString("(?!.*")
+		} else {
+			b.WriteString("(?=.*")
+		}
+		fmt.Fprintf(&b, "(?:%s))", literalRegex(lit))
+	}
+	return b.String(), false
+}
+
+// literalRegex translates the values and modifiers of a literal into a
+// PCRE2 expression. Sigma matches case-insensitively unless |cased is set,
+// and |re values are used verbatim.
+func literalRegex(lit Literal) string {
+	alternatives := make([]string, len(lit.Values))
+	for i, v := range lit.Values {
+		if lit.has("re") {
+			alternatives[i] = v
+		} else {
+			alternatives[i] = wildcardRegex(v)
+		}
+	}
+
+	body := alternatives[0]
+	if len(alternatives) > 1 {
+		body = "(?:" + strings.Join(alternatives, "|") + ")"
+	}
+	if lit.has("re") {
+		return body
+	}
+
+	contains, starts, ends := lit.has("contains"), lit.has("startswith"), lit.has("endswith")
+	if lit.Field == "" {
+		// keywords match anywhere in the log
+		contains = true
+	}
+	if !contains && !ends || starts {
+		body = "^" + body
+	}
+	if !contains && !starts || ends {
+		body += "$"
+	}
+
+	if !lit.has("cased") {
+		body = "(?i)" + body
+	}
+	return body
+}
+
+// wildcardRegex escapes a Sigma value, translating the * and ? wildcards.
+// A backslash escapes a following wildcard or backslash.
+func wildcardRegex(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		ch := value[i]
+		switch {
+		case ch == '\\' && i+1 < len(value) && strings.IndexByte(`*?\`, value[i+1]) >= 0:
+			i++
+			b.WriteString(quoteMeta(value[i]))
+		case ch == '*':
+			b.WriteString(".*")
+		case ch == '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(quoteMeta(ch))
+		}
+	}
+	return b.String()
+}
+
+func quoteMeta(ch byte) string {
+	if strings.IndexByte(`\.+*?()|[]{}^$`, ch) >= 0 {
+		return `\` + string(ch)
+	}
+	return string(ch)
+}
+
+// escape replaces the characters Wazuh's XML parser requires as entities.
+// Quotes are escaped too so the result is safe in attribute values.
+var escape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace
@@ -0,0 +1,162 @@
+package sigma
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mapping tells the converter how Sigma logsources, field names and levels
+// translate to a Wazuh deployment.
+type Mapping struct {
+	// Logsources in priority order; the first whose set keys all match the
+	// rule's logsource is used
+	Logsources []LogsourceMapping `yaml:"logsources"`
+	// Field names mapped for every logsource
+	Fields map[string]string `yaml:"fields"`
+	// Wazuh rule level for each Sigma level
+	Levels map[string]int `yaml:"levels"`
+}
+
+// LogsourceMapping is the parent rule and field naming of one kind of log.
+type LogsourceMapping struct {
+	Product  string `yaml:"product"`
+	Category string `yaml:"category"`
+	Service  string `yaml:"service"`
+
+	// Parent the converted rules hang off, one of the three
+	IfSid     string `yaml:"if_sid"`
+	IfGroup   string `yaml:"if_group"`
+	DecodedAs string `yaml:"decoded_as"`
+
+	// Field names of this logsource
+	Fields map[string]string `yaml:"fields"`
+	// Prefix for fields without an explicit mapping; without one, unmapped
+	// fields are reported as unsupported
+	FieldPrefix string `yaml:"field_prefix"`
+	// Lower case the first letter of prefixed field names, as the Windows
+	// eventchannel decoder does (CommandLine becomes commandLine)
+	LowerFirst bool `yaml:"lower_first"`
+
+	// logtest log format of sample events for the rule test scaffold
+	LogFormat string `yaml:"log_format"`
+}
+
+func (m LogsourceMapping) matches(l Logsource) bool {
+	return (m.Product == "" || strings.EqualFold(m.Product, l.Product)) &&
+		(m.Category == "" || strings.EqualFold(m.Category, l.Category)) &&
+		(m.Service == "" || strings.EqualFold(m.Service, l.Service))
+}
+
+// field returns the Wazuh name of a Sigma field.
+func (m LogsourceMapping) field(name string, global map[string]string) (string, bool) {
+	if f, ok := m.Fields[name]; ok {
+		return f, true
+	}
+	if f, ok := global[name]; ok {
+		return f, true
+	}
+	if m.FieldPrefix == "" {
+		return "", false
+	}
+	if m.LowerFirst {
+		r, size := utf8.DecodeRuneInString(name)
+		name = string(unicode.ToLower(r)) + name[size:]
+	}
+	return m.FieldPrefix + name, true
+}
+
+// DefaultMapping covers the Windows eventchannel, Linux auditd and syslog,
+// proxy and web server logsources with the stock Wazuh decoders.
+func DefaultMapping() Mapping {
+	windows := map[string]string{
+		"EventID":       "win.system.eventID",
+		"Channel":       "win.system.channel",
+		"Provider_Name": "win.system.providerName",
+		"Computer":      "win.system.computer",
+	}
+
+	return Mapping{
+		Logsources: []LogsourceMapping{
+			{Product: "windows", Category: "process_creation", IfGroup: "sysmon_event1", Fields: windows, FieldPrefix: "win.eventdata.", LowerFirst: true, LogFormat: "eventchannel"},
+			{Product: "windows", Service: "sysmon", IfGroup: "sysmon", Fields: windows, FieldPrefix: "win.eventdata.", LowerFirst: true, LogFormat: "eventchannel"},
+			{Product: "windows", Service: "security", IfGroup: "windows_security", Fields: windows, FieldPrefix: "win.eventdata.", LowerFirst: true, LogFormat: "eventchannel"},
+			{Product: "windows", IfGroup: "windows", Fields: windows, FieldPrefix: "win.eventdata.", LowerFirst: true, LogFormat: "eventchannel"},
+			{Product: "linux", Service: "auditd", IfGroup: "audit", FieldPrefix: "audit.", LogFormat: "audit", Fields: map[string]string{
+				"comm": "audit.command",
+				"a0":   "audit.execve.a0",
+				"a1":   "audit.execve.a1",
+				"a2":   "audit.execve.a2",
+				"a3":   "audit.execve.a3",
+			}},
+			{Product: "linux", Service: "sshd", IfGroup: "sshd"},
+			{Product: "linux", Service: "sudo", IfGroup: "sudo"},
+			{Product: "linux", IfGroup: "syslog"},
+			{Category: "proxy", IfGroup: "squid", Fields: map[string]string{
+				"c-ip":      "srcip",
+				"c-uri":     "url",
+				"sc-status": "id",
+			}},
+			{Category: "webserver", IfGroup: "web", Fields: map[string]string{
+				"c-ip":      "srcip",
+				"c-uri":     "url",
+				"cs-method": "protocol",
+				"sc-status": "id",
+			}},
+		},
+		Levels: map[string]int{
+			"informational": 3,
+			"low":           5,
+			"medium":        8,
+			"high":          12,
+			"critical":      15,
+		},
+	}
+}
+
+// LoadMapping reads a mapping file and layers it over the default mapping:
+// its logsources are tried first, and its fields and levels replace the
+// defaults of the same name.
+func LoadMapping(path string) (Mapping, error) {
+	mapping := DefaultMapping()
+	if path == "" {
+		return mapping, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return mapping, err
+	}
+
+	var custom Mapping
+	if err := yaml.Unmarshal(content, &custom); err != nil {
+		return mapping, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	mapping.Logsources = append(custom.Logsources, mapping.Logsources...)
+	if mapping.Fields == nil {
+		mapping.Fields = make(map[string]string)
+	}
+	for field, name := range custom.Fields {
+		mapping.Fields[field] = name
+	}
+	for level, value := range custom.Levels {
+		mapping.Levels[level] = value
+	}
+
+	return mapping, nil
+}
+
+// logsource returns the mapping for l.
+func (m Mapping) logsource(l Logsource) (LogsourceMapping, bool) {
+	for _, ls := range m.Logsources {
+		if ls.matches(l) {
+			return ls, true
+		}
+	}
+	return LogsourceMapping{}, false
+}
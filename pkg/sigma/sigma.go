@@ -0,0 +1,229 @@
+package sigma
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Logsource identifies the kind of log a Sigma rule applies to.
+type Logsource struct {
+	Category string `yaml:"category"`
+	Product  string `yaml:"product"`
+	Service  string `yaml:"service"`
+}
+
+func (l Logsource) String() string {
+	var parts []string
+	for _, p := range [][2]string{{"product", l.Product}, {"category", l.Category}, {"service", l.Service}} {
+		if p[1] != "" {
+			parts = append(parts, p[0]+"="+p[1])
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Rule is the subset of a Sigma rule the converter reads.
+type Rule struct {
+	Title       string    `yaml:"title"`
+	Id          string    `yaml:"id"`
+	Status      string    `yaml:"status"`
+	Description string    `yaml:"description"`
+	Author      string    `yaml:"author"`
+	References  []string  `yaml:"references"`
+	Tags        []string  `yaml:"tags"`
+	Level       string    `yaml:"level"`
+	Logsource   Logsource `yaml:"logsource"`
+
+	// Search identifiers of the detection block, by name
+	Searches map[string]yaml.Node `yaml:"-"`
+	// Conditions combining the searches; several conditions are alternatives
+	Conditions []string `yaml:"-"`
+}
+
+// Parse reads a single Sigma rule.
+func Parse(content []byte) (*Rule, error) {
+	var raw struct {
+		Rule      `yaml:",inline"`
+		Detection map[string]yaml.Node `yaml:"detection"`
+	}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	rule := raw.Rule
+	if rule.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	if len(raw.Detection) == 0 {
+		return nil, fmt.Errorf("detection is required")
+	}
+
+	rule.Searches = make(map[string]yaml.Node)
+	for name, node := range raw.Detection {
+		if name != "condition" {
+			rule.Searches[name] = node
+			continue
+		}
+		switch node.Kind {
+		case yaml.ScalarNode:
+			rule.Conditions = []string{node.Value}
+		case yaml.SequenceNode:
+			for _, c := range node.Content {
+				rule.Conditions = append(rule.Conditions, c.Value)
+			}
+		}
+	}
+	if len(rule.Conditions) == 0 {
+		return nil, fmt.Errorf("detection.condition is required")
+	}
+
+	return &rule, nil
+}
+
+// MitreIds returns the ATT&CK technique ids from the rule's attack.tNNNN
+// tags, upper cased as Wazuh expects them.
+func (r *Rule) MitreIds() []string {
+	var ids []string
+	for _, tag := range r.Tags {
+		id, ok := strings.CutPrefix(strings.ToLower(tag), "attack.t")
+		if ok && id != "" && id[0] >= '0' && id[0] <= '9' {
+			ids = append(ids, "T"+strings.ToUpper(id))
+		}
+	}
+	return ids
+}
+
+// searchNames returns the search identifiers in name order.
+func (r *Rule) searchNames() []string {
+	names := make([]string, 0, len(r.Searches))
+	for name := range r.Searches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Literal is a single condition on a field, or on the whole log when Field
+// is empty (Sigma keywords). Values are alternatives.
+type Literal struct {
+	Field     string
+	Modifiers []string
+	Values    []string
+	Negate    bool
+
+	// Why the literal cannot be expressed in a Wazuh rule, if it cannot
+	Problem string
+}
+
+func (l Literal) has(modifier string) bool {
+	for _, m := range l.Modifiers {
+		if m == modifier {
+			return true
+		}
+	}
+	return false
+}
+
+// supportedModifiers are the Sigma value modifiers the converter translates.
+var supportedModifiers = map[string]bool{
+	"contains":   true,
+	"startswith": true,
+	"endswith":   true,
+	"all":        true,
+	"re":         true,
+	"cased":      true,
+}
+
+// searchExpr turns a search identifier into an expression. A map is the
+// conjunction of its fields, a list of maps their disjunction and a list of
+// plain values a set of keywords.
+func searchExpr(name string, node *yaml.Node) (expr, error) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		var terms andExpr
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			terms = append(terms, fieldExpr(node.Content[i].Value, node.Content[i+1]))
+		}
+		return terms, nil
+	case yaml.SequenceNode:
+		if len(node.Content) > 0 && node.Content[0].Kind == yaml.MappingNode {
+			var alternatives orExpr
+			for _, item := range node.Content {
+				if item.Kind != yaml.MappingNode {
+					return nil, fmt.Errorf("%s: lists mixing maps and values are not supported", name)
+				}
+				e, err := searchExpr(name, item)
+				if err != nil {
+					return nil, err
+				}
+				alternatives = append(alternatives, e)
+			}
+			return alternatives, nil
+		}
+		values, err := scalars(node)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return Literal{Values: values}, nil
+	case yaml.ScalarNode:
+		return Literal{Values: []string{node.Value}}, nil
+	}
+	return nil, fmt.Errorf("%s: unexpected YAML node", name)
+}
+
+// fieldExpr builds the condition for one "field|modifier: values" entry.
+func fieldExpr(key string, value *yaml.Node) expr {
+	parts := strings.Split(key, "|")
+	lit := Literal{Field: parts[0], Modifiers: parts[1:]}
+
+	for _, m := range lit.Modifiers {
+		if !supportedModifiers[m] {
+			lit.Problem = fmt.Sprintf("modifier %q is not supported", m)
+			return lit
+		}
+	}
+
+	values, err := scalars(value)
+	switch {
+	case err != nil:
+		lit.Problem = err.Error()
+	case value.Kind == yaml.ScalarNode && value.Tag == "!!null":
+		lit.Problem = "null values (field absent) cannot be tested by a Wazuh rule"
+	default:
+		lit.Values = values
+	}
+	if lit.Problem != "" || !lit.has("all") || len(values) < 2 {
+		return lit
+	}
+
+	// field|all requires every value, which Wazuh expresses as one field
+	// condition per value
+	var terms andExpr
+	for _, v := range values {
+		single := lit
+		single.Values = []string{v}
+		terms = append(terms, single)
+	}
+	return terms
+}
+
+// scalars returns the values of a scalar or a list of scalars.
+func scalars(node *yaml.Node) ([]string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []string{node.Value}, nil
+	case yaml.SequenceNode:
+		var values []string
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("nested lists and maps are not supported as values")
+			}
+			values = append(values, item.Value)
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("nested maps are not supported as values")
+}
@@ -0,0 +1,252 @@
+package sigma
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EpykLab/wazctl/internal/rulexml"
+)
+
+const encodedPowershell = `title: Encoded PowerShell Command
+id: 5b4f6d89-1111-4c8a-9e0b-000000000001
+description: Detects PowerShell started with an encoded command
+author: Threat Intel
+references:
+  - https://example.com/encoded-powershell
+tags:
+  - attack.execution
+  - attack.t1059.001
+logsource:
+  product: windows
+  category: process_creation
+detection:
+  selection_img:
+    - Image|endswith: '\powershell.exe'
+    - OriginalFileName: PowerShell.EXE
+  selection_cli:
+    CommandLine|contains:
+      - ' -enc '
+      - ' -EncodedCommand '
+  filter_admin:
+    User|startswith: 'CORP\svc_'
+  condition: all of selection_* and not filter_admin
+level: high
+`
+
+func TestConvert(t *testing.T) {
+	rule, err := Parse([]byte(encodedPowershell))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	c := Convert(rule, DefaultMapping())
+	if len(c.Issues) != 0 {
+		t.Errorf("Convert() issues = %v", c.Issues)
+	}
+	if len(c.Branches) != 2 {
+		t.Fatalf("Convert() returned %d branches, want 2 (one per image alternative)", len(c.Branches))
+	}
+	if c.Level != 12 {
+		t.Errorf("level = %d, want 12", c.Level)
+	}
+
+	xml := c.XML([]int{100100, 100101})
+	for _, want := range []string{
+		`<rule id="100100" level="12">`,
+		`<if_group>sysmon_event1</if_group>`,
+		`<field name="win.eventdata.image" type="pcre2">(?i)\\powershell\.exe$</field>`,
+		`<field name="win.eventdata.originalFileName" type="pcre2">(?i)^PowerShell\.EXE$</field>`,
+		`<field name="win.eventdata.commandLine" type="pcre2">(?i)(?: -enc | -EncodedCommand )</field>`,
+		`<field name="win.eventdata.user" type="pcre2" negate="yes">(?i)^CORP\\svc_</field>`,
+		`<info type="link">https://example.com/encoded-powershell</info>`,
+		`<id>T1059.001</id>`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("XML() is missing %s\n%s", want, xml)
+		}
+	}
+
+	rules, err := rulexml.Parse([]byte(xml))
+	if err != nil {
+		t.Fatalf("generated XML does not parse: %v", err)
+	}
+	if len(rules) != 2 || rules[1].Id != "100101" || len(rules[0].Fields) != 3 {
+		t.Errorf("parsed rules = %+v", rules)
+	}
+}
+
+func TestConvertReportsUnsupported(t *testing.T) {
+	tests := []struct {
+		name      string
+		detection string
+		logsource string
+		branches  int
+		want      string
+	}{
+		{
+			name:      "aggregation",
+			detection: "sel:\n    EventID: 4625\n  condition: sel | count() by IpAddress > 10",
+			want:      "aggregations (count() by IpAddress > 10) are not supported",
+		},
+		{
+			name:      "modifier drops only its branch",
+			detection: "a:\n    CommandLine|base64offset|contains: foo\n  b:\n    Image|endswith: '\\cmd.exe'\n  condition: a or b",
+			branches:  1,
+			want:      `modifier "base64offset" is not supported`,
+		},
+		{
+			name:      "null value",
+			detection: "sel:\n    ParentImage: null\n  condition: sel",
+			want:      "null values",
+		},
+		{
+			name:      "unmapped field",
+			logsource: "category: proxy",
+			detection: "sel:\n    c-useragent: curl\n  condition: sel",
+			want:      "field has no mapping",
+		},
+		{
+			name:      "unknown logsource",
+			logsource: "product: macos",
+			detection: "sel: foo\n  condition: sel",
+			want:      "no mapping for logsource",
+		},
+		{
+			name:      "unknown search",
+			detection: "sel: foo\n  condition: sel and other",
+			want:      `unknown search identifier "other"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logsource := tt.logsource
+			if logsource == "" {
+				logsource = "product: windows"
+			}
+			content := "title: T\nlogsource:\n  " + logsource + "\ndetection:\n  " + tt.detection + "\n"
+			rule, err := Parse([]byte(content))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			c := Convert(rule, DefaultMapping())
+			if len(c.Branches) != tt.branches {
+				t.Errorf("Convert() returned %d branches, want %d", len(c.Branches), tt.branches)
+			}
+			var issues []string
+			for _, i := range c.Issues {
+				issues = append(issues, i.String())
+			}
+			if !strings.Contains(strings.Join(issues, "\n"), tt.want) {
+				t.Errorf("issues = %q, want one mentioning %q", issues, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditions(t *testing.T) {
+	tests := []struct {
+		condition string
+		branches  int
+	}{
+		{"a", 1},
+		{"a or b", 2},
+		{"a and b", 1},
+		{"1 of sel*", 2},
+		{"all of them", 1},
+		{"not (a or b)", 1},
+		{"not (a and b)", 2},
+		{"(a or b) and (c or sel1)", 4},
+	}
+
+	a, b, c := Literal{Field: "a", Values: []string{"1"}}, Literal{Field: "b", Values: []string{"2"}}, Literal{Field: "c", Values: []string{"3"}}
+	searches := map[string]expr{"a": a, "b": b, "c": c, "sel1": a, "sel2": b, "_hidden": c}
+	names := []string{"_hidden", "a", "b", "c", "sel1", "sel2"}
+
+	for _, tt := range tests {
+		e, err := parseCondition(tt.condition, searches, names)
+		if err != nil {
+			t.Errorf("parseCondition(%q) error = %v", tt.condition, err)
+			continue
+		}
+		branches, err := dnf(e, false)
+		if err != nil || len(branches) != tt.branches {
+			t.Errorf("dnf(%q) = %d branches, %v; want %d", tt.condition, len(branches), err, tt.branches)
+		}
+	}
+
+	e, _ := parseCondition("not (a and b)", searches, names)
+	branches, _ := dnf(e, false)
+	if !branches[0][0].Negate || !branches[1][0].Negate {
+		t.Errorf("negation was not pushed down to the literals: %+v", branches)
+	}
+
+	for _, bad := range []string{"a and", "(a or b", "1 a", "a b"} {
+		if _, err := parseCondition(bad, searches, names); err == nil {
+			t.Errorf("parseCondition(%q) accepted an invalid condition", bad)
+		}
+	}
+}
+
+func TestLiteralRegex(t *testing.T) {
+	tests := []struct {
+		lit  Literal
+		want string
+	}{
+		{Literal{Field: "f", Values: []string{"a*b?c"}}, `(?i)^a.*b.c$`},
+		{Literal{Field: "f", Values: []string{`C:\Windows\*`}}, `(?i)^C:\\Windows\*$`},
+		{Literal{Field: "f", Modifiers: []string{"startswith", "cased"}, Values: []string{"x.y"}}, `^x\.y`},
+		{Literal{Field: "f", Modifiers: []string{"re"}, Values: []string{`^\d+$`}}, `^\d+$`},
+		{Literal{Values: []string{"mimikatz"}}, `(?i)mimikatz`},
+	}
+
+	for _, tt := range tests {
+		if got := literalRegex(tt.lit); got != tt.want {
+			t.Errorf("literalRegex(%+v) = %q, want %q", tt.lit, got, tt.want)
+		}
+	}
+
+	got, negate := combine([]Literal{
+		{Field: "f", Modifiers: []string{"contains"}, Values: []string{"a"}},
+		{Field: "f", Modifiers: []string{"contains"}, Values: []string{"b"}, Negate: true},
+	})
+	if got != `(?s)^(?=.*(?:(?i)a))(?!.*(?:(?i)b))` || negate {
+		t.Errorf("combine() = %q, %v", got, negate)
+	}
+}
+
+func TestLoadMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.yaml")
+	content := `logsources:
+  - category: proxy
+    if_sid: "100001"
+    fields:
+      c-useragent: http.user_agent
+fields:
+  EventID: win.system.eventID
+levels:
+  high: 10
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mapping, err := LoadMapping(path)
+	if err != nil {
+		t.Fatalf("LoadMapping() error = %v", err)
+	}
+	if mapping.Levels["high"] != 10 || mapping.Levels["critical"] != 15 {
+		t.Errorf("levels = %v", mapping.Levels)
+	}
+
+	proxy, _ := mapping.logsource(Logsource{Category: "proxy"})
+	if proxy.IfSid != "100001" {
+		t.Errorf("custom logsource did not take precedence: %+v", proxy)
+	}
+	if f, ok := proxy.field("c-useragent", mapping.Fields); !ok || f != "http.user_agent" {
+		t.Errorf("field(c-useragent) = %q, %v", f, ok)
+	}
+}